@@ -0,0 +1,15 @@
+package basketspb
+
+const (
+	CommandChannel = "mallbots.baskets.commands"
+
+	CancelAbandonedBasketCommand = "basketsapi.CancelAbandonedBasket"
+)
+
+// CancelAbandonedBasket is scheduled by baskets for itself when a basket is
+// started; it is sent without a reply channel
+type CancelAbandonedBasket struct {
+	ID string
+}
+
+func (CancelAbandonedBasket) Key() string { return CancelAbandonedBasketCommand }
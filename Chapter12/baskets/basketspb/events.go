@@ -27,6 +27,11 @@ func Registrations(reg registry.Registry) error {
 		return err
 	}
 
+	// Basket commands
+	if err := serdes.NewJsonSerde(reg).Register(CancelAbandonedBasket{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -123,11 +123,6 @@ func (a Application) CheckoutBasket(ctx context.Context, checkout CheckoutBasket
 }
 
 func (a Application) AddItem(ctx context.Context, add AddItem) error {
-	basket, err := a.baskets.Load(ctx, add.ID)
-	if err != nil {
-		return err
-	}
-
 	product, err := a.products.Find(ctx, add.ProductID)
 	if err != nil {
 		return err
@@ -138,16 +133,9 @@ func (a Application) AddItem(ctx context.Context, add AddItem) error {
 		return err
 	}
 
-	err = basket.AddItem(store, product, add.Quantity)
-	if err != nil {
-		return err
-	}
-
-	if err = a.baskets.Save(ctx, basket); err != nil {
-		return err
-	}
-
-	return nil
+	return a.baskets.Update(ctx, add.ID, func(basket *domain.Basket) error {
+		return basket.AddItem(store, product, add.Quantity)
+	})
 }
 
 func (a Application) RemoveItem(ctx context.Context, remove RemoveItem) error {
@@ -156,21 +144,9 @@ func (a Application) RemoveItem(ctx context.Context, remove RemoveItem) error {
 		return err
 	}
 
-	basket, err := a.baskets.Load(ctx, remove.ID)
-	if err != nil {
-		return err
-	}
-
-	err = basket.RemoveItem(product, remove.Quantity)
-	if err != nil {
-		return err
-	}
-
-	if err = a.baskets.Save(ctx, basket); err != nil {
-		return err
-	}
-
-	return nil
+	return a.baskets.Update(ctx, remove.ID, func(basket *domain.Basket) error {
+		return basket.RemoveItem(product, remove.Quantity)
+	})
 }
 
 func (a Application) GetBasket(ctx context.Context, get GetBasket) (*domain.Basket, error) {
@@ -50,16 +50,9 @@ func TestApplication_AddItem(t *testing.T) {
 				},
 			},
 			on: func(f mocks) {
-				f.baskets.On("Load", context.Background(), "basket-id").Return(&domain.Basket{
-					Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
-					CustomerID: "customer-id",
-					PaymentID:  "payment-id",
-					Items:      make(map[string]domain.Item),
-					Status:     domain.BasketIsOpen,
-				}, nil)
 				f.products.On("Find", context.Background(), "product-id").Return(product, nil)
 				f.stores.On("Find", context.Background(), "store-id").Return(store, nil)
-				f.baskets.On("Save", context.Background(), mock.AnythingOfType("*domain.Basket")).Return(nil)
+				f.baskets.On("Update", context.Background(), "basket-id", mock.Anything).Return(updateBasket(openBasket(), nil))
 			},
 		},
 		"NoBasket": {
@@ -72,7 +65,9 @@ func TestApplication_AddItem(t *testing.T) {
 				},
 			},
 			on: func(f mocks) {
-				f.baskets.On("Load", context.Background(), "basket-id").Return(nil, fmt.Errorf("no basket"))
+				f.products.On("Find", context.Background(), "product-id").Return(product, nil)
+				f.stores.On("Find", context.Background(), "store-id").Return(store, nil)
+				f.baskets.On("Update", context.Background(), "basket-id", mock.Anything).Return(fmt.Errorf("no basket"))
 			},
 			wantErr: true,
 		},
@@ -86,13 +81,6 @@ func TestApplication_AddItem(t *testing.T) {
 				},
 			},
 			on: func(f mocks) {
-				f.baskets.On("Load", context.Background(), "basket-id").Return(&domain.Basket{
-					Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
-					CustomerID: "customer-id",
-					PaymentID:  "payment-id",
-					Items:      make(map[string]domain.Item),
-					Status:     domain.BasketIsOpen,
-				}, nil)
 				f.products.On("Find", context.Background(), "product-id").Return(nil, fmt.Errorf("no product"))
 			},
 			wantErr: true,
@@ -107,13 +95,6 @@ func TestApplication_AddItem(t *testing.T) {
 				},
 			},
 			on: func(f mocks) {
-				f.baskets.On("Load", context.Background(), "basket-id").Return(&domain.Basket{
-					Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
-					CustomerID: "customer-id",
-					PaymentID:  "payment-id",
-					Items:      make(map[string]domain.Item),
-					Status:     domain.BasketIsOpen,
-				}, nil)
 				f.products.On("Find", context.Background(), "product-id").Return(product, nil)
 				f.stores.On("Find", context.Background(), "store-id").Return(nil, fmt.Errorf("no store"))
 			},
@@ -129,16 +110,9 @@ func TestApplication_AddItem(t *testing.T) {
 				},
 			},
 			on: func(f mocks) {
-				f.baskets.On("Load", context.Background(), "basket-id").Return(&domain.Basket{
-					Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
-					CustomerID: "customer-id",
-					PaymentID:  "payment-id",
-					Items:      make(map[string]domain.Item),
-					Status:     domain.BasketIsOpen,
-				}, nil)
 				f.products.On("Find", context.Background(), "product-id").Return(product, nil)
 				f.stores.On("Find", context.Background(), "store-id").Return(store, nil)
-				f.baskets.On("Save", context.Background(), mock.AnythingOfType("*domain.Basket")).Return(fmt.Errorf("save failed"))
+				f.baskets.On("Update", context.Background(), "basket-id", mock.Anything).Return(updateBasket(openBasket(), fmt.Errorf("save failed")))
 			},
 			wantErr: true,
 		},
@@ -584,7 +558,7 @@ func TestApplication_RemoveItem(t *testing.T) {
 			},
 			on: func(m mocks) {
 				m.products.On("Find", context.Background(), product.ID).Return(product, nil)
-				m.baskets.On("Load", context.Background(), "basket-id").Return(&domain.Basket{
+				m.baskets.On("Update", context.Background(), "basket-id", mock.Anything).Return(updateBasket(&domain.Basket{
 					Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
 					CustomerID: "customer-id",
 					PaymentID:  "",
@@ -592,8 +566,7 @@ func TestApplication_RemoveItem(t *testing.T) {
 						product.ID: item,
 					},
 					Status: domain.BasketIsOpen,
-				}, nil)
-				m.baskets.On("Save", context.Background(), mock.AnythingOfType("*domain.Basket")).Return(nil)
+				}, nil))
 			},
 		},
 		"NoProduct": {
@@ -621,7 +594,7 @@ func TestApplication_RemoveItem(t *testing.T) {
 			},
 			on: func(m mocks) {
 				m.products.On("Find", context.Background(), product.ID).Return(product, nil)
-				m.baskets.On("Load", context.Background(), "basket-id").Return(nil, fmt.Errorf("no basket"))
+				m.baskets.On("Update", context.Background(), "basket-id", mock.Anything).Return(fmt.Errorf("no basket"))
 			},
 			wantErr: true,
 		},
@@ -636,7 +609,7 @@ func TestApplication_RemoveItem(t *testing.T) {
 			},
 			on: func(m mocks) {
 				m.products.On("Find", context.Background(), product.ID).Return(product, nil)
-				m.baskets.On("Load", context.Background(), "basket-id").Return(&domain.Basket{
+				m.baskets.On("Update", context.Background(), "basket-id", mock.Anything).Return(updateBasket(&domain.Basket{
 					Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
 					CustomerID: "customer-id",
 					PaymentID:  "",
@@ -644,8 +617,7 @@ func TestApplication_RemoveItem(t *testing.T) {
 						product.ID: item,
 					},
 					Status: domain.BasketIsOpen,
-				}, nil)
-				m.baskets.On("Save", context.Background(), mock.AnythingOfType("*domain.Basket")).Return(fmt.Errorf("save failed"))
+				}, fmt.Errorf("save failed")))
 			},
 			wantErr: true,
 		},
@@ -787,3 +759,24 @@ func TestApplication_StartBasket(t *testing.T) {
 		})
 	}
 }
+
+func openBasket() *domain.Basket {
+	return &domain.Basket{
+		Aggregate:  es.NewAggregate("basket-id", domain.BasketAggregate),
+		CustomerID: "customer-id",
+		PaymentID:  "payment-id",
+		Items:      make(map[string]domain.Item),
+		Status:     domain.BasketIsOpen,
+	}
+}
+
+// updateBasket runs the command given to BasketRepository.Update against the
+// basket, returning saveErr when the command succeeds
+func updateBasket(basket *domain.Basket, saveErr error) func(context.Context, string, func(*domain.Basket) error) error {
+	return func(_ context.Context, _ string, fn func(*domain.Basket) error) error {
+		if err := fn(basket); err != nil {
+			return err
+		}
+		return saveErr
+	}
+}
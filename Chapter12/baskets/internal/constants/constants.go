@@ -1,8 +1,13 @@
 package constants
 
+import "time"
+
 // ServiceName The name of this module/service
 const ServiceName = "baskets"
 
+// AbandonedBasketTimeout is how long a basket may stay open before it is canceled
+const AbandonedBasketTimeout = 30 * time.Minute
+
 // GRPC Service Names
 const (
 	StoresServiceName    = "STORES"
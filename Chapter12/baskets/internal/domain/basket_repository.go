@@ -7,4 +7,5 @@ import (
 type BasketRepository interface {
 	Load(ctx context.Context, basketID string) (*Basket, error)
 	Save(ctx context.Context, basket *Basket) error
+	Update(ctx context.Context, basketID string, fn func(*Basket) error) error
 }
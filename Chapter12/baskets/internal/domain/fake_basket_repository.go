@@ -28,6 +28,19 @@ func (r *FakeBasketRepository) Save(ctx context.Context, basket *Basket) error {
 	return nil
 }
 
+func (r *FakeBasketRepository) Update(ctx context.Context, basketID string, fn func(*Basket) error) error {
+	basket, err := r.Load(ctx, basketID)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(basket); err != nil {
+		return err
+	}
+
+	return r.Save(ctx, basket)
+}
+
 func (r *FakeBasketRepository) Reset(baskets ...*Basket) {
 	r.baskets = make(map[string]*Basket)
 
@@ -50,6 +50,20 @@ func (_m *MockBasketRepository) Save(ctx context.Context, basket *Basket) error
 	return r0
 }
 
+// Update provides a mock function with given fields: ctx, basketID, fn
+func (_m *MockBasketRepository) Update(ctx context.Context, basketID string, fn func(*Basket) error) error {
+	ret := _m.Called(ctx, basketID, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(*Basket) error) error); ok {
+		r0 = rf(ctx, basketID, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewMockBasketRepository interface {
 	mock.TestingT
 	Cleanup(func())
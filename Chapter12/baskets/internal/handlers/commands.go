@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"eda-in-golang/baskets/basketspb"
+	"eda-in-golang/baskets/internal/application"
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/errorsotel"
+	"eda-in-golang/internal/registry"
+)
+
+type commandHandlers struct {
+	app application.App
+}
+
+// NewCommandHandlers handles the commands baskets sends to itself; none of them
+// expect a reply, so no reply publisher is used
+func NewCommandHandlers(reg registry.Registry, app application.App, mws ...am.MessageHandlerMiddleware) am.MessageHandler {
+	return am.NewCommandHandler(reg, nil, commandHandlers{
+		app: app,
+	}, mws...)
+}
+
+func RegisterCommandHandlers(subscriber am.MessageSubscriber, handlers am.MessageHandler) error {
+	_, err := subscriber.Subscribe(basketspb.CommandChannel, handlers, am.MessageFilter{
+		basketspb.CancelAbandonedBasketCommand,
+	}, am.GroupName("baskets-commands"))
+	return err
+}
+
+func (h commandHandlers) HandleCommand(ctx context.Context, cmd ddd.Command) (reply ddd.Reply, err error) {
+	span := trace.SpanFromContext(ctx)
+	defer func(started time.Time) {
+		if err != nil {
+			span.AddEvent(
+				"Encountered an error handling command",
+				trace.WithAttributes(errorsotel.ErrAttrs(err)...),
+			)
+		}
+		span.AddEvent("Handled command", trace.WithAttributes(
+			attribute.Int64("TookMS", time.Since(started).Milliseconds()),
+		))
+	}(time.Now())
+
+	span.AddEvent("Handling command", trace.WithAttributes(
+		attribute.String("Command", cmd.CommandName()),
+	))
+
+	switch cmd.CommandName() {
+	case basketspb.CancelAbandonedBasketCommand:
+		return nil, h.doCancelAbandonedBasket(ctx, cmd)
+	}
+
+	return nil, nil
+}
+
+func (h commandHandlers) doCancelAbandonedBasket(ctx context.Context, cmd ddd.Command) error {
+	payload := cmd.Payload().(*basketspb.CancelAbandonedBasket)
+
+	basket, err := h.app.GetBasket(ctx, application.GetBasket{ID: payload.ID})
+	if err != nil {
+		return err
+	}
+	// the basket was checked out or canceled in time
+	if !basket.IsCancellable() {
+		return nil
+	}
+
+	return h.app.CancelBasket(ctx, application.CancelBasket{ID: payload.ID})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"eda-in-golang/baskets/basketspb"
+	"eda-in-golang/baskets/internal/application"
+	"eda-in-golang/baskets/internal/domain"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/es"
+)
+
+func TestCommandHandlers_CancelAbandonedBasket(t *testing.T) {
+	tests := map[string]struct {
+		status   domain.BasketStatus
+		canceled bool
+	}{
+		"Open":       {status: domain.BasketIsOpen, canceled: true},
+		"CheckedOut": {status: domain.BasketIsCheckedOut},
+		"Canceled":   {status: domain.BasketIsCanceled},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := &application.MockApp{}
+			app.On("GetBasket", mock.Anything, application.GetBasket{ID: "basket-id"}).Return(&domain.Basket{
+				Aggregate: es.NewAggregate("basket-id", domain.BasketAggregate),
+				Status:    tc.status,
+			}, nil)
+			if tc.canceled {
+				app.On("CancelBasket", mock.Anything, application.CancelBasket{ID: "basket-id"}).Return(nil)
+			}
+
+			h := commandHandlers{app: app}
+			_, err := h.HandleCommand(context.Background(), ddd.NewCommand(basketspb.CancelAbandonedBasketCommand,
+				&basketspb.CancelAbandonedBasket{ID: "basket-id"},
+			))
+			assert.NoError(t, err)
+			app.AssertExpectations(t)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"eda-in-golang/baskets/basketspb"
+	"eda-in-golang/baskets/internal/constants"
 	"eda-in-golang/baskets/internal/domain"
 	"eda-in-golang/internal/am"
 	"eda-in-golang/internal/ddd"
@@ -16,13 +17,15 @@ import (
 
 type domainHandlers[T ddd.Event] struct {
 	publisher am.EventPublisher
+	commands  am.CommandPublisher
 }
 
 var _ ddd.EventHandler[ddd.Event] = (*domainHandlers[ddd.Event])(nil)
 
-func NewDomainEventHandlers(publisher am.EventPublisher) ddd.EventHandler[ddd.Event] {
+func NewDomainEventHandlers(publisher am.EventPublisher, commands am.CommandPublisher) ddd.EventHandler[ddd.Event] {
 	return &domainHandlers[ddd.Event]{
 		publisher: publisher,
+		commands:  commands,
 	}
 }
 
@@ -65,12 +68,22 @@ func (h domainHandlers[T]) HandleEvent(ctx context.Context, event T) (err error)
 
 func (h domainHandlers[T]) onBasketStarted(ctx context.Context, event ddd.Event) error {
 	basket := event.Payload().(*domain.Basket)
-	return h.publisher.Publish(ctx, basketspb.BasketAggregateChannel,
+	err := h.publisher.Publish(ctx, basketspb.BasketAggregateChannel,
 		ddd.NewEvent(basketspb.BasketStartedEvent, &basketspb.BasketStarted{
 			Id:         basket.ID(),
 			CustomerId: basket.CustomerID,
 		}),
 	)
+	if err != nil {
+		return err
+	}
+
+	// cancel the basket later on if it has not been checked out by then
+	return h.commands.Publish(am.DeliverAfter(ctx, constants.AbandonedBasketTimeout), basketspb.CommandChannel,
+		ddd.NewCommand(basketspb.CancelAbandonedBasketCommand, basketspb.CancelAbandonedBasket{
+			ID: basket.ID(),
+		}),
+	)
 }
 
 func (h domainHandlers[T]) onBasketCanceled(ctx context.Context, event ddd.Event) error {
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE events
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+
+ALTER TABLE snapshots
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE snapshots
+  DROP COLUMN IF EXISTS schema_version;
+
+ALTER TABLE events
+  DROP COLUMN IF EXISTS schema_version;
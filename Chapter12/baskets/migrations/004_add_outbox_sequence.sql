@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE outbox
+  ADD COLUMN seq bigserial NOT NULL;
+
+CREATE INDEX unpublished_seq_idx ON outbox (seq) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS unpublished_seq_idx;
+
+ALTER TABLE outbox
+  DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE events
+  ADD COLUMN metadata bytea NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE events
+  DROP COLUMN IF EXISTS metadata;
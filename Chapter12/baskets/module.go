@@ -22,7 +22,6 @@ import (
 	"eda-in-golang/internal/ddd"
 	"eda-in-golang/internal/di"
 	"eda-in-golang/internal/es"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -53,7 +52,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddSingleton(constants.DomainDispatcherKey, func(c di.Container) (any, error) {
 		return ddd.NewEventDispatcher[ddd.Event](), nil
 	})
@@ -68,6 +67,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			stream,
 			amotel.OtelMessageContextInjector(),
 			sentCounter,
+			am.DelayedPublisher(),
 			tm.OutboxPublisher(outboxStore),
 		), nil
 	})
@@ -78,6 +78,12 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			amprom.ReceivedMessagesCounter(constants.ServiceName),
 		), nil
 	})
+	container.AddScoped(constants.CommandPublisherKey, func(c di.Container) (any, error) {
+		return am.NewCommandPublisher(
+			c.Get(constants.RegistryKey).(registry.Registry),
+			c.Get(constants.MessagePublisherKey).(am.MessagePublisher),
+		), nil
+	})
 	container.AddScoped(constants.EventPublisherKey, func(c di.Container) (any, error) {
 		return am.NewEventPublisher(
 			c.Get(constants.RegistryKey).(registry.Registry),
@@ -86,8 +92,21 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
+	snapshotOptions, err := pg.SnapshotStrategies(svc.Config().Snapshots, domain.BasketAggregate)
+	if err != nil {
+		return err
+	}
+	if svc.Config().Snapshots.Async {
+		reg := container.Get(constants.RegistryKey).(registry.Registry)
+		snapshotter := pg.NewSnapshotter(constants.SnapshotsTableName, svc.DB(), reg,
+			pg.NewEventStore(constants.EventsTableName, svc.DB(), reg),
+			svc.Logger(),
+		)
+		snapshotOptions = append(snapshotOptions, pg.AsyncSnapshots(snapshotter))
+		svc.Waiter().Add(snapshotter.Run)
+	}
 	container.AddScoped(constants.BasketsRepoKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
 		reg := c.Get(constants.RegistryKey).(registry.Registry)
@@ -96,7 +115,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			reg,
 			es.AggregateStoreWithMiddleware(
 				pg.NewEventStore(constants.EventsTableName, tx, reg),
-				pg.NewSnapshotStore(constants.SnapshotsTableName, tx, reg),
+				pg.NewSnapshotStore(constants.SnapshotsTableName, tx, reg, snapshotOptions...),
 			),
 		), nil
 	})
@@ -135,19 +154,32 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		), basketsStarted, basketsCheckedOut, basketsCanceled), nil
 	})
 	container.AddScoped(constants.DomainEventHandlersKey, func(c di.Container) (any, error) {
-		return handlers.NewDomainEventHandlers(c.Get(constants.EventPublisherKey).(am.EventPublisher)), nil
+		return handlers.NewDomainEventHandlers(
+			c.Get(constants.EventPublisherKey).(am.EventPublisher),
+			c.Get(constants.CommandPublisherKey).(am.CommandPublisher),
+		), nil
 	})
 	container.AddScoped(constants.IntegrationEventHandlersKey, func(c di.Container) (any, error) {
 		return handlers.NewIntegrationEventHandlers(
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.StoresRepoKey).(domain.StoreCacheRepository),
 			c.Get(constants.ProductsRepoKey).(domain.ProductCacheRepository),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.IntegrationEventHandlersKey),
+		), nil
+	})
+	container.AddScoped(constants.CommandHandlersKey, func(c di.Container) (any, error) {
+		return handlers.NewCommandHandlers(
+			c.Get(constants.RegistryKey).(registry.Registry),
+			c.Get(constants.ApplicationKey).(application.App),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.CommandHandlersKey),
 		), nil
 	})
 	outboxProcessor := tm.NewOutboxProcessor(
+		constants.ServiceName,
 		stream,
 		pg.NewOutboxStore(constants.OutboxTableName, svc.DB()),
+		svc.Logger(),
+		pg.OutboxProcessorOptions(svc.Config().Outbox, constants.OutboxTableName, svc.DB())...,
 	)
 
 	// setup Driver adapters
@@ -164,7 +196,17 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	if err = handlers.RegisterIntegrationEventHandlersTx(container); err != nil {
 		return err
 	}
+	if err = handlers.RegisterCommandHandlersTx(container); err != nil {
+		return err
+	}
 	startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
 	return
 }
 
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/nats-io/nats.go"
+
+	"eda-in-golang/internal/jetstream"
+	"eda-in-golang/internal/logger"
+	"eda-in-golang/internal/postgres"
+	"eda-in-golang/internal/tm"
+	"eda-in-golang/internal/waiter"
+)
+
+var pgConn = flag.String("db", "host=localhost dbname=mallbots user=mallbots_user password=mallbots_pass", "Sets the connection string of the module database")
+var module = flag.String("module", "ordering", "Sets the module, and database schema, the inbox belongs to")
+var natsURL = flag.String("nats", "nats://localhost:4222", "Sets the URL of the NATS server")
+var streamName = flag.String("stream", "mallbots", "Sets the name of the JetStream stream to publish retried messages to")
+var limit = flag.Int("limit", 20, "Maximum number of failed entries to list")
+var retry = flag.String("retry", "", "ID of a failed message to publish again; every subscriber receives it, but handlers that completed it skip it")
+var handler = flag.String("handler", "", "Handler the failed message is retried for (required with -retry)")
+
+func main() {
+	log.SetFlags(log.Ltime)
+	if err := run(); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+func run() error {
+	flag.Parse()
+
+	if *retry != "" && *handler == "" {
+		return fmt.Errorf("the -handler flag is required with -retry")
+	}
+
+	db, err := sql.Open("pgx", *pgConn)
+	if err != nil {
+		return err
+	}
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+
+	store := postgres.NewInboxStore(*module+".inbox", db, db)
+
+	wait := waiter.New(waiter.CatchSignals())
+
+	wait.Add(func(ctx context.Context) error {
+		defer wait.CancelFunc()()
+
+		if *retry == "" {
+			return list(ctx, tm.NewInboxAdmin(store, nil))
+		}
+
+		return retryFailed(ctx, store)
+	})
+
+	return wait.Wait()
+}
+
+func list(ctx context.Context, admin tm.InboxAdmin) error {
+	entries, err := admin.ListFailed(ctx, *limit)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		log.Printf("%s %s %s for %s failed %d times, last at %s: %s\n", entry.Message.Subject(), entry.Message.MessageName(),
+			entry.Message.ID(), entry.Handler, entry.Attempts, entry.UpdatedAt.Format(time.RFC3339), entry.LastError)
+	}
+	log.Printf("found %d failed entries\n", len(entries))
+
+	return nil
+}
+
+func retryFailed(ctx context.Context, store postgres.InboxStore) error {
+	nc, err := nats.Connect(*natsURL)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return err
+	}
+
+	stream := jetstream.NewStream(*streamName, js, logger.New(logger.LogConfig{
+		Environment: "development",
+		LogLevel:    logger.INFO,
+	}))
+
+	if err = tm.NewInboxAdmin(store, stream).Retry(ctx, *retry, *handler); err != nil {
+		return err
+	}
+
+	// wait for the stream to acknowledge the asynchronously published message
+	select {
+	case <-js.PublishAsyncComplete():
+		log.Printf("published %s again for %s\n", *retry, *handler)
+		return nil
+	case <-time.After(30 * time.Second):
+		return fmt.Errorf("timed out waiting for the message to be acknowledged")
+	}
+}
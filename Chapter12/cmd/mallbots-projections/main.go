@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+
+	"eda-in-golang/internal/postgres"
+	"eda-in-golang/internal/projection"
+	"eda-in-golang/internal/waiter"
+)
+
+var pgConn = flag.String("db", "host=localhost dbname=mallbots user=mallbots_user password=mallbots_pass", "Sets the connection string of the module database")
+var module = flag.String("module", "stores", "Sets the module, and database schema, the projections belong to")
+var rebuild = flag.String("rebuild", "", "Name of a projection to reset and rebuild from the event store; its read model is emptied first and stays incomplete until the projection is live again")
+var watch = flag.Bool("watch", false, "Keep reporting the progress of the projections until they are all live")
+var interval = flag.Duration("interval", 2*time.Second, "How often progress is reported when watching")
+
+func main() {
+	log.SetFlags(log.Ltime)
+	if err := run(); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+func run() error {
+	flag.Parse()
+
+	db, err := sql.Open("pgx", *pgConn)
+	if err != nil {
+		return err
+	}
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+
+	checkpoints := postgres.NewCheckpointStore(*module+".projection_checkpoints", db)
+
+	wait := waiter.New(waiter.CatchSignals())
+
+	wait.Add(func(ctx context.Context) error {
+		defer wait.CancelFunc()()
+
+		if *rebuild != "" {
+			if err := checkpoints.RequestRebuild(ctx, *rebuild); err != nil {
+				return err
+			}
+			log.Printf("requested a rebuild of the %s projection; reads will be incomplete until it is live\n", *rebuild)
+			if !*watch {
+				return nil
+			}
+			// give the projection time to pick up the request
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(*interval):
+			}
+		}
+
+		for {
+			live, err := report(ctx, db, checkpoints)
+			if err != nil || live || !*watch {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(*interval):
+			}
+		}
+	})
+
+	return wait.Wait()
+}
+
+// report logs the progress of each projection and whether they are all live
+func report(ctx context.Context, db *sql.DB, checkpoints projection.CheckpointStore) (bool, error) {
+	const query = "SELECT coalesce(max(global_position), 0) FROM %s.events"
+
+	var head int64
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(query, *module)).Scan(&head); err != nil {
+		return false, err
+	}
+
+	list, err := checkpoints.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(list) == 0 {
+		log.Printf("no %s projections have been built\n", *module)
+		return false, nil
+	}
+
+	live := true
+	for _, checkpoint := range list {
+		status := string(checkpoint.Status)
+		if checkpoint.RebuildRequested {
+			status = "rebuild requested"
+		}
+		if checkpoint.Status != projection.StatusLive || checkpoint.RebuildRequested {
+			live = false
+		}
+		log.Printf("%s %s: %d events projected since %s, at position %d of %d, updated %s\n",
+			checkpoint.Name, status, checkpoint.Handled, checkpoint.StartedAt.Format(time.RFC3339),
+			checkpoint.Position, head, checkpoint.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+
+	return live, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/amotel"
+	"eda-in-golang/internal/jetstream"
+	"eda-in-golang/internal/logger"
+	"eda-in-golang/internal/waiter"
+)
+
+var natsURL = flag.String("nats", "nats://localhost:4222", "Sets the URL of the NATS server")
+var streamName = flag.String("stream", "mallbots", "Sets the name of the JetStream stream to read from")
+var subject = flag.String("subject", "", "Subject to read messages from; wildcards are allowed (required)")
+var destination = flag.String("to", "", "Topic to republish messages to; every group subscribed to it receives the messages (required unless -dry-run)")
+var keepIDs = flag.Bool("keep-ids", false, "Keep the IDs of replayed messages that are not dead letters; inboxes which already handled them skip them")
+var names = flag.String("names", "", "Comma separated list of message names to replay")
+var since = flag.Duration("since", 0, "Replay messages stored within this duration, e.g. 6h")
+var fromTime = flag.String("from-time", "", "Replay messages stored at or after this RFC3339 time")
+var untilTime = flag.String("until-time", "", "Replay messages stored at or before this RFC3339 time")
+var fromSeq = flag.Uint64("from-seq", 0, "Replay messages starting at this stream sequence")
+var untilSeq = flag.Uint64("until-seq", 0, "Replay messages up to and including this stream sequence")
+var limit = flag.Int("limit", 0, "Maximum number of messages to replay [0: no limit]")
+var dryRun = flag.Bool("dry-run", false, "List the messages that would be replayed without publishing them")
+
+func main() {
+	log.SetFlags(log.Ltime)
+	if err := run(); err != nil {
+		log.Println(err.Error())
+	}
+	log.Println("replay shutdown")
+}
+
+func run() error {
+	flag.Parse()
+
+	if *subject == "" {
+		return fmt.Errorf("the -subject flag is required")
+	}
+	if *destination == "" && !*dryRun {
+		return fmt.Errorf("the -to flag is required")
+	}
+
+	options, err := readerOptions()
+	if err != nil {
+		return err
+	}
+
+	nc, err := nats.Connect(*natsURL)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return err
+	}
+
+	stream := jetstream.NewStream(*streamName, js, logger.New(logger.LogConfig{
+		Environment: "development",
+		LogLevel:    logger.INFO,
+	}))
+
+	wait := waiter.New(waiter.CatchSignals())
+
+	wait.Add(func(ctx context.Context) error {
+		defer wait.CancelFunc()()
+
+		if *dryRun {
+			return list(ctx, stream, options)
+		}
+
+		return replay(ctx, stream, js, options)
+	})
+
+	return wait.Wait()
+}
+
+func list(ctx context.Context, stream *jetstream.Stream, options []am.ReaderOption) error {
+	count := 0
+	err := stream.ReadMessages(ctx, *subject, func(msg am.Message) error {
+		count++
+		if handler, ok := msg.Metadata().Get(am.DeadLetterHandlerHdr).(string); ok {
+			log.Printf("%s %s %s sent at %s, dead-lettered by %s from %s\n", msg.Subject(), msg.MessageName(), msg.ID(),
+				msg.SentAt().Format(time.RFC3339), handler, msg.Metadata().Get(am.DeadLetterSubjectHdr))
+			return nil
+		}
+		log.Printf("%s %s %s sent at %s\n", msg.Subject(), msg.MessageName(), msg.ID(), msg.SentAt().Format(time.RFC3339))
+		return nil
+	}, options...)
+	log.Printf("found %d messages to replay\n", count)
+	return err
+}
+
+func replay(ctx context.Context, stream *jetstream.Stream, js nats.JetStreamContext, options []am.ReaderOption) error {
+	replayer := am.NewMessageReplayer(stream, stream, amotel.OtelMessageContextInjector())
+
+	count, err := replayer.Replay(ctx, *subject, *destination, am.ReplayReaderOptions(options...), am.ReplayKeepIDs(*keepIDs))
+	log.Printf("replayed %d messages\n", count)
+	if err != nil {
+		return err
+	}
+
+	// wait for the stream to acknowledge the asynchronously published messages
+	select {
+	case <-js.PublishAsyncComplete():
+		return nil
+	case <-time.After(30 * time.Second):
+		return fmt.Errorf("timed out waiting for %d messages to be acknowledged", js.PublishAsyncPending())
+	}
+}
+
+func readerOptions() ([]am.ReaderOption, error) {
+	var options []am.ReaderOption
+
+	if *names != "" {
+		options = append(options, am.MessageFilter(strings.Split(*names, ",")))
+	}
+	if *since > 0 {
+		options = append(options, am.ReadFromTime(time.Now().Add(-*since)))
+	}
+	if *fromTime != "" {
+		t, err := time.Parse(time.RFC3339, *fromTime)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, am.ReadFromTime(t))
+	}
+	if *untilTime != "" {
+		t, err := time.Parse(time.RFC3339, *untilTime)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, am.ReadUntilTime(t))
+	}
+	if *fromSeq > 0 {
+		options = append(options, am.ReadFromSequence(*fromSeq))
+	}
+	if *untilSeq > 0 {
+		options = append(options, am.ReadUntilSequence(*untilSeq))
+	}
+	if *limit > 0 {
+		options = append(options, am.ReadLimit(*limit))
+	}
+
+	return options, nil
+}
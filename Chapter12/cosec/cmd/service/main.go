@@ -51,7 +51,7 @@ func run() (err error) {
 
 	s.Waiter().Add(
 		s.WaitForWeb,
-		// s.WaitForRPC,
+		s.WaitForRPC,
 		s.WaitForStream,
 	)
 
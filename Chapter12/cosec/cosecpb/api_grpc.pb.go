@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: cosecpb/api.proto
+
+package cosecpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SagaAdminServiceClient is the client API for SagaAdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SagaAdminServiceClient interface {
+	ListSagas(ctx context.Context, in *ListSagasRequest, opts ...grpc.CallOption) (*ListSagasResponse, error)
+	GetSaga(ctx context.Context, in *GetSagaRequest, opts ...grpc.CallOption) (*GetSagaResponse, error)
+	RetrySaga(ctx context.Context, in *RetrySagaRequest, opts ...grpc.CallOption) (*RetrySagaResponse, error)
+	CompensateSaga(ctx context.Context, in *CompensateSagaRequest, opts ...grpc.CallOption) (*CompensateSagaResponse, error)
+}
+
+type sagaAdminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSagaAdminServiceClient(cc grpc.ClientConnInterface) SagaAdminServiceClient {
+	return &sagaAdminServiceClient{cc}
+}
+
+func (c *sagaAdminServiceClient) ListSagas(ctx context.Context, in *ListSagasRequest, opts ...grpc.CallOption) (*ListSagasResponse, error) {
+	out := new(ListSagasResponse)
+	err := c.cc.Invoke(ctx, "/cosecpb.SagaAdminService/ListSagas", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sagaAdminServiceClient) GetSaga(ctx context.Context, in *GetSagaRequest, opts ...grpc.CallOption) (*GetSagaResponse, error) {
+	out := new(GetSagaResponse)
+	err := c.cc.Invoke(ctx, "/cosecpb.SagaAdminService/GetSaga", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sagaAdminServiceClient) RetrySaga(ctx context.Context, in *RetrySagaRequest, opts ...grpc.CallOption) (*RetrySagaResponse, error) {
+	out := new(RetrySagaResponse)
+	err := c.cc.Invoke(ctx, "/cosecpb.SagaAdminService/RetrySaga", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sagaAdminServiceClient) CompensateSaga(ctx context.Context, in *CompensateSagaRequest, opts ...grpc.CallOption) (*CompensateSagaResponse, error) {
+	out := new(CompensateSagaResponse)
+	err := c.cc.Invoke(ctx, "/cosecpb.SagaAdminService/CompensateSaga", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SagaAdminServiceServer is the server API for SagaAdminService service.
+// All implementations must embed UnimplementedSagaAdminServiceServer
+// for forward compatibility
+type SagaAdminServiceServer interface {
+	ListSagas(context.Context, *ListSagasRequest) (*ListSagasResponse, error)
+	GetSaga(context.Context, *GetSagaRequest) (*GetSagaResponse, error)
+	RetrySaga(context.Context, *RetrySagaRequest) (*RetrySagaResponse, error)
+	CompensateSaga(context.Context, *CompensateSagaRequest) (*CompensateSagaResponse, error)
+	mustEmbedUnimplementedSagaAdminServiceServer()
+}
+
+// UnimplementedSagaAdminServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSagaAdminServiceServer struct {
+}
+
+func (UnimplementedSagaAdminServiceServer) ListSagas(context.Context, *ListSagasRequest) (*ListSagasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSagas not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) GetSaga(context.Context, *GetSagaRequest) (*GetSagaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSaga not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) RetrySaga(context.Context, *RetrySagaRequest) (*RetrySagaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetrySaga not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) CompensateSaga(context.Context, *CompensateSagaRequest) (*CompensateSagaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompensateSaga not implemented")
+}
+func (UnimplementedSagaAdminServiceServer) mustEmbedUnimplementedSagaAdminServiceServer() {}
+
+// UnsafeSagaAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SagaAdminServiceServer will
+// result in compilation errors.
+type UnsafeSagaAdminServiceServer interface {
+	mustEmbedUnimplementedSagaAdminServiceServer()
+}
+
+func RegisterSagaAdminServiceServer(s grpc.ServiceRegistrar, srv SagaAdminServiceServer) {
+	s.RegisterService(&SagaAdminService_ServiceDesc, srv)
+}
+
+func _SagaAdminService_ListSagas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSagasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).ListSagas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cosecpb.SagaAdminService/ListSagas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).ListSagas(ctx, req.(*ListSagasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SagaAdminService_GetSaga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSagaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).GetSaga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cosecpb.SagaAdminService/GetSaga",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).GetSaga(ctx, req.(*GetSagaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SagaAdminService_RetrySaga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrySagaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).RetrySaga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cosecpb.SagaAdminService/RetrySaga",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).RetrySaga(ctx, req.(*RetrySagaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SagaAdminService_CompensateSaga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompensateSagaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SagaAdminServiceServer).CompensateSaga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cosecpb.SagaAdminService/CompensateSaga",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SagaAdminServiceServer).CompensateSaga(ctx, req.(*CompensateSagaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SagaAdminService_ServiceDesc is the grpc.ServiceDesc for SagaAdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SagaAdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cosecpb.SagaAdminService",
+	HandlerType: (*SagaAdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSagas",
+			Handler:    _SagaAdminService_ListSagas_Handler,
+		},
+		{
+			MethodName: "GetSaga",
+			Handler:    _SagaAdminService_GetSaga_Handler,
+		},
+		{
+			MethodName: "RetrySaga",
+			Handler:    _SagaAdminService_RetrySaga_Handler,
+		},
+		{
+			MethodName: "CompensateSaga",
+			Handler:    _SagaAdminService_CompensateSaga_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cosecpb/api.proto",
+}
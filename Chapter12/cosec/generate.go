@@ -0,0 +1,3 @@
+package cosec
+
+//go:generate buf generate
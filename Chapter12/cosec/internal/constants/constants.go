@@ -20,6 +20,7 @@ const (
 	CommandPublisherKey         = "commandPublisher"
 	ReplyPublisherKey           = "replyPublisher"
 	SagaStoreKey                = "sagaStore"
+	SagaAdminStoreKey           = "sagaAdminStore"
 	InboxStoreKey               = "inboxStore"
 	ApplicationKey              = "app"
 	DomainEventHandlersKey      = "domainEventHandlers"
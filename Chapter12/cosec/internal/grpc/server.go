@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/stackus/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"eda-in-golang/cosec/cosecpb"
+	"eda-in-golang/cosec/internal"
+	"eda-in-golang/cosec/internal/models"
+	"eda-in-golang/internal/errorsotel"
+	"eda-in-golang/internal/sec"
+)
+
+const defaultSagaLimit = 50
+const maxSagaLimit = 500
+
+type server struct {
+	sagas        sec.SagaAdminStore
+	orchestrator sec.Orchestrator[*models.CreateOrderData]
+	cosecpb.UnimplementedSagaAdminServiceServer
+}
+
+var _ cosecpb.SagaAdminServiceServer = (*server)(nil)
+
+func RegisterServer(sagas sec.SagaAdminStore, orchestrator sec.Orchestrator[*models.CreateOrderData], registrar grpc.ServiceRegistrar) error {
+	cosecpb.RegisterSagaAdminServiceServer(registrar, server{sagas: sagas, orchestrator: orchestrator})
+	return nil
+}
+
+func (s server) ListSagas(ctx context.Context, request *cosecpb.ListSagasRequest) (*cosecpb.ListSagasResponse, error) {
+	limit := int(request.GetLimit())
+	switch {
+	case limit <= 0:
+		limit = defaultSagaLimit
+	case limit > maxSagaLimit:
+		limit = maxSagaLimit
+	}
+
+	filter := sec.SagaFilter{
+		Name:   request.GetName(),
+		Status: sec.SagaStatus(request.GetStatus()),
+		Limit:  limit,
+	}
+	if minAge := request.GetMinAge().AsDuration(); minAge > 0 {
+		filter.UpdatedBefore = time.Now().Add(-minAge)
+	}
+
+	sagas, err := s.sagas.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &cosecpb.ListSagasResponse{
+		Sagas: make([]*cosecpb.Saga, len(sagas)),
+	}
+	for i, saga := range sagas {
+		resp.Sagas[i] = s.sagaFromRecord(saga)
+	}
+
+	return resp, nil
+}
+
+func (s server) GetSaga(ctx context.Context, request *cosecpb.GetSagaRequest) (*cosecpb.GetSagaResponse, error) {
+	saga, err := s.sagas.Find(ctx, request.GetName(), request.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.sagas.History(ctx, request.GetName(), request.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &cosecpb.GetSagaResponse{
+		Saga:    s.sagaFromRecord(saga),
+		History: make([]*cosecpb.SagaStep, len(history)),
+	}
+	for i, step := range history {
+		resp.History[i] = s.stepFromRecord(step)
+	}
+
+	return resp, nil
+}
+
+func (s server) RetrySaga(ctx context.Context, request *cosecpb.RetrySagaRequest) (*cosecpb.RetrySagaResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("SagaName", request.GetName()),
+		attribute.String("SagaID", request.GetId()),
+	)
+
+	err := s.checkSagaName(request.GetName())
+	if err == nil {
+		err = s.orchestrator.Retry(ctx, request.GetId())
+	}
+	if err != nil {
+		span.RecordError(err, trace.WithAttributes(errorsotel.ErrAttrs(err)...))
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return &cosecpb.RetrySagaResponse{}, err
+}
+
+func (s server) CompensateSaga(ctx context.Context, request *cosecpb.CompensateSagaRequest) (*cosecpb.CompensateSagaResponse, error) {
+	span := trace.SpanFromContext(ctx)
+
+	span.SetAttributes(
+		attribute.String("SagaName", request.GetName()),
+		attribute.String("SagaID", request.GetId()),
+	)
+
+	err := s.checkSagaName(request.GetName())
+	if err == nil {
+		err = s.orchestrator.Compensate(ctx, request.GetId())
+	}
+	if err != nil {
+		span.RecordError(err, trace.WithAttributes(errorsotel.ErrAttrs(err)...))
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return &cosecpb.CompensateSagaResponse{}, err
+}
+
+func (s server) checkSagaName(name string) error {
+	if name != internal.CreateOrderSagaName {
+		return errors.ErrNotFound.Msgf("saga %s is not orchestrated by cosec", name)
+	}
+	return nil
+}
+
+func (s server) sagaFromRecord(saga sec.SagaRecord) *cosecpb.Saga {
+	return &cosecpb.Saga{
+		Id:        saga.ID,
+		Name:      saga.Name,
+		Status:    saga.Status.String(),
+		Step:      int32(saga.Step),
+		Attempts:  int32(saga.Attempts),
+		Deadline:  s.timestamp(saga.Deadline),
+		UpdatedAt: s.timestamp(saga.UpdatedAt),
+		Data:      string(saga.Data),
+	}
+}
+
+func (s server) stepFromRecord(step sec.SagaStepRecord) *cosecpb.SagaStep {
+	return &cosecpb.SagaStep{
+		Step:       int32(step.Step),
+		Status:     step.Status.String(),
+		Attempts:   int32(step.Attempts),
+		Deadline:   s.timestamp(step.Deadline),
+		RecordedAt: s.timestamp(step.RecordedAt),
+	}
+}
+
+func (s server) timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+
+	"google.golang.org/grpc"
+
+	"eda-in-golang/cosec/cosecpb"
+	"eda-in-golang/cosec/internal/constants"
+	"eda-in-golang/cosec/internal/models"
+	"eda-in-golang/internal/di"
+	"eda-in-golang/internal/sec"
+)
+
+type serverTx struct {
+	c di.Container
+	cosecpb.UnimplementedSagaAdminServiceServer
+}
+
+var _ cosecpb.SagaAdminServiceServer = (*serverTx)(nil)
+
+func RegisterServerTx(container di.Container, registrar grpc.ServiceRegistrar) error {
+	cosecpb.RegisterSagaAdminServiceServer(registrar, serverTx{
+		c: container,
+	})
+	return nil
+}
+
+func (s serverTx) ListSagas(ctx context.Context, request *cosecpb.ListSagasRequest) (resp *cosecpb.ListSagasResponse, err error) {
+	ctx = s.c.Scoped(ctx)
+	defer func(tx *sql.Tx) {
+		err = s.closeTx(tx, err)
+	}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+	return s.next(ctx).ListSagas(ctx, request)
+}
+
+func (s serverTx) GetSaga(ctx context.Context, request *cosecpb.GetSagaRequest) (resp *cosecpb.GetSagaResponse, err error) {
+	ctx = s.c.Scoped(ctx)
+	defer func(tx *sql.Tx) {
+		err = s.closeTx(tx, err)
+	}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+	return s.next(ctx).GetSaga(ctx, request)
+}
+
+func (s serverTx) RetrySaga(ctx context.Context, request *cosecpb.RetrySagaRequest) (resp *cosecpb.RetrySagaResponse, err error) {
+	ctx = s.c.Scoped(ctx)
+	defer func(tx *sql.Tx) {
+		err = s.closeTx(tx, err)
+	}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+	return s.next(ctx).RetrySaga(ctx, request)
+}
+
+func (s serverTx) CompensateSaga(ctx context.Context, request *cosecpb.CompensateSagaRequest) (resp *cosecpb.CompensateSagaResponse, err error) {
+	ctx = s.c.Scoped(ctx)
+	defer func(tx *sql.Tx) {
+		err = s.closeTx(tx, err)
+	}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+	return s.next(ctx).CompensateSaga(ctx, request)
+}
+
+func (s serverTx) next(ctx context.Context) server {
+	return server{
+		sagas:        di.Get(ctx, constants.SagaAdminStoreKey).(sec.SagaAdminStore),
+		orchestrator: di.Get(ctx, constants.OrchestratorKey).(sec.Orchestrator[*models.CreateOrderData]),
+	}
+}
+
+func (s serverTx) closeTx(tx *sql.Tx, err error) error {
+	if p := recover(); p != nil {
+		_ = tx.Rollback()
+		panic(p)
+	} else if err != nil {
+		_ = tx.Rollback()
+		return err
+	} else {
+		return tx.Commit()
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+
+	"eda-in-golang/cosec/internal/constants"
+	"eda-in-golang/cosec/internal/models"
+	"eda-in-golang/internal/di"
+	"eda-in-golang/internal/sec"
+)
+
+func NewSagaTimeoutHandlerTx(container di.Container) sec.TimeoutHandlerFunc {
+	return func(ctx context.Context, sagaID string) (err error) {
+		ctx = container.Scoped(ctx)
+		defer func(tx *sql.Tx) {
+			if p := recover(); p != nil {
+				_ = tx.Rollback()
+				panic(p)
+			} else if err != nil {
+				_ = tx.Rollback()
+			} else {
+				err = tx.Commit()
+			}
+		}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+		return di.Get(ctx, constants.OrchestratorKey).(sec.Orchestrator[*models.CreateOrderData]).HandleTimeout(ctx, sagaID)
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"time"
 
 	"eda-in-golang/cosec/internal/models"
 	"eda-in-golang/customers/customerspb"
@@ -15,6 +16,9 @@ import (
 const CreateOrderSagaName = "cosec.CreateOrder"
 const CreateOrderReplyChannel = "mallbots.cosec.replies.CreateOrder"
 
+// createOrderStepTimeout is how long each step waits for its reply
+const createOrderStepTimeout = 30 * time.Second
+
 type createOrderSaga struct {
 	sec.Saga[*models.CreateOrderData]
 }
@@ -26,29 +30,36 @@ func NewCreateOrderSaga() sec.Saga[*models.CreateOrderData] {
 
 	// 0. -RejectOrder
 	saga.AddStep().
-		Compensation(saga.rejectOrder)
+		Compensation(saga.rejectOrder).
+		Timeout(createOrderStepTimeout)
 
 	// 1. AuthorizeCustomer
 	saga.AddStep().
-		Action(saga.authorizeCustomer)
+		Action(saga.authorizeCustomer).
+		Timeout(createOrderStepTimeout).
+		RetryOnTimeout(2)
 
 	// 2. CreateShoppingList, -CancelShoppingList
 	saga.AddStep().
 		Action(saga.createShoppingList).
 		OnActionReply(depotpb.CreatedShoppingListReply, saga.onCreatedShoppingListReply).
-		Compensation(saga.cancelShoppingList)
+		Compensation(saga.cancelShoppingList).
+		Timeout(createOrderStepTimeout)
 
 	// 3. ConfirmPayment
 	saga.AddStep().
-		Action(saga.confirmPayment)
+		Action(saga.confirmPayment).
+		Timeout(createOrderStepTimeout)
 
 	// 4. InitiateShopping
 	saga.AddStep().
-		Action(saga.initiateShopping)
+		Action(saga.initiateShopping).
+		Timeout(createOrderStepTimeout)
 
 	// 5. ApproveOrder
 	saga.AddStep().
-		Action(saga.approveOrder)
+		Action(saga.approveOrder).
+		Timeout(createOrderStepTimeout)
 
 	return saga
 }
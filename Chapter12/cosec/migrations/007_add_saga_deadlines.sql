@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE sagas
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN deadline timestamptz;
+CREATE INDEX sagas_deadline_idx ON sagas (deadline) WHERE NOT done AND deadline IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS sagas_deadline_idx;
+ALTER TABLE sagas
+  DROP COLUMN IF EXISTS deadline,
+  DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE sagas_history (
+  seq          bigserial   NOT NULL,
+  id           text        NOT NULL,
+  name         text        NOT NULL,
+  step         int         NOT NULL,
+  done         bool        NOT NULL,
+  compensating bool        NOT NULL,
+  attempts     int         NOT NULL,
+  deadline     timestamptz,
+  recorded_at  timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (seq)
+);
+CREATE INDEX sagas_history_saga_idx ON sagas_history (name, id, seq);
+
+-- +goose Down
+DROP TABLE IF EXISTS sagas_history;
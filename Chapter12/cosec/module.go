@@ -8,15 +8,16 @@ import (
 
 	"eda-in-golang/cosec/internal"
 	"eda-in-golang/cosec/internal/constants"
+	"eda-in-golang/cosec/internal/grpc"
 	"eda-in-golang/cosec/internal/handlers"
 	"eda-in-golang/cosec/internal/models"
+	"eda-in-golang/cosec/internal/rest"
 	"eda-in-golang/customers/customerspb"
 	"eda-in-golang/depot/depotpb"
 	"eda-in-golang/internal/am"
 	"eda-in-golang/internal/amotel"
 	"eda-in-golang/internal/amprom"
 	"eda-in-golang/internal/di"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -56,7 +57,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddScoped(constants.DatabaseTransactionKey, func(c di.Container) (any, error) {
 		return svc.DB().Begin()
 	})
@@ -68,6 +69,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			stream,
 			amotel.OtelMessageContextInjector(),
 			sentCounter,
+			am.DelayedPublisher(),
 			tm.OutboxPublisher(outboxStore),
 		), nil
 	})
@@ -86,7 +88,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
 	container.AddScoped(constants.SagaStoreKey, func(c di.Container) (any, error) {
 		reg := c.Get(constants.RegistryKey).(registry.Registry)
@@ -99,6 +101,13 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			),
 		), nil
 	})
+	container.AddScoped(constants.SagaAdminStoreKey, func(c di.Container) (any, error) {
+		return pg.NewSagaStore(
+			constants.SagasTableName,
+			postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx)),
+			c.Get(constants.RegistryKey).(registry.Registry),
+		), nil
+	})
 	container.AddSingleton(constants.SagaKey, func(c di.Container) (any, error) {
 		return internal.NewCreateOrderSaga(), nil
 	})
@@ -115,22 +124,34 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return handlers.NewIntegrationEventHandlers(
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.OrchestratorKey).(sec.Orchestrator[*models.CreateOrderData]),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.IntegrationEventHandlersKey),
 		), nil
 	})
 	container.AddScoped(constants.ReplyHandlersKey, func(c di.Container) (any, error) {
 		return handlers.NewReplyHandlers(
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.OrchestratorKey).(sec.Orchestrator[*models.CreateOrderData]),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.ReplyHandlersKey),
 		), nil
 	})
 	outboxProcessor := tm.NewOutboxProcessor(
+		constants.ServiceName,
 		stream,
 		pg.NewOutboxStore(constants.OutboxTableName, svc.DB()),
+		svc.Logger(),
+		pg.OutboxProcessorOptions(svc.Config().Outbox, constants.OutboxTableName, svc.DB())...,
 	)
 
 	// setup Driver adapters
+	if err = grpc.RegisterServerTx(container, svc.RPC()); err != nil {
+		return err
+	}
+	if err = rest.RegisterGateway(ctx, svc.Mux(), svc.Config().Rpc.Address()); err != nil {
+		return err
+	}
+	if err = rest.RegisterSwagger(svc.Mux()); err != nil {
+		return err
+	}
 	if err = handlers.RegisterIntegrationEventHandlersTx(container); err != nil {
 		return err
 	}
@@ -138,6 +159,19 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return err
 	}
 	startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
+	svc.Waiter().Add(sec.NewTimeoutSweeper(
+		internal.CreateOrderSagaName,
+		pg.NewSagaStore(constants.SagasTableName, svc.DB(), container.Get(constants.RegistryKey).(registry.Registry)),
+		handlers.NewSagaTimeoutHandlerTx(container),
+		svc.Logger(),
+	).Run)
 
 	return
 }
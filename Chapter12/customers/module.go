@@ -21,7 +21,6 @@ import (
 	"eda-in-golang/internal/amprom"
 	"eda-in-golang/internal/ddd"
 	"eda-in-golang/internal/di"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -45,7 +44,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddSingleton(constants.DomainDispatcherKey, func(c di.Container) (any, error) {
 		return ddd.NewEventDispatcher[ddd.AggregateEvent](), nil
 	})
@@ -66,6 +65,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			stream,
 			amotel.OtelMessageContextInjector(),
 			sentCounter,
+			am.DelayedPublisher(),
 			tm.OutboxPublisher(outboxStore),
 		), nil
 	})
@@ -90,7 +90,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
 	// Prometheus counters
 	customersRegistered := promauto.NewCounter(prometheus.CounterOpts{
@@ -112,12 +112,15 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.ApplicationKey).(application.App),
 			c.Get(constants.ReplyPublisherKey).(am.ReplyPublisher),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.CommandHandlersKey),
 		), nil
 	})
 	outboxProcessor := tm.NewOutboxProcessor(
+		constants.ServiceName,
 		stream,
 		pg.NewOutboxStore(constants.OutboxTableName, svc.DB()),
+		svc.Logger(),
+		pg.OutboxProcessorOptions(svc.Config().Outbox, constants.OutboxTableName, svc.DB())...,
 	)
 
 	// setup Driver adapters
@@ -135,6 +138,13 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return err
 	}
 	startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
 
 	return nil
 }
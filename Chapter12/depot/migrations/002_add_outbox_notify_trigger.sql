@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION outbox_notify_trigger()
+  RETURNS TRIGGER AS
+$$
+BEGIN
+  PERFORM pg_notify(TG_TABLE_SCHEMA || '.' || TG_TABLE_NAME, '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE outbox_notify_trigger();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON outbox;
+DROP FUNCTION IF EXISTS outbox_notify_trigger();
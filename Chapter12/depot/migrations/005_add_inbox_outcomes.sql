@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX inbox_failed_idx ON inbox (updated_at) WHERE status = 'failed';
+
+-- +goose Down
+DROP INDEX IF EXISTS inbox_failed_idx;
+DELETE FROM inbox a USING inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
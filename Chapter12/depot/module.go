@@ -19,7 +19,6 @@ import (
 	"eda-in-golang/internal/amprom"
 	"eda-in-golang/internal/ddd"
 	"eda-in-golang/internal/di"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -48,7 +47,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddSingleton(constants.DomainDispatcherKey, func(c di.Container) (any, error) {
 		return ddd.NewEventDispatcher[ddd.AggregateEvent](), nil
 	})
@@ -63,6 +62,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			stream,
 			amotel.OtelMessageContextInjector(),
 			sentCounter,
+			am.DelayedPublisher(),
 			tm.OutboxPublisher(outboxStore),
 		), nil
 	})
@@ -93,7 +93,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
 	container.AddScoped(constants.ShoppingListsRepoKey, func(c di.Container) (any, error) {
 		return postgres.NewShoppingListRepository(
@@ -133,7 +133,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.StoresCacheRepoKey).(domain.StoreCacheRepository),
 			c.Get(constants.ProductsCacheRepoKey).(domain.ProductCacheRepository),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.IntegrationEventHandlersKey),
 		), nil
 	})
 	container.AddScoped(constants.CommandHandlersKey, func(c di.Container) (any, error) {
@@ -141,12 +141,15 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.ApplicationKey).(application.App),
 			c.Get(constants.ReplyPublisherKey).(am.ReplyPublisher),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.CommandHandlersKey),
 		), nil
 	})
 	outboxProcessor := tm.NewOutboxProcessor(
+		constants.ServiceName,
 		stream,
 		pg.NewOutboxStore(constants.OutboxTableName, svc.DB()),
+		svc.Logger(),
+		pg.OutboxProcessorOptions(svc.Config().Outbox, constants.OutboxTableName, svc.DB())...,
 	)
 
 	// setup Driver adapters
@@ -167,6 +170,13 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return err
 	}
 	startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
 
 	return nil
 }
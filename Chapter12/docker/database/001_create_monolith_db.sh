@@ -4,7 +4,7 @@ set -e
 psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" <<-EOSQL
   CREATE DATABASE mallbots;
 
-  CREATE USER mallbots_user WITH ENCRYPTED PASSWORD 'mallbots_pass';
+  CREATE USER mallbots_user WITH ENCRYPTED PASSWORD 'mallbots_pass' REPLICATION;
 
   GRANT CREATE, CONNECT ON DATABASE mallbots TO mallbots_user;
 EOSQL
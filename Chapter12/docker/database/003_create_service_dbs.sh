@@ -92,7 +92,7 @@ EOSQL
 psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" <<-EOSQL
   CREATE DATABASE ordering TEMPLATE commondb;
 
-  CREATE USER ordering_user WITH ENCRYPTED PASSWORD 'ordering_pass';
+  CREATE USER ordering_user WITH ENCRYPTED PASSWORD 'ordering_pass' REPLICATION;
   GRANT USAGE ON SCHEMA public TO ordering_user;
   GRANT CREATE, CONNECT ON DATABASE ordering TO ordering_user;
 EOSQL
@@ -128,7 +128,7 @@ EOSQL
 psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" <<-EOSQL
   CREATE DATABASE stores TEMPLATE commondb;
 
-  CREATE USER stores_user WITH ENCRYPTED PASSWORD 'stores_pass';
+  CREATE USER stores_user WITH ENCRYPTED PASSWORD 'stores_pass' REPLICATION;
   GRANT USAGE ON SCHEMA public TO stores_user;
   GRANT CREATE, CONNECT ON DATABASE stores TO stores_user;
 EOSQL
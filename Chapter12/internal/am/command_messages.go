@@ -65,7 +65,9 @@ func NewCommandPublisher(reg registry.Registry, msgPublisher MessagePublisher, m
 }
 
 func (s commandPublisher) Publish(ctx context.Context, topicName string, command ddd.Command) error {
-	payload, err := s.reg.Serialize(command.CommandName(), command.Payload())
+	ddd.AddContextMetadata(ctx, command.Metadata())
+
+	payload, metadata, err := serializePayload(s.reg, command.CommandName(), command.Payload(), command.Metadata())
 	if err != nil {
 		return err
 	}
@@ -83,7 +85,7 @@ func (s commandPublisher) Publish(ctx context.Context, topicName string, command
 		name:     command.CommandName(),
 		subject:  topicName,
 		data:     data,
-		metadata: command.Metadata(),
+		metadata: metadata,
 		sentAt:   time.Now(),
 	})
 }
@@ -120,7 +122,7 @@ func (h commandMsgHandler) HandleMessage(ctx context.Context, msg IncomingMessag
 
 	commandName := msg.MessageName()
 
-	payload, err := h.reg.Deserialize(commandName, commandData.GetPayload())
+	payload, err := deserializePayload(h.reg, commandName, commandData.GetPayload(), msg.Metadata())
 	if err != nil {
 		return err
 	}
@@ -133,9 +135,16 @@ func (h commandMsgHandler) HandleMessage(ctx context.Context, msg IncomingMessag
 		msg:        msg,
 	}
 
-	destination := commandMsg.Metadata().Get(CommandReplyChannelHdr).(string)
+	destination, _ := commandMsg.Metadata().Get(CommandReplyChannelHdr).(string)
+
+	ctx = ddd.ContextWithCause(ctx, msg.ID(), msg.Metadata())
 
 	reply, err := h.handler.HandleCommand(ctx, commandMsg)
+	// commands sent without a reply channel are not replied to; a failure is
+	// returned so that the command is redelivered
+	if destination == "" {
+		return err
+	}
 	if err != nil {
 		return h.publishReply(ctx, destination, h.failure(reply, commandMsg))
 	}
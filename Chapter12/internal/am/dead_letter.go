@@ -0,0 +1,10 @@
+package am
+
+const (
+	DeadLetterHdrPrefix     = "DEADLETTER_"
+	DeadLetterSubjectHdr    = DeadLetterHdrPrefix + "SUBJECT"
+	DeadLetterErrorHdr      = DeadLetterHdrPrefix + "ERROR"
+	DeadLetterDeliveriesHdr = DeadLetterHdrPrefix + "DELIVERIES"
+	DeadLetterHandlerHdr    = DeadLetterHdrPrefix + "HANDLER"
+	DeadLetterFailedAtHdr   = DeadLetterHdrPrefix + "FAILED_AT"
+)
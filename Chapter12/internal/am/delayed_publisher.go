@@ -0,0 +1,58 @@
+package am
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// DelayedMessage is a message that should not be delivered before DeliverAt
+	DelayedMessage interface {
+		Message
+		DeliverAt() time.Time
+	}
+
+	delayedMessage struct {
+		Message
+		deliverAt time.Time
+	}
+
+	contextKey int
+)
+
+const deliverAtKey contextKey = 1
+
+// DeliverAt schedules the messages published with the context for delivery at a later time
+func DeliverAt(ctx context.Context, deliverAt time.Time) context.Context {
+	return context.WithValue(ctx, deliverAtKey, deliverAt)
+}
+
+// DeliverAfter schedules the messages published with the context for delivery after a delay
+func DeliverAfter(ctx context.Context, delay time.Duration) context.Context {
+	return DeliverAt(ctx, time.Now().Add(delay))
+}
+
+// DelayedPublisher turns messages published with a delivery time into a
+// DelayedMessage. It must come before a publisher that can hold on to
+// messages, such as the outbox; a stream will deliver them right away
+func DelayedPublisher() MessagePublisherMiddleware {
+	return func(next MessagePublisher) MessagePublisher {
+		return MessagePublisherFunc(func(ctx context.Context, topicName string, msg Message) error {
+			if deliverAt, ok := ctx.Value(deliverAtKey).(time.Time); ok && deliverAt.After(time.Now()) {
+				msg = delayedMessage{Message: msg, deliverAt: deliverAt}
+			}
+			return next.Publish(ctx, topicName, msg)
+		})
+	}
+}
+
+// MessageDeliverAt returns when the message should be delivered; the zero time
+// means at once
+func MessageDeliverAt(msg Message) time.Time {
+	if delayed, ok := msg.(DelayedMessage); ok {
+		return delayed.DeliverAt()
+	}
+	return time.Time{}
+}
+
+func (m delayedMessage) DeliverAt() time.Time { return m.deliverAt }
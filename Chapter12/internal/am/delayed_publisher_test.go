@@ -0,0 +1,40 @@
+package am_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+	"eda-in-golang/internal/registry/serdes"
+)
+
+type capturedMessages []am.Message
+
+func (c *capturedMessages) Publish(_ context.Context, _ string, msg am.Message) error {
+	*c = append(*c, msg)
+	return nil
+}
+
+func TestDelayedPublisher(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testCommand, getThing{}))
+
+	captured := &capturedMessages{}
+	publisher := am.NewCommandPublisher(reg, captured, am.DelayedPublisher())
+
+	ctx := context.Background()
+	deliverAt := time.Now().Add(30 * time.Minute)
+
+	assert.NoError(t, publisher.Publish(ctx, testCommandChannel, ddd.NewCommand(testCommand, &getThing{ID: "now"})))
+	assert.NoError(t, publisher.Publish(am.DeliverAt(ctx, deliverAt), testCommandChannel, ddd.NewCommand(testCommand, &getThing{ID: "later"})))
+
+	if assert.Len(t, *captured, 2) {
+		assert.True(t, am.MessageDeliverAt((*captured)[0]).IsZero())
+		assert.Equal(t, deliverAt, am.MessageDeliverAt((*captured)[1]))
+	}
+}
@@ -0,0 +1,135 @@
+package am
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Dispatcher runs the handling of messages for a subscription on a limited number
+// of workers
+//
+// When the subscription is partitioned each worker owns the partitions that hash
+// to it, which keeps the handling of messages with the same key sequential while
+// messages with different keys are handled in parallel. Dispatch blocks while the
+// chosen worker is busy.
+//
+// The order only holds while messages are handled without errors. A message that
+// is NAck'd is redelivered later on, and the messages with the same key that were
+// received after it are handled in the meantime; handlers must tolerate seeing
+// them out of order, for example by failing until what they depend on is there.
+type Dispatcher struct {
+	partitionBy PartitionBy
+	queues      []chan func()
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewDispatcher(cfg SubscriberConfig) *Dispatcher {
+	d := &Dispatcher{
+		partitionBy: cfg.PartitionBy(),
+		done:        make(chan struct{}),
+	}
+
+	workers := max(cfg.MaxConcurrency(), 1)
+	if workers == 1 {
+		// handle messages in the caller
+		return d
+	}
+
+	if d.partitionBy == nil {
+		// competing workers share a single queue
+		queue := make(chan func())
+		d.queues = []chan func(){queue}
+		for i := 0; i < workers; i++ {
+			go d.work(queue)
+		}
+		return d
+	}
+
+	d.queues = make([]chan func(), workers)
+	for i := range d.queues {
+		d.queues[i] = make(chan func())
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+func (d *Dispatcher) Dispatch(msg MessageBase, fn func()) {
+	if len(d.queues) == 0 {
+		fn()
+		return
+	}
+
+	d.send(d.queues[d.queueFor(msg)], fn)
+}
+
+// DispatchBatch hands a batch of messages to the workers; fn is called with the
+// indexes of the messages that make up each part of the batch
+//
+// A partitioned batch is split so each worker receives the messages, in order,
+// for the partitions it owns. Otherwise the whole batch goes to the next free
+// worker.
+func (d *Dispatcher) DispatchBatch(msgs []MessageBase, fn func(indexes []int)) {
+	if len(d.queues) == 0 || d.partitionBy == nil {
+		indexes := make([]int, len(msgs))
+		for i := range msgs {
+			indexes[i] = i
+		}
+		if len(d.queues) == 0 {
+			fn(indexes)
+			return
+		}
+		d.send(d.queues[0], func() { fn(indexes) })
+		return
+	}
+
+	parts := make([][]int, len(d.queues))
+	for i, msg := range msgs {
+		q := d.queueFor(msg)
+		parts[q] = append(parts[q], i)
+	}
+
+	for q, indexes := range parts {
+		if len(indexes) == 0 {
+			continue
+		}
+		d.send(d.queues[q], func() { fn(indexes) })
+	}
+}
+
+// Close stops the workers; messages dispatched afterwards are handled by the caller
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		close(d.done)
+	})
+}
+
+func (d *Dispatcher) queueFor(msg MessageBase) int {
+	if d.partitionBy == nil {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(d.partitionBy(msg)))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+func (d *Dispatcher) send(queue chan func(), fn func()) {
+	select {
+	case queue <- fn:
+	case <-d.done:
+		fn()
+	}
+}
+
+func (d *Dispatcher) work(queue chan func()) {
+	for {
+		select {
+		case fn := <-queue:
+			fn()
+		case <-d.done:
+			return
+		}
+	}
+}
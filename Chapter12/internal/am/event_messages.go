@@ -51,7 +51,9 @@ func NewEventPublisher(reg registry.Registry, msgPublisher MessagePublisher, mws
 }
 
 func (s eventPublisher) Publish(ctx context.Context, topicName string, event ddd.Event) error {
-	payload, err := s.reg.Serialize(event.EventName(), event.Payload())
+	ddd.AddContextMetadata(ctx, event.Metadata())
+
+	payload, metadata, err := serializePayload(s.reg, event.EventName(), event.Payload(), event.Metadata())
 	if err != nil {
 		return err
 	}
@@ -69,7 +71,7 @@ func (s eventPublisher) Publish(ctx context.Context, topicName string, event ddd
 		name:     event.EventName(),
 		subject:  topicName,
 		data:     data,
-		metadata: event.Metadata(),
+		metadata: metadata,
 		sentAt:   time.Now(),
 	})
 }
@@ -110,7 +112,7 @@ func (h eventMsgHandler) HandleMessage(ctx context.Context, msg IncomingMessage)
 
 	eventName := msg.MessageName()
 
-	payload, err := h.reg.Deserialize(eventName, eventData.GetPayload())
+	payload, err := deserializePayload(h.reg, eventName, eventData.GetPayload(), msg.Metadata())
 	if err != nil {
 		return err
 	}
@@ -124,5 +126,5 @@ func (h eventMsgHandler) HandleMessage(ctx context.Context, msg IncomingMessage)
 		msg:        msg,
 	}
 
-	return h.handler.HandleEvent(ctx, eventMsg)
+	return h.handler.HandleEvent(ddd.ContextWithCause(ctx, msg.ID(), msg.Metadata()), eventMsg)
 }
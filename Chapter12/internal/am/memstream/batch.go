@@ -0,0 +1,129 @@
+package memstream
+
+import (
+	"context"
+	"time"
+
+	"eda-in-golang/internal/am"
+)
+
+// batchLinger is how long a batch waits for another message before it is handled
+const batchLinger = 10 * time.Millisecond
+
+type batchEntry struct {
+	d   *delivery
+	msg *rawMessage
+}
+
+// runBatches collects deliveries into batches as a JetStream pull subscription
+// would fetch them
+func (s *subscription) runBatches() {
+	for {
+		var batch []*delivery
+
+		select {
+		case d := <-s.c.deliveries:
+			batch = append(batch, d)
+		case <-s.done:
+			return
+		case <-s.c.done:
+			return
+		}
+
+		maxWait := time.NewTimer(s.c.cfg.BatchMaxWait())
+	collect:
+		for len(batch) < s.c.cfg.BatchSize() {
+			select {
+			case d := <-s.c.deliveries:
+				batch = append(batch, d)
+			case <-time.After(batchLinger):
+				break collect
+			case <-maxWait.C:
+				break collect
+			}
+		}
+		maxWait.Stop()
+
+		s.handleBatch(batch)
+	}
+}
+
+func (s *subscription) handleBatch(deliveries []*delivery) {
+	cfg := s.c.cfg
+
+	batch, ok := s.handler.(am.BatchMessageHandler)
+	if !ok {
+		for _, d := range deliveries {
+			s.dispatcher.Dispatch(d.msg, func() {
+				s.handle(d)
+			})
+		}
+		return
+	}
+
+	entries := make([]batchEntry, 0, len(deliveries))
+	for _, d := range deliveries {
+		if !s.c.wants(d) {
+			continue
+		}
+
+		// as with JetStream, batched messages are kept in progress until the batch
+		// handler returns; they are not redelivered when the ack wait is up
+		msg := s.newRawMessage(d, nil)
+		if cfg.AckType() == am.AckTypeAuto {
+			_ = msg.Ack()
+		}
+
+		entries = append(entries, batchEntry{d: d, msg: msg})
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	msgs := make([]am.MessageBase, len(entries))
+	for i, entry := range entries {
+		msgs[i] = entry.msg
+	}
+	s.dispatcher.DispatchBatch(msgs, func(indexes []int) {
+		part := make([]batchEntry, len(indexes))
+		for i, index := range indexes {
+			part[i] = entries[index]
+		}
+		s.handleEntries(batch, part)
+	})
+}
+
+// handleEntries handles the entries as one batch; when the batch fails, and
+// holds more than one message, each message is handled on its own so only the
+// failing messages are redelivered
+func (s *subscription) handleEntries(batch am.BatchMessageHandler, entries []batchEntry) {
+	wCtx, cancel := context.WithTimeout(context.Background(), s.c.cfg.AckWait())
+	defer cancel()
+
+	msgs := make([]am.IncomingMessage, len(entries))
+	for i, entry := range entries {
+		msgs[i] = entry.msg
+	}
+
+	err := batch.HandleMessages(wCtx, msgs)
+	if err == nil {
+		for _, entry := range entries {
+			_ = entry.msg.Ack()
+		}
+		return
+	}
+
+	if len(entries) == 1 {
+		s.c.stream.logger.Error().Err(err).Msg("error while handling message")
+		s.fail(entries[0].d, entries[0].msg, err)
+		return
+	}
+
+	s.c.stream.logger.Error().Err(err).Msgf("error while handling a batch of %d messages; handling them one at a time", len(msgs))
+	for _, entry := range entries {
+		// the delivery is handled again with a new message
+		entry.msg.settle()
+		s.handle(entry.d)
+	}
+}
@@ -0,0 +1,165 @@
+package memstream
+
+import (
+	"sync"
+	"time"
+
+	"eda-in-golang/internal/am"
+)
+
+// consumer tracks the position of a subscription, or group of subscriptions, in
+// the stream and feeds the messages, and any redeliveries, to its subscriptions
+type consumer struct {
+	key          string
+	stream       *Stream
+	topic        string
+	cfg          am.SubscriberConfig
+	filters      map[string]struct{}
+	mu           sync.Mutex
+	cursor       int
+	redeliveries []*delivery
+	deliveries   chan *delivery
+	wake         chan struct{}
+	done         chan struct{}
+	stopOnce     sync.Once
+}
+
+type delivery struct {
+	msg              *storedMessage
+	deliveries       int
+	firstDeliveredAt time.Time
+}
+
+func newConsumer(key string, stream *Stream, topicName string, cfg am.SubscriberConfig) *consumer {
+	var filters map[string]struct{}
+	if len(cfg.MessageFilters()) > 0 {
+		filters = make(map[string]struct{})
+		for _, key := range cfg.MessageFilters() {
+			filters[key] = struct{}{}
+		}
+	}
+
+	return &consumer{
+		key:        key,
+		stream:     stream,
+		topic:      topicName,
+		cfg:        cfg,
+		filters:    filters,
+		deliveries: make(chan *delivery),
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// feed hands out messages to the subscriptions until the consumer is stopped
+func (c *consumer) feed() {
+	for {
+		d := c.nextDelivery()
+		if d == nil {
+			select {
+			case <-c.wake:
+				continue
+			case <-c.done:
+				return
+			}
+		}
+
+		if d.deliveries == 0 {
+			d.firstDeliveredAt = time.Now()
+		}
+		d.deliveries++
+
+		select {
+		case c.deliveries <- d:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *consumer) nextDelivery() *delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.redeliveries) > 0 {
+		d := c.redeliveries[0]
+		c.redeliveries = c.redeliveries[1:]
+		return d
+	}
+
+	msg := c.stream.next(c.cursor, c.topic)
+	if msg == nil {
+		return nil
+	}
+	c.cursor = msg.seq
+
+	return &delivery{msg: msg}
+}
+
+func (c *consumer) redeliver(d *delivery, delay time.Duration) {
+	enqueue := func() {
+		c.mu.Lock()
+		c.redeliveries = append(c.redeliveries, d)
+		c.mu.Unlock()
+		c.notify()
+	}
+
+	if delay <= 0 {
+		enqueue()
+		return
+	}
+
+	time.AfterFunc(delay, enqueue)
+}
+
+// nack schedules the redelivery of the message unless it has been delivered
+// for the last time
+func (c *consumer) nack(d *delivery) {
+	if c.isFinalDelivery(d) {
+		return
+	}
+
+	var delay time.Duration
+	if policy := c.cfg.RetryPolicy(); policy != nil {
+		delay = policy.Backoff(d.deliveries)
+	}
+
+	c.redeliver(d, delay)
+}
+
+// isFinalDelivery reports whether the message should not be redelivered should
+// this delivery fail
+func (c *consumer) isFinalDelivery(d *delivery) bool {
+	if c.cfg.AckType() == am.AckTypeAuto {
+		return true
+	}
+
+	if policy := c.cfg.RetryPolicy(); policy != nil && am.RetryExhausted(policy, d.firstDeliveredAt) {
+		return true
+	}
+
+	return d.deliveries >= c.cfg.MaxRedeliver()
+}
+
+// wants reports whether the message passes the subscription filters
+func (c *consumer) wants(d *delivery) bool {
+	if c.filters == nil {
+		return true
+	}
+
+	_, exists := c.filters[d.msg.name]
+	return exists
+}
+
+func (c *consumer) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *consumer) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
@@ -0,0 +1,213 @@
+package memstream
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+// Stream is an in-memory am.MessageStream
+//
+// Published messages are kept for the lifetime of the stream, and like a
+// JetStream consumer, each new subscription will begin with the oldest stored
+// message. Subscriptions that share a GroupName compete for the messages.
+type Stream struct {
+	mu        sync.Mutex
+	messages  []*storedMessage
+	consumers map[string]*consumer
+	subs      []*subscription
+	nextID    int
+	logger    zerolog.Logger
+}
+
+type storedMessage struct {
+	seq      int
+	id       string
+	name     string
+	subject  string
+	data     []byte
+	metadata ddd.Metadata
+	sentAt   time.Time
+	storedAt time.Time
+}
+
+var _ am.MessageStream = (*Stream)(nil)
+var _ am.MessageBase = (*storedMessage)(nil)
+
+func NewStream(logger zerolog.Logger) *Stream {
+	return &Stream{
+		consumers: make(map[string]*consumer),
+		logger:    logger,
+	}
+}
+
+func (s *Stream) Publish(_ context.Context, topicName string, msg am.Message) error {
+	metadata := make(ddd.Metadata, len(msg.Metadata()))
+	for key, value := range msg.Metadata() {
+		metadata.Set(key, value)
+	}
+
+	data := make([]byte, len(msg.Data()))
+	copy(data, msg.Data())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, &storedMessage{
+		seq:      len(s.messages) + 1,
+		id:       msg.ID(),
+		name:     msg.MessageName(),
+		subject:  topicName,
+		data:     data,
+		metadata: metadata,
+		sentAt:   msg.SentAt(),
+		storedAt: time.Now(),
+	})
+
+	for _, c := range s.consumers {
+		c.notify()
+	}
+
+	return nil
+}
+
+func (s *Stream) Subscribe(topicName string, handler am.MessageHandler, options ...am.SubscriberOption) (am.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subCfg := am.NewSubscriberConfig(options)
+
+	var key string
+	if groupName := subCfg.GroupName(); groupName != "" {
+		key = groupName
+	} else {
+		s.nextID++
+		key = fmt.Sprintf("%s#%d", topicName, s.nextID)
+	}
+
+	c, exists := s.consumers[key]
+	if !exists {
+		c = newConsumer(key, s, topicName, subCfg)
+		s.consumers[key] = c
+		go c.feed()
+	}
+
+	sub := newSubscription(c, handler)
+	s.subs = append(s.subs, sub)
+	go sub.run()
+
+	return sub, nil
+}
+
+func (s *Stream) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		sub.stop()
+	}
+	s.subs = nil
+
+	for key, c := range s.consumers {
+		c.stop()
+		delete(s.consumers, key)
+	}
+
+	return nil
+}
+
+func (s *Stream) removeSubscription(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.subs {
+		if existing == sub {
+			s.subs = append(s.subs[:i], s.subs[i+1:]...)
+			break
+		}
+	}
+
+	// ephemeral consumers end with their only subscription
+	if sub.c.cfg.GroupName() == "" {
+		sub.c.stop()
+		delete(s.consumers, sub.c.key)
+	}
+}
+
+// next returns the first stored message after the sequence that matches the subject
+func (s *Stream) next(after int, subject string) *storedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, msg := range s.messages[after:] {
+		if subjectMatches(subject, msg.subject) {
+			return msg
+		}
+	}
+
+	return nil
+}
+
+func (s *Stream) deadLetter(topicName string, cfg am.SubscriberConfig, d *delivery, handlerErr error) error {
+	handlerName := cfg.GroupName()
+	if handlerName == "" {
+		handlerName = d.msg.subject
+	}
+
+	metadata := make(ddd.Metadata, len(d.msg.metadata)+5)
+	for key, value := range d.msg.metadata {
+		metadata.Set(key, value)
+	}
+	metadata.Set(am.DeadLetterSubjectHdr, d.msg.subject)
+	metadata.Set(am.DeadLetterErrorHdr, handlerErr.Error())
+	metadata.Set(am.DeadLetterDeliveriesHdr, d.deliveries)
+	metadata.Set(am.DeadLetterHandlerHdr, handlerName)
+	metadata.Set(am.DeadLetterFailedAtHdr, time.Now().Format(time.RFC3339Nano))
+
+	return s.Publish(context.Background(), topicName, &rawMessage{
+		id:       d.msg.id,
+		name:     d.msg.name,
+		subject:  topicName,
+		data:     d.msg.data,
+		metadata: metadata,
+		sentAt:   d.msg.sentAt,
+	})
+}
+
+func (m *storedMessage) ID() string             { return m.id }
+func (m *storedMessage) Subject() string        { return m.subject }
+func (m *storedMessage) MessageName() string    { return m.name }
+func (m *storedMessage) Metadata() ddd.Metadata { return m.metadata }
+func (m *storedMessage) SentAt() time.Time      { return m.sentAt }
+
+// subjectMatches reports whether the subject matches the pattern using the NATS
+// wildcard rules; "*" matches a single token and ">" matches one or more tokens
+func subjectMatches(pattern, subject string) bool {
+	if pattern == subject {
+		return true
+	}
+
+	pTokens := strings.Split(pattern, ".")
+	sTokens := strings.Split(subject, ".")
+
+	for i, token := range pTokens {
+		if token == ">" {
+			return len(sTokens) > i
+		}
+		if i >= len(sTokens) {
+			return false
+		}
+		if token != "*" && token != sTokens[i] {
+			return false
+		}
+	}
+
+	return len(pTokens) == len(sTokens)
+}
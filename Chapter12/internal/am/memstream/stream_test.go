@@ -0,0 +1,398 @@
+package memstream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type testMessage struct {
+	id       string
+	name     string
+	metadata ddd.Metadata
+}
+
+func (m testMessage) ID() string             { return m.id }
+func (m testMessage) Subject() string        { return "" }
+func (m testMessage) MessageName() string    { return m.name }
+func (m testMessage) Data() []byte           { return []byte(m.id) }
+func (m testMessage) Metadata() ddd.Metadata { return m.metadata }
+func (m testMessage) SentAt() time.Time      { return time.Now() }
+
+func publish(t *testing.T, s *Stream, topicName string, count int, name string) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		err := s.Publish(context.Background(), topicName, testMessage{id: fmt.Sprintf("%s-%d", name, i), name: name, metadata: ddd.Metadata{}})
+		assert.NoError(t, err)
+	}
+}
+
+func TestStream_GroupCompetes(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var mu sync.Mutex
+	seen := map[string]int{}
+	var wg sync.WaitGroup
+	wg.Add(10)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		mu.Lock()
+		seen[msg.ID()]++
+		mu.Unlock()
+		wg.Done()
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Subscribe("test.topic", handler, am.GroupName("group"))
+		assert.NoError(t, err)
+	}
+	publish(t, s, "test.topic", 10, "test.Message")
+
+	waitFor(t, &wg)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, seen, 10)
+	for id, count := range seen {
+		assert.Equal(t, 1, count, id)
+	}
+}
+
+func TestStream_SubscribersReceiveStoredMessages(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	publish(t, s, "test.topic", 3, "test.Message")
+
+	var received atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(6)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		received.Add(1)
+		wg.Done()
+		return nil
+	})
+
+	_, err := s.Subscribe("test.*", handler)
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.>", handler)
+	assert.NoError(t, err)
+
+	waitFor(t, &wg)
+	assert.Equal(t, int32(6), received.Load())
+}
+
+func TestStream_MessageFilter(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		assert.Equal(t, "test.Wanted", msg.MessageName())
+		wg.Done()
+		return nil
+	})
+
+	_, err := s.Subscribe("test.topic", handler, am.MessageFilter{"test.Wanted"})
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 2, "test.Unwanted")
+	publish(t, s, "test.topic", 2, "test.Wanted")
+
+	waitFor(t, &wg)
+}
+
+func TestStream_RedeliversUpToMaxRedeliver(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var attempts atomic.Int32
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		attempts.Add(1)
+		return fmt.Errorf("handler failed")
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	deadLetters := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		assert.Equal(t, "handler failed", msg.Metadata().Get(am.DeadLetterErrorHdr))
+		assert.Equal(t, 3, msg.Metadata().Get(am.DeadLetterDeliveriesHdr))
+		assert.Equal(t, "test.topic", msg.Metadata().Get(am.DeadLetterSubjectHdr))
+		wg.Done()
+		return nil
+	})
+
+	_, err := s.Subscribe("test.topic", handler, am.MaxRedeliver(3), am.DeadLetterTopic("test.deadletters"))
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.deadletters", deadLetters)
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.Message")
+
+	waitFor(t, &wg)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestStream_RetriesBacklogOlderThanMaxElapsedTime(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	publish(t, s, "test.topic", 1, "test.Message")
+	// the message has been stored for longer than retries are allowed for
+	time.Sleep(30 * time.Millisecond)
+
+	var attempts atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(2)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		defer wg.Done()
+		if attempts.Add(1) == 1 {
+			return fmt.Errorf("handler failed")
+		}
+		return nil
+	})
+
+	_, err := s.Subscribe("test.topic", handler,
+		am.MaxRedeliver(3),
+		am.RedeliveryPolicy(am.WithMaxElapsedTime(am.ConstantBackoff(0), 20*time.Millisecond)),
+	)
+	assert.NoError(t, err)
+
+	waitFor(t, &wg)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestStream_RedeliversAfterAckWait(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var attempts atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(2)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		defer wg.Done()
+		if attempts.Add(1) == 1 {
+			<-ctx.Done()
+		}
+		return nil
+	})
+
+	_, err := s.Subscribe("test.topic", handler, am.AckWait(20*time.Millisecond))
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.Message")
+
+	waitFor(t, &wg)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestStream_PartitionedHandling(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	const aggregates = 4
+	const perAggregate = 5
+
+	var mu sync.Mutex
+	active := map[string]bool{}
+	handled := map[string][]int{}
+	var concurrent atomic.Int32
+	var maxConcurrent atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(aggregates * perAggregate)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		defer wg.Done()
+		key := msg.Metadata().Get(ddd.AggregateIDKey).(string)
+
+		mu.Lock()
+		assert.False(t, active[key], "messages for %s handled concurrently", key)
+		active[key] = true
+		mu.Unlock()
+
+		if n := concurrent.Add(1); n > maxConcurrent.Load() {
+			maxConcurrent.Store(n)
+		}
+		time.Sleep(5 * time.Millisecond)
+		concurrent.Add(-1)
+
+		mu.Lock()
+		active[key] = false
+		handled[key] = append(handled[key], msg.Metadata().Get("position").(int))
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 0; i < perAggregate; i++ {
+		for a := 0; a < aggregates; a++ {
+			err := s.Publish(context.Background(), "test.topic", testMessage{
+				id:   fmt.Sprintf("%d-%d", a, i),
+				name: "test.Message",
+				metadata: ddd.Metadata{
+					ddd.AggregateIDKey: fmt.Sprintf("aggregate-%d", a),
+					"position":         i,
+				},
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	_, err := s.Subscribe("test.topic", handler, am.MaxConcurrency(aggregates), am.PartitionByMetadata(ddd.AggregateIDKey))
+	assert.NoError(t, err)
+
+	waitFor(t, &wg)
+	for key, positions := range handled {
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, positions, key)
+	}
+	assert.LessOrEqual(t, maxConcurrent.Load(), int32(aggregates))
+}
+
+type batchHandler struct {
+	mu      sync.Mutex
+	batches [][]string
+	wg      *sync.WaitGroup
+}
+
+func (h *batchHandler) HandleMessage(context.Context, am.IncomingMessage) error {
+	return fmt.Errorf("expected batches")
+}
+
+func (h *batchHandler) HandleMessages(_ context.Context, msgs []am.IncomingMessage) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID()
+		h.wg.Done()
+	}
+	h.batches = append(h.batches, ids)
+	return nil
+}
+
+func TestStream_PullBatches(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	publish(t, s, "test.topic", 7, "test.Message")
+
+	var wg sync.WaitGroup
+	wg.Add(7)
+	handler := &batchHandler{wg: &wg}
+
+	_, err := s.Subscribe("test.topic", handler, am.PullBatchSize(3), am.PullMaxWait(time.Second))
+	assert.NoError(t, err)
+
+	waitFor(t, &wg)
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	total := 0
+	for _, batch := range handler.batches {
+		assert.LessOrEqual(t, len(batch), 3)
+		total += len(batch)
+	}
+	assert.Equal(t, 7, total)
+}
+
+func TestStream_PullBatchIsolatesFailingMessage(t *testing.T) {
+	s := NewStream(zerolog.Nop())
+	defer s.Unsubscribe()
+
+	publish(t, s, "test.topic", 4, "test.Message")
+
+	const poison = "test.Message-1"
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	var mu sync.Mutex
+	var handled []string
+	handler := &failingBatchHandler{
+		batch: func(msgs []am.IncomingMessage) error {
+			for _, msg := range msgs {
+				if msg.ID() == poison {
+					return fmt.Errorf("batch failed")
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, msg := range msgs {
+				handled = append(handled, msg.ID())
+				wg.Done()
+			}
+			return nil
+		},
+	}
+	deadLetters := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		assert.Equal(t, poison, msg.ID())
+		wg.Done()
+		return nil
+	})
+
+	_, err := s.Subscribe("test.topic", handler, am.PullBatchSize(4), am.PullMaxWait(time.Second),
+		am.MaxRedeliver(2), am.DeadLetterTopic("test.deadletters"))
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.deadletters", deadLetters)
+	assert.NoError(t, err)
+
+	waitFor(t, &wg)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"test.Message-0", "test.Message-2", "test.Message-3"}, handled)
+}
+
+type failingBatchHandler struct {
+	batch func(msgs []am.IncomingMessage) error
+}
+
+func (h *failingBatchHandler) HandleMessage(_ context.Context, msg am.IncomingMessage) error {
+	return h.batch([]am.IncomingMessage{msg})
+}
+
+func (h *failingBatchHandler) HandleMessages(_ context.Context, msgs []am.IncomingMessage) error {
+	return h.batch(msgs)
+}
+
+func Test_subjectMatches(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		"Exact":          {"a.b.c", "a.b.c", true},
+		"Different":      {"a.b.c", "a.b.d", false},
+		"Star":           {"a.*.c", "a.b.c", true},
+		"StarTooShort":   {"a.*", "a.b.c", false},
+		"Tail":           {"a.>", "a.b.c", true},
+		"TailNeedsToken": {"a.>", "a", false},
+		"Longer":         {"a.b.c.d", "a.b.c", false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, subjectMatches(tt.pattern, tt.subject))
+		})
+	}
+}
+
+func waitFor(t *testing.T, wg *sync.WaitGroup) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for messages to be handled")
+	}
+}
@@ -0,0 +1,143 @@
+package memstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"eda-in-golang/internal/am"
+)
+
+// subscription handles the messages from its consumer one at a time, or using
+// the number of workers and partitioning set by the subscriber options
+type subscription struct {
+	c          *consumer
+	handler    am.MessageHandler
+	dispatcher *am.Dispatcher
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+func newSubscription(c *consumer, handler am.MessageHandler) *subscription {
+	return &subscription{
+		c:          c,
+		handler:    handler,
+		dispatcher: am.NewDispatcher(c.cfg),
+		done:       make(chan struct{}),
+	}
+}
+
+func (s *subscription) Unsubscribe() error {
+	s.stop()
+	s.c.stream.removeSubscription(s)
+	return nil
+}
+
+func (s *subscription) run() {
+	if s.c.cfg.Pull() {
+		s.runBatches()
+		return
+	}
+
+	for {
+		select {
+		case d := <-s.c.deliveries:
+			s.dispatcher.Dispatch(d.msg, func() {
+				s.handle(d)
+			})
+		case <-s.done:
+			return
+		case <-s.c.done:
+			return
+		}
+	}
+}
+
+func (s *subscription) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.dispatcher.Close()
+	})
+}
+
+func (s *subscription) handle(d *delivery) {
+	var err error
+
+	cfg := s.c.cfg
+
+	if !s.c.wants(d) {
+		return
+	}
+
+	ackTimer := time.NewTimer(cfg.AckWait())
+	defer ackTimer.Stop()
+
+	msg := s.newRawMessage(d, ackTimer)
+
+	wCtx, cancel := context.WithTimeout(context.Background(), cfg.AckWait())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.handler.HandleMessage(wCtx, msg)
+	}()
+
+	if cfg.AckType() == am.AckTypeAuto {
+		_ = msg.Ack()
+	}
+
+	select {
+	case err = <-errc:
+		if err == nil {
+			_ = msg.Ack()
+			return
+		}
+		s.c.stream.logger.Error().Err(err).Msg("error while handling message")
+		s.fail(d, msg, err)
+	case <-ackTimer.C:
+		// the message was not acknowledged in time; redeliver it as JetStream would
+		if msg.settle() && !s.c.isFinalDelivery(d) {
+			s.c.redeliver(d, 0)
+		}
+	}
+}
+
+func (s *subscription) newRawMessage(d *delivery, ackTimer *time.Timer) *rawMessage {
+	return &rawMessage{
+		id:         d.msg.id,
+		name:       d.msg.name,
+		subject:    d.msg.subject,
+		data:       d.msg.data,
+		metadata:   d.msg.metadata,
+		sentAt:     d.msg.sentAt,
+		receivedAt: time.Now(),
+		ackFn:      func() error { return nil },
+		nackFn: func() error {
+			s.c.nack(d)
+			return nil
+		},
+		extendFn: func() error {
+			if ackTimer != nil {
+				ackTimer.Reset(s.c.cfg.AckWait())
+			}
+			return nil
+		},
+		killFn: func() error { return nil },
+	}
+}
+
+// fail NAcks the message, or when it will not be redelivered, sends it to the
+// dead-letter topic and terminates it
+func (s *subscription) fail(d *delivery, msg *rawMessage, err error) {
+	if !s.c.isFinalDelivery(d) {
+		_ = msg.NAck()
+		return
+	}
+
+	if topic := s.c.cfg.DeadLetterTopic(); topic != "" {
+		if dlErr := s.c.stream.deadLetter(topic, s.c.cfg, d, err); dlErr != nil {
+			s.c.stream.logger.Error().Err(dlErr).Msgf("failed to publish message to dead-letter topic %s", topic)
+		}
+	}
+	_ = msg.Kill()
+}
@@ -30,6 +30,16 @@ type (
 	}
 	MessageHandlerFunc func(ctx context.Context, msg IncomingMessage) error
 
+	// BatchMessageHandler handles a batch of messages fetched by a pull subscription
+	// as a single unit of work; either every message is handled, or none are
+	//
+	// When a batch fails the streams handle its messages again one at a time with
+	// HandleMessage, so a single poison message does not hold back the others.
+	BatchMessageHandler interface {
+		HandleMessages(ctx context.Context, msgs []IncomingMessage) error
+	}
+	BatchMessageHandlerFunc func(ctx context.Context, msgs []IncomingMessage) error
+
 	MessageSubscriber interface {
 		Subscribe(topicName string, handler MessageHandler, options ...SubscriberOption) (Subscription, error)
 		Unsubscribe() error
@@ -76,6 +86,12 @@ type (
 		subscriber MessageSubscriber
 		mws        []MessageHandlerMiddleware
 	}
+
+	batchMessageHandler struct {
+		MessageHandler
+		batch BatchMessageHandler
+		mws   []MessageHandlerMiddleware
+	}
 )
 
 var _ Message = (*message)(nil)
@@ -95,6 +111,10 @@ func (f MessageHandlerFunc) HandleMessage(ctx context.Context, cmd IncomingMessa
 	return f(ctx, cmd)
 }
 
+func (f BatchMessageHandlerFunc) HandleMessages(ctx context.Context, msgs []IncomingMessage) error {
+	return f(ctx, msgs)
+}
+
 func NewMessagePublisher(publisher MessagePublisher, mws ...MessagePublisherMiddleware) MessagePublisher {
 	return messagePublisher{
 		publisher: MessagePublisherWithMiddleware(publisher, mws...),
@@ -112,10 +132,42 @@ func NewMessageSubscriber(subscriber MessageSubscriber, mws ...MessageHandlerMid
 	}
 }
 
+// Subscribe applies the middleware to the handler before subscribing
+//
+// Handlers that are also a BatchMessageHandler remain one; the middleware is
+// applied to each message of the batches.
 func (s messageSubscriber) Subscribe(topicName string, handler MessageHandler, options ...SubscriberOption) (Subscription, error) {
-	return s.subscriber.Subscribe(topicName, MessageHandlerWithMiddleware(handler, s.mws...), options...)
+	wrapped := MessageHandlerWithMiddleware(handler, s.mws...)
+	if batch, ok := handler.(BatchMessageHandler); ok {
+		wrapped = batchMessageHandler{
+			MessageHandler: wrapped,
+			batch:          batch,
+			mws:            s.mws,
+		}
+	}
+
+	return s.subscriber.Subscribe(topicName, wrapped, options...)
 }
 
 func (s messageSubscriber) Unsubscribe() error {
 	return s.subscriber.Unsubscribe()
 }
+
+// HandleMessages runs the middleware for each message around the handling of
+// the batch; the middleware all see the result of the batch, and the batch is
+// handled with the context built up by the middleware of every message
+func (h batchMessageHandler) HandleMessages(ctx context.Context, msgs []IncomingMessage) error {
+	return h.handleFrom(ctx, msgs, 0)
+}
+
+func (h batchMessageHandler) handleFrom(ctx context.Context, msgs []IncomingMessage, i int) error {
+	if i == len(msgs) {
+		return h.batch.HandleMessages(ctx, msgs)
+	}
+
+	next := MessageHandlerFunc(func(ctx context.Context, _ IncomingMessage) error {
+		return h.handleFrom(ctx, msgs, i+1)
+	})
+
+	return MessageHandlerWithMiddleware(next, h.mws...).HandleMessage(ctx, msgs[i])
+}
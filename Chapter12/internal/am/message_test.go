@@ -0,0 +1,80 @@
+package am_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type ctxKey string
+
+type capturedHandler struct {
+	am.MessageSubscriber
+	handler am.MessageHandler
+}
+
+func (c *capturedHandler) Subscribe(_ string, handler am.MessageHandler, _ ...am.SubscriberOption) (am.Subscription, error) {
+	c.handler = handler
+	return nil, nil
+}
+
+type testIncomingMessage struct {
+	am.IncomingMessage
+	id string
+}
+
+func (m testIncomingMessage) ID() string             { return m.id }
+func (m testIncomingMessage) MessageName() string    { return "test.Message" }
+func (m testIncomingMessage) Metadata() ddd.Metadata { return ddd.Metadata{} }
+func (m testIncomingMessage) SentAt() time.Time      { return time.Time{} }
+
+type testBatchHandler struct {
+	am.MessageHandler
+	handled func(ctx context.Context, msgs []am.IncomingMessage) error
+}
+
+func (h testBatchHandler) HandleMessages(ctx context.Context, msgs []am.IncomingMessage) error {
+	return h.handled(ctx, msgs)
+}
+
+func TestMessageSubscriber_BatchMiddleware(t *testing.T) {
+	var seen []string
+	mw := func(next am.MessageHandler) am.MessageHandler {
+		return am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+			seen = append(seen, msg.ID())
+			return next.HandleMessage(context.WithValue(ctx, ctxKey(msg.ID()), true), msg)
+		})
+	}
+
+	captured := &capturedHandler{}
+	subscriber := am.NewMessageSubscriber(captured, mw)
+
+	var batchSize int
+	_, err := subscriber.Subscribe("test.topic", testBatchHandler{
+		handled: func(ctx context.Context, msgs []am.IncomingMessage) error {
+			batchSize = len(msgs)
+			// the batch is handled with the context from the middleware of each message
+			assert.Equal(t, true, ctx.Value(ctxKey("a")))
+			assert.Equal(t, true, ctx.Value(ctxKey("b")))
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	batch, ok := captured.handler.(am.BatchMessageHandler)
+	if !assert.True(t, ok) {
+		return
+	}
+	err = batch.HandleMessages(context.Background(), []am.IncomingMessage{
+		testIncomingMessage{id: "a"},
+		testIncomingMessage{id: "b"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, seen)
+	assert.Equal(t, 2, batchSize)
+}
@@ -0,0 +1,93 @@
+package am
+
+import (
+	"time"
+)
+
+type ReaderConfig struct {
+	msgFilter []string
+	startTime time.Time
+	endTime   time.Time
+	startSeq  uint64
+	endSeq    uint64
+	limit     int
+}
+
+func NewReaderConfig(options []ReaderOption) ReaderConfig {
+	cfg := ReaderConfig{
+		msgFilter: []string{},
+		startTime: time.Time{},
+		endTime:   time.Time{},
+		startSeq:  0,
+		endSeq:    0,
+		limit:     0,
+	}
+
+	for _, option := range options {
+		option.configureReaderConfig(&cfg)
+	}
+
+	return cfg
+}
+
+type ReaderOption interface {
+	configureReaderConfig(*ReaderConfig)
+}
+
+func (c ReaderConfig) MessageFilters() []string {
+	return c.msgFilter
+}
+
+func (c ReaderConfig) StartTime() time.Time {
+	return c.startTime
+}
+
+func (c ReaderConfig) EndTime() time.Time {
+	return c.endTime
+}
+
+func (c ReaderConfig) StartSequence() uint64 {
+	return c.startSeq
+}
+
+func (c ReaderConfig) EndSequence() uint64 {
+	return c.endSeq
+}
+
+func (c ReaderConfig) Limit() int {
+	return c.limit
+}
+
+func (s MessageFilter) configureReaderConfig(cfg *ReaderConfig) {
+	cfg.msgFilter = s
+}
+
+type ReadFromTime time.Time
+
+func (t ReadFromTime) configureReaderConfig(cfg *ReaderConfig) {
+	cfg.startTime = time.Time(t)
+}
+
+type ReadUntilTime time.Time
+
+func (t ReadUntilTime) configureReaderConfig(cfg *ReaderConfig) {
+	cfg.endTime = time.Time(t)
+}
+
+type ReadFromSequence uint64
+
+func (s ReadFromSequence) configureReaderConfig(cfg *ReaderConfig) {
+	cfg.startSeq = uint64(s)
+}
+
+type ReadUntilSequence uint64
+
+func (s ReadUntilSequence) configureReaderConfig(cfg *ReaderConfig) {
+	cfg.endSeq = uint64(s)
+}
+
+type ReadLimit int
+
+func (l ReadLimit) configureReaderConfig(cfg *ReaderConfig) {
+	cfg.limit = int(l)
+}
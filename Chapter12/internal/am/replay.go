@@ -0,0 +1,129 @@
+package am
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/ddd"
+)
+
+const (
+	ReplayHdrPrefix     = "REPLAY_"
+	ReplayOriginalIDHdr = ReplayHdrPrefix + "ORIGINAL_ID"
+	ReplaySubjectHdr    = ReplayHdrPrefix + "SUBJECT"
+	ReplayedAtHdr       = ReplayHdrPrefix + "REPLAYED_AT"
+)
+
+type (
+	MessageReader interface {
+		ReadMessages(ctx context.Context, subject string, fn func(Message) error, options ...ReaderOption) error
+	}
+
+	MessageReplayer interface {
+		Replay(ctx context.Context, subject, destination string, options ...ReplayOption) (int, error)
+	}
+
+	ReplayOption interface {
+		configureReplayConfig(*replayConfig)
+	}
+
+	// ReplayKeepIDs keeps the IDs of all replayed messages, not only those of the
+	// dead letters; inboxes skip the messages they have already handled
+	ReplayKeepIDs bool
+
+	replayReaderOptions []ReaderOption
+
+	replayConfig struct {
+		readerOptions []ReaderOption
+		keepIDs       bool
+	}
+
+	messageReplayer struct {
+		reader    MessageReader
+		publisher MessagePublisher
+	}
+)
+
+var _ MessageReplayer = (*messageReplayer)(nil)
+
+// NewMessageReplayer creates a MessageReplayer that reads messages back out of the
+// reader and republishes them through the publisher and any middleware
+func NewMessageReplayer(reader MessageReader, publisher MessagePublisher, mws ...MessagePublisherMiddleware) MessageReplayer {
+	return messageReplayer{
+		reader:    reader,
+		publisher: MessagePublisherWithMiddleware(publisher, mws...),
+	}
+}
+
+// ReplayReaderOptions selects the messages that are replayed
+func ReplayReaderOptions(options ...ReaderOption) ReplayOption {
+	return replayReaderOptions(options)
+}
+
+func (o replayReaderOptions) configureReplayConfig(cfg *replayConfig) {
+	cfg.readerOptions = append(cfg.readerOptions, o...)
+}
+
+func (b ReplayKeepIDs) configureReplayConfig(cfg *replayConfig) {
+	cfg.keepIDs = bool(b)
+}
+
+// Replay republishes the messages found on subject to the destination topic
+//
+// Every group subscribed to the destination receives the replayed messages.
+// Dead letters keep their IDs, so the groups whose inbox has already handled a
+// message skip it while the group it failed for handles it again; a group
+// without an inbox will handle it again. Any other message is given a new ID,
+// as every inbox has handled it already and would skip it, unless the
+// ReplayKeepIDs option is used.
+func (r messageReplayer) Replay(ctx context.Context, subject, destination string, options ...ReplayOption) (int, error) {
+	if destination == "" {
+		return 0, errors.ErrBadRequest.Msg("a destination topic is required to replay messages")
+	}
+
+	var cfg replayConfig
+	for _, option := range options {
+		option.configureReplayConfig(&cfg)
+	}
+
+	count := 0
+
+	err := r.reader.ReadMessages(ctx, subject, func(msg Message) error {
+		metadata := make(ddd.Metadata, len(msg.Metadata())+3)
+		for key, value := range msg.Metadata() {
+			if strings.HasPrefix(key, DeadLetterHdrPrefix) {
+				continue
+			}
+			metadata.Set(key, value)
+		}
+		metadata.Set(ReplayOriginalIDHdr, msg.ID())
+		metadata.Set(ReplaySubjectHdr, msg.Subject())
+		metadata.Set(ReplayedAtHdr, time.Now().Format(time.RFC3339Nano))
+
+		id := msg.ID()
+		if _, deadLetter := msg.Metadata()[DeadLetterSubjectHdr]; !deadLetter && !cfg.keepIDs {
+			id = uuid.New().String()
+		}
+
+		err := r.publisher.Publish(ctx, destination, message{
+			id:       id,
+			name:     msg.MessageName(),
+			subject:  destination,
+			data:     msg.Data(),
+			metadata: metadata,
+			sentAt:   time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		count++
+		return nil
+	}, cfg.readerOptions...)
+
+	return count, err
+}
@@ -0,0 +1,90 @@
+package am_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type storedMessages []am.Message
+
+func (s storedMessages) ReadMessages(_ context.Context, _ string, fn func(am.Message) error, _ ...am.ReaderOption) error {
+	for _, msg := range s {
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type deadLetter struct {
+	testIncomingMessage
+}
+
+func (m deadLetter) Subject() string { return "test.deadletters" }
+func (m deadLetter) Data() []byte    { return []byte(m.id) }
+func (m deadLetter) Metadata() ddd.Metadata {
+	return ddd.Metadata{
+		"kept":                  "value",
+		am.DeadLetterSubjectHdr: "test.topic",
+		am.DeadLetterHandlerHdr: "test-group",
+	}
+}
+
+type historicalMessage struct {
+	testIncomingMessage
+}
+
+func (m historicalMessage) Subject() string { return "test.deadletters" }
+func (m historicalMessage) Data() []byte    { return []byte(m.id) }
+func (m historicalMessage) Metadata() ddd.Metadata {
+	return ddd.Metadata{"kept": "value"}
+}
+
+func TestMessageReplayer_Replay(t *testing.T) {
+	tests := map[string]struct {
+		stored  am.Message
+		options []am.ReplayOption
+		keepsID bool
+	}{
+		"DeadLetterKeepsID":  {stored: deadLetter{testIncomingMessage{id: "msg-1"}}, keepsID: true},
+		"HistoricalNewID":    {stored: historicalMessage{testIncomingMessage{id: "msg-1"}}},
+		"HistoricalKeepsIDs": {stored: historicalMessage{testIncomingMessage{id: "msg-1"}}, options: []am.ReplayOption{am.ReplayKeepIDs(true)}, keepsID: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			captured := &capturedMessages{}
+			replayer := am.NewMessageReplayer(storedMessages{tt.stored}, captured)
+
+			count, err := replayer.Replay(context.Background(), "test.deadletters", "test.topic", tt.options...)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+			if !assert.Len(t, *captured, 1) {
+				return
+			}
+
+			msg := (*captured)[0]
+			assert.Equal(t, tt.keepsID, msg.ID() == "msg-1")
+			assert.Equal(t, "test.topic", msg.Subject())
+			assert.Equal(t, "test.Message", msg.MessageName())
+			assert.Equal(t, []byte("msg-1"), msg.Data())
+			assert.Equal(t, "value", msg.Metadata().Get("kept"))
+			assert.Nil(t, msg.Metadata().Get(am.DeadLetterHandlerHdr))
+			assert.Equal(t, "msg-1", msg.Metadata().Get(am.ReplayOriginalIDHdr))
+			assert.Equal(t, "test.deadletters", msg.Metadata().Get(am.ReplaySubjectHdr))
+		})
+	}
+}
+
+func TestMessageReplayer_RequiresDestination(t *testing.T) {
+	captured := &capturedMessages{}
+	replayer := am.NewMessageReplayer(storedMessages{deadLetter{testIncomingMessage{id: "msg-1"}}}, captured)
+
+	_, err := replayer.Replay(context.Background(), "test.deadletters", "")
+	assert.Error(t, err)
+	assert.Empty(t, *captured)
+}
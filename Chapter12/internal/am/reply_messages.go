@@ -71,8 +71,11 @@ func (s replyPublisher) Publish(ctx context.Context, topicName string, reply ddd
 	var err error
 	var payload []byte
 
+	metadata := reply.Metadata()
+	ddd.AddContextMetadata(ctx, metadata)
+
 	if reply.ReplyName() != SuccessReply && reply.ReplyName() != FailureReply {
-		payload, err = s.reg.Serialize(reply.ReplyName(), reply.Payload())
+		payload, metadata, err = serializePayload(s.reg, reply.ReplyName(), reply.Payload(), metadata)
 		if err != nil {
 			return err
 		}
@@ -91,7 +94,7 @@ func (s replyPublisher) Publish(ctx context.Context, topicName string, reply ddd
 		name:     reply.ReplyName(),
 		subject:  topicName,
 		data:     data,
-		metadata: reply.Metadata(),
+		metadata: metadata,
 		sentAt:   time.Now(),
 	})
 }
@@ -135,7 +138,7 @@ func (h replyMsgHandler) HandleMessage(ctx context.Context, msg IncomingMessage)
 	var payload any
 
 	if replyName != SuccessReply && replyName != FailureReply {
-		payload, err = h.reg.Deserialize(replyName, replyData.GetPayload())
+		payload, err = deserializePayload(h.reg, replyName, replyData.GetPayload(), msg.Metadata())
 		if err != nil {
 			return err
 		}
@@ -149,5 +152,5 @@ func (h replyMsgHandler) HandleMessage(ctx context.Context, msg IncomingMessage)
 		msg:        msg,
 	}
 
-	return h.handler.HandleReply(ctx, replyMsg)
+	return h.handler.HandleReply(ddd.ContextWithCause(ctx, msg.ID(), msg.Metadata()), replyMsg)
 }
@@ -0,0 +1,121 @@
+package am
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+)
+
+const (
+	CommandRequestIDHdr = CommandHdrPrefix + "REQUEST_ID"
+	ReplyRequestIDHdr   = ReplyHdrPrefix + "REQUEST_ID"
+)
+
+// DefaultRequestTimeout is used for requests made with a context that has no deadline
+const DefaultRequestTimeout = 30 * time.Second
+
+type (
+	// Requester publishes commands and waits for their replies
+	Requester interface {
+		// Request returns the reply to the command; failure replies are returned
+		// with an error
+		Request(ctx context.Context, topicName string, cmd ddd.Command) (ddd.Reply, error)
+		Close() error
+	}
+
+	requester struct {
+		publisher    CommandPublisher
+		replyChannel string
+		subscription Subscription
+		mu           sync.Mutex
+		pending      map[string]chan ddd.Reply
+	}
+)
+
+var _ Requester = (*requester)(nil)
+
+// NewRequester subscribes to a reply channel for this instance, named using the
+// channel prefix, which receives the replies to every request it makes
+func NewRequester(reg registry.Registry, stream MessageStream, channelPrefix string, mws ...MessageHandlerMiddleware) (Requester, error) {
+	r := &requester{
+		publisher:    NewCommandPublisher(reg, stream),
+		replyChannel: fmt.Sprintf("%s.%s", channelPrefix, uuid.New().String()),
+		pending:      make(map[string]chan ddd.Reply),
+	}
+
+	subscription, err := stream.Subscribe(r.replyChannel, NewReplyHandler(reg, r, mws...), AckType(AckTypeAuto))
+	if err != nil {
+		return nil, err
+	}
+	r.subscription = subscription
+
+	return r, nil
+}
+
+func (r *requester) Request(ctx context.Context, topicName string, cmd ddd.Command) (ddd.Reply, error) {
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
+
+	requestID := cmd.ID()
+	replies := make(chan ddd.Reply, 1)
+
+	r.mu.Lock()
+	r.pending[requestID] = replies
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, requestID)
+		r.mu.Unlock()
+	}()
+
+	cmd.Metadata().Set(CommandReplyChannelHdr, r.replyChannel)
+	cmd.Metadata().Set(CommandRequestIDHdr, requestID)
+
+	if err := r.publisher.Publish(ctx, topicName, cmd); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-replies:
+		if outcome, _ := reply.Metadata().Get(ReplyOutcomeHdr).(string); outcome != OutcomeSuccess {
+			return reply, errors.ErrInternal.Msgf("the %s command failed", cmd.CommandName())
+		}
+		return reply, nil
+	case <-ctx.Done():
+		return nil, errors.ErrDeadlineExceeded.Wrapf(ctx.Err(), "waiting for the reply to the %s command", cmd.CommandName())
+	}
+}
+
+func (r *requester) Close() error {
+	return r.subscription.Unsubscribe()
+}
+
+// HandleReply hands the reply to the request waiting for it; replies to requests
+// that are no longer waiting are dropped
+func (r *requester) HandleReply(_ context.Context, reply ddd.Reply) error {
+	requestID, _ := reply.Metadata().Get(ReplyRequestIDHdr).(string)
+
+	r.mu.Lock()
+	replies, exists := r.pending[requestID]
+	r.mu.Unlock()
+
+	if exists {
+		select {
+		case replies <- reply:
+		default:
+		}
+	}
+
+	return nil
+}
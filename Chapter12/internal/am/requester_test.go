@@ -0,0 +1,150 @@
+package am_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stackus/errors"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/am/memstream"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+	"eda-in-golang/internal/registry/serdes"
+)
+
+const (
+	testCommand        = "test.GetThing"
+	testReply          = "test.Thing"
+	testCommandChannel = "test.commands"
+)
+
+type getThing struct {
+	ID string
+}
+
+type thing struct {
+	ID   string
+	Name string
+}
+
+func setupRequester(t *testing.T, handler ddd.CommandHandlerFunc[ddd.Command]) am.Requester {
+	t.Helper()
+
+	reg := registry.New()
+	serde := serdes.NewJsonSerde(reg)
+	assert.NoError(t, serde.RegisterKey(testCommand, getThing{}))
+	assert.NoError(t, serde.RegisterKey(testReply, thing{}))
+
+	stream := memstream.NewStream(zerolog.Nop())
+	t.Cleanup(func() { _ = stream.Unsubscribe() })
+
+	_, err := stream.Subscribe(testCommandChannel, am.NewCommandHandler(reg, am.NewReplyPublisher(reg, stream), handler), am.GroupName("things"))
+	assert.NoError(t, err)
+
+	requester, err := am.NewRequester(reg, stream, "test.replies")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = requester.Close() })
+
+	return requester
+}
+
+func TestRequester_Reply(t *testing.T) {
+	requester := setupRequester(t, func(ctx context.Context, cmd ddd.Command) (ddd.Reply, error) {
+		payload := cmd.Payload().(*getThing)
+		return ddd.NewReply(testReply, &thing{ID: payload.ID, Name: "the thing"}), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	reply, err := requester.Request(ctx, testCommandChannel, ddd.NewCommand(testCommand, &getThing{ID: "thing-1"}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, testReply, reply.ReplyName())
+		assert.Equal(t, &thing{ID: "thing-1", Name: "the thing"}, reply.Payload())
+	}
+}
+
+func TestRequester_FailureReply(t *testing.T) {
+	requester := setupRequester(t, func(ctx context.Context, cmd ddd.Command) (ddd.Reply, error) {
+		return nil, errors.ErrNotFound.Msg("no such thing")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	reply, err := requester.Request(ctx, testCommandChannel, ddd.NewCommand(testCommand, &getThing{ID: "thing-1"}))
+	assert.Error(t, err)
+	if assert.NotNil(t, reply) {
+		assert.Equal(t, am.FailureReply, reply.ReplyName())
+	}
+}
+
+func TestRequester_Timeout(t *testing.T) {
+	requester := setupRequester(t, func(ctx context.Context, cmd ddd.Command) (ddd.Reply, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := requester.Request(ctx, testCommandChannel, ddd.NewCommand(testCommand, &getThing{ID: "thing-1"}))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequester_PropagatesCausation(t *testing.T) {
+	var handled ddd.Metadata
+	requester := setupRequester(t, func(ctx context.Context, cmd ddd.Command) (ddd.Reply, error) {
+		handled = ddd.MetadataFromContext(ctx)
+		return ddd.NewReply(testReply, &thing{ID: "thing-1"}), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	ctx = ddd.ContextWithMetadata(ctx, ddd.Metadata{
+		ddd.CorrelationIDKey: "correlation-1",
+		ddd.ActorKey:         "customer-1",
+	})
+
+	cmd := ddd.NewCommand(testCommand, &getThing{ID: "thing-1"})
+	reply, err := requester.Request(ctx, testCommandChannel, cmd)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "correlation-1", handled.Get(ddd.CorrelationIDKey))
+		assert.Equal(t, cmd.ID(), handled.Get(ddd.CausationIDKey))
+		assert.Equal(t, "customer-1", handled.Get(ddd.ActorKey))
+		assert.Equal(t, "correlation-1", reply.Metadata().Get(ddd.CorrelationIDKey))
+		assert.Equal(t, cmd.ID(), reply.Metadata().Get(ddd.CausationIDKey))
+	}
+}
+
+func TestCommandHandler_WithoutReplyChannel(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testCommand, getThing{}))
+
+	stream := memstream.NewStream(zerolog.Nop())
+	t.Cleanup(func() { _ = stream.Unsubscribe() })
+
+	handled := make(chan string, 1)
+	// no reply publisher; a command without a reply channel is not replied to
+	_, err := stream.Subscribe(testCommandChannel, am.NewCommandHandler(reg, nil, ddd.CommandHandlerFunc[ddd.Command](
+		func(ctx context.Context, cmd ddd.Command) (ddd.Reply, error) {
+			handled <- cmd.Payload().(*getThing).ID
+			return nil, nil
+		}),
+	), am.GroupName("things"))
+	assert.NoError(t, err)
+
+	err = am.NewCommandPublisher(reg, stream).Publish(context.Background(), testCommandChannel, ddd.NewCommand(testCommand, &getThing{ID: "thing-1"}))
+	assert.NoError(t, err)
+
+	select {
+	case id := <-handled:
+		assert.Equal(t, "thing-1", id)
+	case <-time.After(time.Second):
+		t.Fatal("the command was not handled")
+	}
+}
@@ -0,0 +1,116 @@
+package am
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+type (
+	// RetryPolicy decides how long to wait before each retry of a failed
+	// publish or message delivery
+	RetryPolicy interface {
+		// Backoff returns the delay before the retry attempt; the first retry is attempt 1
+		Backoff(attempt int) time.Duration
+		// MaxElapsedTime returns how long retries may continue for; zero means no limit
+		MaxElapsedTime() time.Duration
+	}
+
+	constantBackoff struct {
+		delay time.Duration
+	}
+
+	exponentialBackoff struct {
+		initial    time.Duration
+		max        time.Duration
+		multiplier float64
+		jitter     bool
+	}
+
+	maxElapsedTime struct {
+		RetryPolicy
+		maxElapsed time.Duration
+	}
+)
+
+const defaultBackoffMultiplier = 2.0
+
+// ConstantBackoff waits the same delay before every retry
+func ConstantBackoff(delay time.Duration) RetryPolicy {
+	return constantBackoff{delay: delay}
+}
+
+// ExponentialBackoff doubles the delay with each retry starting at initial, up to max
+func ExponentialBackoff(initial, max time.Duration) RetryPolicy {
+	return exponentialBackoff{
+		initial:    initial,
+		max:        max,
+		multiplier: defaultBackoffMultiplier,
+	}
+}
+
+// ExponentialBackoffWithJitter is ExponentialBackoff with each delay picked at
+// random between zero and the exponential delay; "full jitter"
+func ExponentialBackoffWithJitter(initial, max time.Duration) RetryPolicy {
+	return exponentialBackoff{
+		initial:    initial,
+		max:        max,
+		multiplier: defaultBackoffMultiplier,
+		jitter:     true,
+	}
+}
+
+// WithMaxElapsedTime limits how long the policy will keep retrying for
+func WithMaxElapsedTime(policy RetryPolicy, maxElapsed time.Duration) RetryPolicy {
+	return maxElapsedTime{
+		RetryPolicy: policy,
+		maxElapsed:  maxElapsed,
+	}
+}
+
+// RetryExhausted reports whether the policy allows no more retries, given the
+// time the first attempt was made
+func RetryExhausted(policy RetryPolicy, startedAt time.Time) bool {
+	maxElapsed := policy.MaxElapsedTime()
+	return maxElapsed > 0 && time.Since(startedAt) >= maxElapsed
+}
+
+func (b constantBackoff) Backoff(int) time.Duration     { return b.delay }
+func (b constantBackoff) MaxElapsedTime() time.Duration { return 0 }
+
+func (b exponentialBackoff) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := float64(b.initial) * math.Pow(b.multiplier, float64(attempt-1))
+	if b.max > 0 && delay > float64(b.max) {
+		delay = float64(b.max)
+	}
+
+	if b.jitter {
+		delay = rand.Float64() * delay
+	}
+
+	return time.Duration(delay)
+}
+
+func (b exponentialBackoff) MaxElapsedTime() time.Duration { return 0 }
+
+func (p maxElapsedTime) MaxElapsedTime() time.Duration { return p.maxElapsed }
+
+type retryPolicyOption struct {
+	policy RetryPolicy
+}
+
+// RedeliveryPolicy applies the RetryPolicy to the redelivery of messages that
+// are NAcked or are not acknowledged in time; the max elapsed time is measured
+// from the first delivery by streams that record it, JetStream limits
+// redeliveries with MaxRedeliver alone
+func RedeliveryPolicy(policy RetryPolicy) SubscriberOption {
+	return retryPolicyOption{policy: policy}
+}
+
+func (o retryPolicyOption) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.retryPolicy = o.policy
+}
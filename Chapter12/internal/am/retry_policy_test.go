@@ -0,0 +1,78 @@
+package am
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	policy := ConstantBackoff(time.Second)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		assert.Equal(t, time.Second, policy.Backoff(attempt))
+	}
+	assert.Zero(t, policy.MaxElapsedTime())
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	policy := ExponentialBackoff(100*time.Millisecond, time.Second)
+
+	tests := map[string]struct {
+		attempt int
+		want    time.Duration
+	}{
+		"BeforeFirst": {attempt: 0, want: 100 * time.Millisecond},
+		"First":       {attempt: 1, want: 100 * time.Millisecond},
+		"Second":      {attempt: 2, want: 200 * time.Millisecond},
+		"Fourth":      {attempt: 4, want: 800 * time.Millisecond},
+		"Capped":      {attempt: 5, want: time.Second},
+		"FarCapped":   {attempt: 100, want: time.Second},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Backoff(tt.attempt))
+		})
+	}
+	assert.Zero(t, policy.MaxElapsedTime())
+}
+
+func TestExponentialBackoffWithJitter(t *testing.T) {
+	policy := ExponentialBackoffWithJitter(100*time.Millisecond, time.Second)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := ExponentialBackoff(100*time.Millisecond, time.Second).Backoff(attempt)
+		for i := 0; i < 20; i++ {
+			delay := policy.Backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling)
+		}
+	}
+}
+
+func TestWithMaxElapsedTime(t *testing.T) {
+	policy := WithMaxElapsedTime(ConstantBackoff(time.Second), time.Minute)
+
+	assert.Equal(t, time.Second, policy.Backoff(3))
+	assert.Equal(t, time.Minute, policy.MaxElapsedTime())
+}
+
+func TestRetryExhausted(t *testing.T) {
+	limited := WithMaxElapsedTime(ConstantBackoff(time.Second), time.Minute)
+
+	tests := map[string]struct {
+		policy    RetryPolicy
+		startedAt time.Time
+		want      bool
+	}{
+		"WithinLimit": {policy: limited, startedAt: time.Now().Add(-30 * time.Second), want: false},
+		"PastLimit":   {policy: limited, startedAt: time.Now().Add(-2 * time.Minute), want: true},
+		"NoLimit":     {policy: ConstantBackoff(time.Second), startedAt: time.Now().Add(-time.Hour), want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RetryExhausted(tt.policy, tt.startedAt))
+		})
+	}
+}
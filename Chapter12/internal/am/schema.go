@@ -0,0 +1,64 @@
+package am
+
+import (
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+)
+
+const (
+	ContentTypeHdr   = "CONTENT_TYPE"
+	SchemaVersionHdr = "SCHEMA_VERSION"
+)
+
+// serializePayload serializes the payload registered with the key and returns a
+// copy of the metadata with the content type and schema version headers added
+func serializePayload(reg registry.Registry, key string, payload any, metadata ddd.Metadata) ([]byte, ddd.Metadata, error) {
+	data, err := reg.Serialize(key, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schema, err := reg.Schema(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := make(ddd.Metadata, len(metadata)+2)
+	for key, value := range metadata {
+		headers[key] = value
+	}
+	headers.Set(ContentTypeHdr, schema.ContentType)
+	headers.Set(SchemaVersionHdr, schema.Version)
+
+	return data, headers, nil
+}
+
+// deserializePayload uses the serde for the content type the payload was sent
+// with and upcasts payloads sent using older schema versions; messages sent
+// without the headers use the default serde and are taken to be at version 1
+func deserializePayload(reg registry.Registry, key string, data []byte, metadata ddd.Metadata) (any, error) {
+	contentType, _ := metadata.Get(ContentTypeHdr).(string)
+
+	version := SchemaVersion(metadata)
+	if version == 0 {
+		version = 1
+	}
+
+	return reg.DeserializeSchema(key, registry.Schema{ContentType: contentType, Version: version}, data)
+}
+
+// SchemaVersion returns the schema version the payload of a message was sent
+// with, or zero when it was sent without one
+func SchemaVersion(metadata ddd.Metadata) int {
+	// numbers will be float64 after a trip through the message stream
+	switch v := metadata.Get(SchemaVersionHdr).(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
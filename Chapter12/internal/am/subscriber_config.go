@@ -1,6 +1,7 @@
 package am
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -13,22 +14,37 @@ const (
 
 var defaultAckWait = 30 * time.Second
 var defaultMaxRedeliver = 5
+var defaultBatchMaxWait = 5 * time.Second
 
 type SubscriberConfig struct {
-	msgFilter    []string
-	groupName    string
-	ackType      AckType
-	ackWait      time.Duration
-	maxRedeliver int
+	msgFilter       []string
+	groupName       string
+	ackType         AckType
+	ackWait         time.Duration
+	maxRedeliver    int
+	deadLetterTopic string
+	retryPolicy     RetryPolicy
+	maxAckPending   int
+	maxConcurrency  int
+	partitionBy     PartitionBy
+	batchSize       int
+	batchMaxWait    time.Duration
 }
 
 func NewSubscriberConfig(options []SubscriberOption) SubscriberConfig {
 	cfg := SubscriberConfig{
-		msgFilter:    []string{},
-		groupName:    "",
-		ackType:      AckTypeManual,
-		ackWait:      defaultAckWait,
-		maxRedeliver: defaultMaxRedeliver,
+		msgFilter:       []string{},
+		groupName:       "",
+		ackType:         AckTypeManual,
+		ackWait:         defaultAckWait,
+		maxRedeliver:    defaultMaxRedeliver,
+		deadLetterTopic: "",
+		retryPolicy:     nil,
+		maxAckPending:   0,
+		maxConcurrency:  1,
+		partitionBy:     nil,
+		batchSize:       0,
+		batchMaxWait:    defaultBatchMaxWait,
 	}
 
 	for _, option := range options {
@@ -62,6 +78,39 @@ func (c SubscriberConfig) MaxRedeliver() int {
 	return c.maxRedeliver
 }
 
+func (c SubscriberConfig) DeadLetterTopic() string {
+	return c.deadLetterTopic
+}
+
+func (c SubscriberConfig) RetryPolicy() RetryPolicy {
+	return c.retryPolicy
+}
+
+func (c SubscriberConfig) MaxAckPending() int {
+	return c.maxAckPending
+}
+
+func (c SubscriberConfig) MaxConcurrency() int {
+	return c.maxConcurrency
+}
+
+func (c SubscriberConfig) PartitionBy() PartitionBy {
+	return c.partitionBy
+}
+
+// Pull reports whether the subscription should fetch batches of messages
+func (c SubscriberConfig) Pull() bool {
+	return c.batchSize > 0
+}
+
+func (c SubscriberConfig) BatchSize() int {
+	return c.batchSize
+}
+
+func (c SubscriberConfig) BatchMaxWait() time.Duration {
+	return c.batchMaxWait
+}
+
 type MessageFilter []string
 
 func (s MessageFilter) configureSubscriberConfig(cfg *SubscriberConfig) {
@@ -89,3 +138,62 @@ type MaxRedeliver int
 func (i MaxRedeliver) configureSubscriberConfig(cfg *SubscriberConfig) {
 	cfg.maxRedeliver = int(i)
 }
+
+type DeadLetterTopic string
+
+func (t DeadLetterTopic) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.deadLetterTopic = string(t)
+}
+
+// MaxAckPending limits the number of messages delivered to the subscription that
+// are waiting to be acknowledged
+type MaxAckPending int
+
+func (i MaxAckPending) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.maxAckPending = int(i)
+}
+
+// MaxConcurrency sets the number of messages that may be handled at the same time;
+// pull subscriptions with a BatchMessageHandler handle up to this number of batches
+type MaxConcurrency int
+
+func (i MaxConcurrency) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.maxConcurrency = int(i)
+}
+
+// PartitionBy returns the key used to keep messages in order; messages with
+// the same key are handled one at a time in the order they were received. A
+// message that fails does not hold back the messages after it, so they are
+// handled before its redelivery
+type PartitionBy func(msg MessageBase) string
+
+func (fn PartitionBy) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.partitionBy = fn
+}
+
+// PartitionByMetadata partitions messages using the value of a metadata key,
+// such as ddd.AggregateIDKey
+func PartitionByMetadata(key string) PartitionBy {
+	return func(msg MessageBase) string {
+		if value := msg.Metadata().Get(key); value != nil {
+			return fmt.Sprint(value)
+		}
+		return ""
+	}
+}
+
+// PullBatchSize switches the subscription to fetch batches of up to the given
+// number of messages; handlers that are a BatchMessageHandler receive the whole
+// batch at once
+type PullBatchSize int
+
+func (i PullBatchSize) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.batchSize = int(i)
+}
+
+// PullMaxWait is the longest a pull subscription will wait to fill a batch
+type PullMaxWait time.Duration
+
+func (w PullMaxWait) configureSubscriberConfig(cfg *SubscriberConfig) {
+	cfg.batchMaxWait = time.Duration(w)
+}
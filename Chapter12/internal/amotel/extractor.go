@@ -25,7 +25,7 @@ func OtelMessageContextExtractor() am.MessageHandlerMiddleware {
 			)
 			defer span.End()
 
-			err := next.HandleMessage(ctx, msg)
+			err := next.HandleMessage(ContextWithTraceMetadata(ctx), msg)
 			if err != nil {
 				span.RecordError(err)
 				span.SetStatus(codes.Error, err.Error())
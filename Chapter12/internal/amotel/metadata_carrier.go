@@ -1,6 +1,7 @@
 package amotel
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -33,3 +34,12 @@ func (mc MetadataCarrier) Set(key, value string) {
 func (mc MetadataCarrier) Keys() []string {
 	return ddd.Metadata(mc).Keys()
 }
+
+// ContextWithTraceMetadata adds the trace context of the current span to the
+// metadata carried by the context so that it is kept with new events
+func ContextWithTraceMetadata(ctx context.Context) context.Context {
+	md := make(ddd.Metadata)
+	propagator.Inject(ctx, MetadataCarrier(md))
+
+	return ddd.ContextWithMetadata(ctx, md)
+}
@@ -12,16 +12,61 @@ import (
 	"eda-in-golang/internal/web"
 )
 
+// Message stream drivers
+const (
+	NatsStreamDriver   = "nats"
+	KafkaStreamDriver  = "kafka"
+	MemoryStreamDriver = "memory"
+)
+
+// Outbox relays
+const (
+	PollingOutboxRelay     = "polling"
+	ReplicationOutboxRelay = "replication"
+)
+
 type (
 	PGConfig struct {
 		Conn string `required:"true"`
 	}
 
 	NatsConfig struct {
-		URL    string `required:"true"`
+		URL    string
 		Stream string `default:"mallbots"`
 	}
 
+	KafkaConfig struct {
+		Brokers []string
+	}
+
+	OutboxConfig struct {
+		BatchSize       int           `envconfig:"BATCH_SIZE" default:"50"`
+		PollingInterval time.Duration `envconfig:"POLLING_INTERVAL" default:"5s"`
+		MaxAttempts     int           `envconfig:"MAX_ATTEMPTS" default:"10"`
+		Notify          bool          `default:"true"`
+		Relay           string        `default:"polling"`
+	}
+
+	RetentionConfig struct {
+		Interval     time.Duration `default:"1h"`
+		BatchSize    int           `envconfig:"BATCH_SIZE" default:"1000"`
+		OutboxMaxAge time.Duration `envconfig:"OUTBOX_MAX_AGE" default:"168h"`
+		InboxMaxAge  time.Duration `envconfig:"INBOX_MAX_AGE" default:"48h"`
+		ArchiveDir   string        `envconfig:"ARCHIVE_DIR"`
+	}
+
+	SnapshotConfig struct {
+		// Async takes snapshots in the background after the commands have committed
+		Async bool `default:"true"`
+		// Every is the number of events between the snapshots of an aggregate; it
+		// is low for demonstration, production envs should use 50, 75, 100...
+		Every int `default:"3"`
+		// Aggregates overrides the strategy of the named aggregates, e.g.
+		// "stores.Product:every=50,stores.Store:after=24h+on=stores.StoreRebranded,ordering.Order:never";
+		// see es.ParseSnapshotStrategy
+		Aggregates map[string]string
+	}
+
 	OtelConfig struct {
 		ServiceName      string `envconfig:"SERVICE_NAME" default:"mallbots"`
 		ExporterEndpoint string `envconfig:"EXPORTER_OTLP_ENDPOINT" default:"http://collector:4317"`
@@ -31,9 +76,14 @@ type (
 		LogLevel        string `envconfig:"LOG_LEVEL" default:"DEBUG"`
 		PG              PGConfig
 		Nats            NatsConfig
+		Kafka           KafkaConfig
 		Rpc             rpc.RpcConfig
 		Web             web.WebConfig
 		Otel            OtelConfig
+		Outbox          OutboxConfig
+		Retention       RetentionConfig
+		Snapshots       SnapshotConfig
+		StreamDriver    string        `envconfig:"STREAM_DRIVER" default:"nats"`
 		ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"30s"`
 	}
 )
@@ -0,0 +1,69 @@
+package ddd
+
+import (
+	"context"
+)
+
+const (
+	CorrelationIDKey = "correlation-id"
+	CausationIDKey   = "causation-id"
+	ActorKey         = "actor"
+)
+
+type contextKey int
+
+const metadataContextKey contextKey = iota
+
+// ContextWithMetadata returns a context carrying the metadata together with any
+// already carried; the metadata is added to the events, commands and replies
+// created while the context is in use
+func ContextWithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	md := MetadataFromContext(ctx)
+	for key, value := range metadata {
+		md[key] = value
+	}
+
+	return context.WithValue(ctx, metadataContextKey, md)
+}
+
+// MetadataFromContext returns a copy of the metadata carried by the context
+func MetadataFromContext(ctx context.Context) Metadata {
+	md := make(Metadata)
+	if carried, ok := ctx.Value(metadataContextKey).(Metadata); ok {
+		for key, value := range carried {
+			md[key] = value
+		}
+	}
+
+	return md
+}
+
+// ContextWithCause returns a context for handling an event, command or reply;
+// whatever is created while handling it is caused by it and shares its
+// correlation ID, which is the ID of the first cause when it has none
+func ContextWithCause(ctx context.Context, causeID string, causeMetadata Metadata) context.Context {
+	correlationID, _ := causeMetadata.Get(CorrelationIDKey).(string)
+	if correlationID == "" {
+		correlationID = causeID
+	}
+
+	md := Metadata{
+		CorrelationIDKey: correlationID,
+		CausationIDKey:   causeID,
+	}
+	if actor, ok := causeMetadata.Get(ActorKey).(string); ok && actor != "" {
+		md.Set(ActorKey, actor)
+	}
+
+	return ContextWithMetadata(ctx, md)
+}
+
+// AddContextMetadata adds the metadata carried by the context to the metadata;
+// values that have already been set are kept
+func AddContextMetadata(ctx context.Context, metadata Metadata) {
+	for key, value := range MetadataFromContext(ctx) {
+		if _, exists := metadata[key]; !exists {
+			metadata[key] = value
+		}
+	}
+}
@@ -62,13 +62,15 @@ func (h *EventDispatcher[T]) Subscribe(handler EventHandler[T], events ...string
 
 func (h *EventDispatcher[T]) Publish(ctx context.Context, events ...T) error {
 	for _, event := range events {
+		AddContextMetadata(ctx, event.Metadata())
+		handlerCtx := ContextWithCause(ctx, event.ID(), event.Metadata())
 		for _, handler := range h.handlers {
 			if handler.filters != nil {
 				if _, exists := handler.filters[event.EventName()]; !exists {
 					continue
 				}
 			}
-			err := handler.h.HandleEvent(ctx, event)
+			err := handler.h.HandleEvent(handlerCtx, event)
 			if err != nil {
 				return err
 			}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/stackus/errors"
+
 	"eda-in-golang/internal/ddd"
 	"eda-in-golang/internal/registry"
 )
@@ -12,22 +14,53 @@ type (
 	AggregateRepository[T EventSourcedAggregate] interface {
 		Load(ctx context.Context, aggregateID string) (agg T, err error)
 		Save(ctx context.Context, aggregate T) error
+		Update(ctx context.Context, aggregateID string, fn func(T) error) error
+	}
+
+	AggregateRepositoryOption interface {
+		configureAggregateRepository(*aggregateRepositoryConfig)
+	}
+
+	// ConflictRetries is how many more times Update will reload the aggregate and
+	// run the command after a concurrency conflict
+	ConflictRetries int
+
+	aggregateRepositoryConfig struct {
+		retries int
 	}
 
 	aggregateRepository[T EventSourcedAggregate] struct {
 		aggregateName string
 		registry      registry.Registry
 		store         AggregateStore
+		retries       int
 	}
 )
 
+const defaultConflictRetries = 3
+
 var _ AggregateRepository[EventSourcedAggregate] = (*aggregateRepository[EventSourcedAggregate])(nil)
 
-func NewAggregateRepository[T EventSourcedAggregate](aggregateName string, registry registry.Registry, store AggregateStore) aggregateRepository[T] {
+func NewAggregateRepository[T EventSourcedAggregate](aggregateName string, registry registry.Registry, store AggregateStore, options ...AggregateRepositoryOption) aggregateRepository[T] {
+	cfg := &aggregateRepositoryConfig{
+		retries: defaultConflictRetries,
+	}
+
+	for _, option := range options {
+		option.configureAggregateRepository(cfg)
+	}
+
 	return aggregateRepository[T]{
 		aggregateName: aggregateName,
 		registry:      registry,
 		store:         store,
+		retries:       cfg.retries,
+	}
+}
+
+func (r ConflictRetries) configureAggregateRepository(cfg *aggregateRepositoryConfig) {
+	if r >= 0 {
+		cfg.retries = int(r)
 	}
 }
 
@@ -60,6 +93,7 @@ func (r aggregateRepository[T]) Save(ctx context.Context, aggregate T) error {
 	}
 
 	for _, event := range aggregate.Events() {
+		ddd.AddContextMetadata(ctx, event.Metadata())
 		if err := aggregate.ApplyEvent(event); err != nil {
 			return err
 		}
@@ -74,3 +108,28 @@ func (r aggregateRepository[T]) Save(ctx context.Context, aggregate T) error {
 
 	return nil
 }
+
+// Update loads the aggregate, runs fn against it and saves the result; fn is
+// run again on a freshly loaded aggregate when the save is in conflict
+func (r aggregateRepository[T]) Update(ctx context.Context, aggregateID string, fn func(T) error) error {
+	for attempt := 0; ; attempt++ {
+		aggregate, err := r.Load(ctx, aggregateID)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(aggregate); err != nil {
+			return err
+		}
+
+		err = r.Save(ctx, aggregate)
+		if err == nil {
+			return nil
+		}
+
+		var conflict ErrConcurrencyConflict
+		if !errors.As(err, &conflict) || attempt >= r.retries {
+			return err
+		}
+	}
+}
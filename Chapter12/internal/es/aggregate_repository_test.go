@@ -0,0 +1,82 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+	"eda-in-golang/internal/registry/serdes"
+)
+
+const testAggregateName = "es.TestAggregate"
+
+type testAggregate struct {
+	Aggregate
+}
+
+func (a *testAggregate) ApplyEvent(ddd.Event) error { return nil }
+
+func TestAggregateRepository_Update(t *testing.T) {
+	conflict := ErrConcurrencyConflict{AggregateName: testAggregateName, AggregateID: "agg-id", ExpectedVersion: 1, ActualVersion: 2}
+
+	tests := map[string]struct {
+		options  []AggregateRepositoryOption
+		saveErrs []error
+		wantRuns int
+		wantErr  error
+	}{
+		"Success": {
+			saveErrs: []error{nil},
+			wantRuns: 1,
+		},
+		"RetriedConflict": {
+			saveErrs: []error{conflict, nil},
+			wantRuns: 2,
+		},
+		"TooManyConflicts": {
+			options:  []AggregateRepositoryOption{ConflictRetries(1)},
+			saveErrs: []error{conflict, conflict},
+			wantRuns: 2,
+			wantErr:  conflict,
+		},
+		"OtherError": {
+			saveErrs: []error{fmt.Errorf("save failed")},
+			wantRuns: 1,
+			wantErr:  fmt.Errorf("save failed"),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := registry.New()
+			err := serdes.NewJsonSerde(reg).RegisterFactory(testAggregateName, func() interface{} {
+				return &testAggregate{Aggregate: NewAggregate("", testAggregateName)}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			store := NewMockAggregateStore(t)
+			store.On("Load", context.Background(), mock.Anything).Return(nil)
+			for _, saveErr := range tt.saveErrs {
+				store.On("Save", context.Background(), mock.Anything).Return(saveErr).Once()
+			}
+
+			repo := NewAggregateRepository[*testAggregate](testAggregateName, reg, store, tt.options...)
+
+			var runs int
+			err = repo.Update(context.Background(), "agg-id", func(agg *testAggregate) error {
+				runs++
+				agg.AddEvent("es.TestEvent", nil)
+				return nil
+			})
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantRuns, runs)
+		})
+	}
+}
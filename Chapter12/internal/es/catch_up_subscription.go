@@ -0,0 +1,179 @@
+package es
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"eda-in-golang/internal/ddd"
+)
+
+const defaultStreamBatchSize = 100
+const defaultStreamPollingInterval = 500 * time.Millisecond
+const streamListenRetryInterval = time.Second
+
+type (
+	CatchUpSubscriptionOption interface {
+		configureCatchUpSubscription(*CatchUpSubscription)
+	}
+
+	// StreamBatchSize is the most events read from the stream at a time
+	StreamBatchSize int
+
+	// StreamPollingInterval is how long a subscription that has caught up waits
+	// before looking for new events again; with notifications it is only a fallback
+	StreamPollingInterval time.Duration
+
+	// StreamAggregateName limits a subscription to the events of one aggregate type
+	StreamAggregateName string
+
+	streamNotifications struct {
+		notifier EventStreamNotifier
+	}
+
+	// CatchUpSubscription hands every event after a position to a handler, first
+	// reading the events already saved and then tailing new events as they are
+	// committed
+	CatchUpSubscription struct {
+		reader          EventStreamReader
+		handler         ddd.EventHandler[StreamEvent]
+		aggregateName   string
+		batchSize       int
+		pollingInterval time.Duration
+		notifier        EventStreamNotifier
+		position        atomic.Int64
+		caughtUp        chan struct{}
+		caughtUpOnce    sync.Once
+	}
+)
+
+func NewCatchUpSubscription(reader EventStreamReader, handler ddd.EventHandler[StreamEvent], options ...CatchUpSubscriptionOption) *CatchUpSubscription {
+	s := &CatchUpSubscription{
+		reader:          reader,
+		handler:         handler,
+		batchSize:       defaultStreamBatchSize,
+		pollingInterval: defaultStreamPollingInterval,
+		caughtUp:        make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option.configureCatchUpSubscription(s)
+	}
+
+	return s
+}
+
+// StreamNotifications wakes a subscription that has caught up as soon as new
+// events are saved
+func StreamNotifications(notifier EventStreamNotifier) CatchUpSubscriptionOption {
+	return streamNotifications{notifier: notifier}
+}
+
+func (s StreamBatchSize) configureCatchUpSubscription(sub *CatchUpSubscription) {
+	if s > 0 {
+		sub.batchSize = int(s)
+	}
+}
+
+func (i StreamPollingInterval) configureCatchUpSubscription(sub *CatchUpSubscription) {
+	if i > 0 {
+		sub.pollingInterval = time.Duration(i)
+	}
+}
+
+func (n StreamAggregateName) configureCatchUpSubscription(sub *CatchUpSubscription) {
+	sub.aggregateName = string(n)
+}
+
+func (n streamNotifications) configureCatchUpSubscription(sub *CatchUpSubscription) {
+	sub.notifier = n.notifier
+}
+
+// Subscribe handles the events after the position until the context is done or
+// the handler fails
+func (s *CatchUpSubscription) Subscribe(ctx context.Context, after int64) error {
+	s.position.Store(after)
+
+	wake := make(chan struct{}, 1)
+	if s.notifier != nil {
+		listenCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go s.listen(listenCtx, wake)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		events, err := s.reader.ReadEvents(ctx, EventStreamFilter{
+			After:         s.position.Load(),
+			AggregateName: s.aggregateName,
+			Limit:         s.batchSize,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for _, event := range events {
+			if err = s.handler.HandleEvent(ctx, event); err != nil {
+				return err
+			}
+			s.position.Store(event.GlobalPosition())
+		}
+
+		if len(events) == s.batchSize {
+			// still catching up
+			continue
+		}
+
+		s.caughtUpOnce.Do(func() { close(s.caughtUp) })
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.pollingInterval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// CaughtUp is closed once the subscription has read every event saved before
+// it switched to tailing new events
+func (s *CatchUpSubscription) CaughtUp() <-chan struct{} {
+	return s.caughtUp
+}
+
+// Position is the position of the last event handled
+func (s *CatchUpSubscription) Position() int64 {
+	return s.position.Load()
+}
+
+func (s *CatchUpSubscription) listen(ctx context.Context, wake chan<- struct{}) {
+	notify := func() {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		_ = s.notifier.Listen(ctx, notify)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamListenRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/ddd"
+)
+
+type testStreamEvent struct {
+	ddd.AggregateEvent
+	position int64
+}
+
+func (e testStreamEvent) GlobalPosition() int64 { return e.position }
+
+type testEventStream struct {
+	events []StreamEvent
+	mu     sync.Mutex
+}
+
+func (s *testEventStream) append(aggregateName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agg := NewAggregate(fmt.Sprintf("agg-%d", len(s.events)), aggregateName)
+	agg.AddEvent("es.TestEvent", nil)
+	s.events = append(s.events, testStreamEvent{
+		AggregateEvent: agg.Events()[0],
+		position:       int64(len(s.events) + 1),
+	})
+}
+
+func (s *testEventStream) ReadEvents(_ context.Context, filter EventStreamFilter) ([]StreamEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []StreamEvent
+	for _, event := range s.events {
+		if event.GlobalPosition() <= filter.After {
+			continue
+		}
+		if filter.AggregateName != "" && event.AggregateName() != filter.AggregateName {
+			continue
+		}
+		if len(events) == filter.Limit {
+			break
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func TestCatchUpSubscription_Subscribe(t *testing.T) {
+	stream := &testEventStream{}
+	for i := 0; i < 5; i++ {
+		stream.append("es.Tracked")
+		stream.append("es.Ignored")
+	}
+
+	handled := make(chan int64, 20)
+	sub := NewCatchUpSubscription(stream, ddd.EventHandlerFunc[StreamEvent](func(ctx context.Context, event StreamEvent) error {
+		handled <- event.GlobalPosition()
+		return nil
+	}), StreamBatchSize(2), StreamPollingInterval(10*time.Millisecond), StreamAggregateName("es.Tracked"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- sub.Subscribe(ctx, 2) }()
+
+	select {
+	case <-sub.CaughtUp():
+	case <-time.After(time.Second):
+		t.Fatal("subscription did not catch up")
+	}
+
+	stream.append("es.Tracked")
+
+	var positions []int64
+	for len(positions) < 5 {
+		select {
+		case position := <-handled:
+			positions = append(positions, position)
+		case <-time.After(time.Second):
+			t.Fatalf("only handled %v", positions)
+		}
+	}
+
+	assert.Equal(t, []int64{3, 5, 7, 9, 11}, positions)
+	assert.Eventually(t, func() bool { return sub.Position() == 11 }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
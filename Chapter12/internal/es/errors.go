@@ -0,0 +1,27 @@
+package es
+
+import (
+	"fmt"
+
+	"github.com/stackus/errors"
+)
+
+// ErrConcurrencyConflict is returned when an aggregate is saved after another
+// writer has already added events at the versions it expected to use
+type ErrConcurrencyConflict struct {
+	AggregateName   string
+	AggregateID     string
+	ExpectedVersion int
+	ActualVersion   int
+}
+
+func (e ErrConcurrencyConflict) Error() string {
+	return fmt.Sprintf("concurrency conflict saving %s %s: expected version %d, actual version %d",
+		e.AggregateName, e.AggregateID, e.ExpectedVersion, e.ActualVersion,
+	)
+}
+
+// Unwrap lets the conflict be reported by the api as an aborted request
+func (e ErrConcurrencyConflict) Unwrap() error {
+	return errors.ErrAborted
+}
@@ -0,0 +1,42 @@
+package es
+
+import (
+	"context"
+
+	"eda-in-golang/internal/ddd"
+)
+
+type (
+	// StreamEvent is an event read from the stream of every event saved by a
+	// module, together with its position in that stream
+	StreamEvent interface {
+		ddd.AggregateEvent
+		GlobalPosition() int64
+	}
+
+	// EventStreamFilter picks which events are read from the stream
+	EventStreamFilter struct {
+		// After is the position of the last event already read; zero reads from
+		// the beginning
+		After int64
+		// AggregateName limits the events to those of one aggregate type, e.g.
+		// "ordering.Order"; all events are read when it is empty
+		AggregateName string
+		Limit         int
+	}
+
+	// EventStreamReader reads the events of every aggregate in the order they
+	// were committed. Positions are unique but events committed together with
+	// others may be read after events with higher positions; the position of
+	// the last event read is the checkpoint to continue from.
+	EventStreamReader interface {
+		ReadEvents(ctx context.Context, filter EventStreamFilter) ([]StreamEvent, error)
+	}
+
+	// EventStreamNotifier tells a subscription when new events have been saved
+	EventStreamNotifier interface {
+		// Listen calls notify each time events have been saved until the context
+		// is done or the connection fails
+		Listen(ctx context.Context, notify func()) error
+	}
+)
@@ -56,6 +56,19 @@ func (r *FakeAggregateRepository[T]) Save(ctx context.Context, aggregate T) erro
 	return nil
 }
 
+func (r *FakeAggregateRepository[T]) Update(ctx context.Context, aggregateID string, fn func(T) error) error {
+	aggregate, err := r.Load(ctx, aggregateID)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(aggregate); err != nil {
+		return err
+	}
+
+	return r.Save(ctx, aggregate)
+}
+
 func (r *FakeAggregateRepository[T]) Reset(aggregates ...T) {
 	r.aggregates = make(map[string]T)
 
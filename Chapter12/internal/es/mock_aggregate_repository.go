@@ -48,6 +48,20 @@ func (_m *MockAggregateRepository[T]) Save(ctx context.Context, aggregate T) err
 	return r0
 }
 
+// Update provides a mock function with given fields: ctx, aggregateID, fn
+func (_m *MockAggregateRepository[T]) Update(ctx context.Context, aggregateID string, fn func(T) error) error {
+	ret := _m.Called(ctx, aggregateID, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(T) error) error); ok {
+		r0 = rf(ctx, aggregateID, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewMockAggregateRepository interface {
 	mock.TestingT
 	Cleanup(func())
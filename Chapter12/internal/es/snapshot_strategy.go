@@ -0,0 +1,121 @@
+package es
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackus/errors"
+)
+
+type (
+	// SnapshotStrategy decides whether an aggregate being saved should be
+	// snapshotted
+	SnapshotStrategy interface {
+		ShouldSnapshot(aggregate EventSourcedAggregate, last LastSnapshot) bool
+	}
+
+	SnapshotStrategyFunc func(aggregate EventSourcedAggregate, last LastSnapshot) bool
+
+	// LastSnapshot is the latest snapshot of an aggregate; it is the zero value
+	// when the aggregate has not been snapshotted
+	LastSnapshot struct {
+		Version int
+		TakenAt time.Time
+	}
+)
+
+func (f SnapshotStrategyFunc) ShouldSnapshot(aggregate EventSourcedAggregate, last LastSnapshot) bool {
+	return f(aggregate, last)
+}
+
+// SnapshotEvery snapshots an aggregate once the number of events since its
+// last snapshot reaches the given number
+func SnapshotEvery(events int) SnapshotStrategy {
+	return SnapshotStrategyFunc(func(aggregate EventSourcedAggregate, last LastSnapshot) bool {
+		return aggregate.PendingVersion()-last.Version >= events
+	})
+}
+
+// SnapshotAfter snapshots an aggregate that changes once the duration has
+// passed since its last snapshot, or when it has never been snapshotted
+func SnapshotAfter(duration time.Duration) SnapshotStrategy {
+	return SnapshotStrategyFunc(func(aggregate EventSourcedAggregate, last LastSnapshot) bool {
+		return aggregate.PendingVersion() > last.Version && time.Since(last.TakenAt) >= duration
+	})
+}
+
+// SnapshotOn snapshots an aggregate when any of the named events are saved
+func SnapshotOn(eventNames ...string) SnapshotStrategy {
+	names := make(map[string]struct{}, len(eventNames))
+	for _, name := range eventNames {
+		names[name] = struct{}{}
+	}
+
+	return SnapshotStrategyFunc(func(aggregate EventSourcedAggregate, _ LastSnapshot) bool {
+		for _, event := range aggregate.Events() {
+			if _, exists := names[event.EventName()]; exists {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NeverSnapshot leaves the aggregate to be loaded from its events alone
+func NeverSnapshot() SnapshotStrategy {
+	return SnapshotStrategyFunc(func(EventSourcedAggregate, LastSnapshot) bool {
+		return false
+	})
+}
+
+// SnapshotWhenAny snapshots an aggregate when any of the strategies would
+func SnapshotWhenAny(strategies ...SnapshotStrategy) SnapshotStrategy {
+	return SnapshotStrategyFunc(func(aggregate EventSourcedAggregate, last LastSnapshot) bool {
+		for _, strategy := range strategies {
+			if strategy.ShouldSnapshot(aggregate, last) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ParseSnapshotStrategy reads a strategy written as "every=50", "after=24h",
+// "on=ordering.OrderCompleted|ordering.OrderCanceled" or "never"; strategies
+// joined with "+" snapshot when any of them would
+func ParseSnapshotStrategy(spec string) (SnapshotStrategy, error) {
+	var strategies []SnapshotStrategy
+	for _, part := range strings.Split(spec, "+") {
+		kind, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch kind {
+		case "every":
+			events, err := strconv.Atoi(value)
+			if err != nil || events < 1 {
+				return nil, errors.ErrInvalidArgument.Msgf("invalid number of events in snapshot strategy %q", part)
+			}
+			strategies = append(strategies, SnapshotEvery(events))
+		case "after":
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration <= 0 {
+				return nil, errors.ErrInvalidArgument.Msgf("invalid duration in snapshot strategy %q", part)
+			}
+			strategies = append(strategies, SnapshotAfter(duration))
+		case "on":
+			if value == "" {
+				return nil, errors.ErrInvalidArgument.Msgf("no event names in snapshot strategy %q", part)
+			}
+			strategies = append(strategies, SnapshotOn(strings.Split(value, "|")...))
+		case "never":
+			strategies = append(strategies, NeverSnapshot())
+		default:
+			return nil, errors.ErrInvalidArgument.Msgf("unknown snapshot strategy %q", part)
+		}
+	}
+
+	if len(strategies) == 1 {
+		return strategies[0], nil
+	}
+
+	return SnapshotWhenAny(strategies...), nil
+}
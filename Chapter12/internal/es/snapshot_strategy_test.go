@@ -0,0 +1,115 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotStrategies(t *testing.T) {
+	aggregate := func(version int, eventNames ...string) EventSourcedAggregate {
+		agg := &testAggregate{Aggregate: NewAggregate("agg-id", testAggregateName)}
+		agg.SetVersion(version)
+		for _, name := range eventNames {
+			agg.AddEvent(name, nil)
+		}
+		return agg
+	}
+
+	tests := map[string]struct {
+		strategy  SnapshotStrategy
+		aggregate EventSourcedAggregate
+		last      LastSnapshot
+		want      bool
+	}{
+		"EveryNotReached": {
+			strategy:  SnapshotEvery(3),
+			aggregate: aggregate(3, "es.Changed"),
+			last:      LastSnapshot{Version: 2},
+			want:      false,
+		},
+		"EveryReached": {
+			strategy:  SnapshotEvery(3),
+			aggregate: aggregate(4, "es.Changed"),
+			last:      LastSnapshot{Version: 2},
+			want:      true,
+		},
+		"EveryNeverSnapshotted": {
+			strategy:  SnapshotEvery(3),
+			aggregate: aggregate(0, "es.Created", "es.Changed", "es.Changed"),
+			want:      true,
+		},
+		"AfterNotPassed": {
+			strategy:  SnapshotAfter(time.Hour),
+			aggregate: aggregate(3, "es.Changed"),
+			last:      LastSnapshot{Version: 3, TakenAt: time.Now().Add(-time.Minute)},
+			want:      false,
+		},
+		"AfterPassed": {
+			strategy:  SnapshotAfter(time.Hour),
+			aggregate: aggregate(3, "es.Changed"),
+			last:      LastSnapshot{Version: 3, TakenAt: time.Now().Add(-2 * time.Hour)},
+			want:      true,
+		},
+		"OnEvent": {
+			strategy:  SnapshotOn("es.Completed"),
+			aggregate: aggregate(1, "es.Changed", "es.Completed"),
+			want:      true,
+		},
+		"OnOtherEvent": {
+			strategy:  SnapshotOn("es.Completed"),
+			aggregate: aggregate(1, "es.Changed"),
+			want:      false,
+		},
+		"Never": {
+			strategy:  NeverSnapshot(),
+			aggregate: aggregate(100, "es.Changed"),
+			want:      false,
+		},
+		"WhenAny": {
+			strategy:  SnapshotWhenAny(NeverSnapshot(), SnapshotOn("es.Completed")),
+			aggregate: aggregate(1, "es.Completed"),
+			want:      true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.strategy.ShouldSnapshot(tt.aggregate, tt.last))
+		})
+	}
+}
+
+func TestParseSnapshotStrategy(t *testing.T) {
+	agg := &testAggregate{Aggregate: NewAggregate("agg-id", testAggregateName)}
+	agg.SetVersion(1)
+	agg.AddEvent("es.Completed", nil)
+
+	tests := map[string]struct {
+		spec    string
+		want    bool
+		wantErr bool
+	}{
+		"Every":        {spec: "every=2", want: true},
+		"EveryLater":   {spec: "every=5", want: false},
+		"After":        {spec: "after=1h", want: true},
+		"On":           {spec: "on=es.Changed|es.Completed", want: true},
+		"Never":        {spec: "never", want: false},
+		"Any":          {spec: "never+on=es.Completed", want: true},
+		"Unknown":      {spec: "sometimes", wantErr: true},
+		"BadEvery":     {spec: "every=none", wantErr: true},
+		"BadDuration":  {spec: "after=1", wantErr: true},
+		"NoEventNames": {spec: "on=", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := ParseSnapshotStrategy(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, strategy.ShouldSnapshot(agg, LastSnapshot{}))
+		})
+	}
+}
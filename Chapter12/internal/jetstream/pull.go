@@ -0,0 +1,181 @@
+package jetstream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/am"
+)
+
+const fetchErrorDelay = time.Second
+
+type pulledMessage struct {
+	natsMsg *nats.Msg
+	m       *StreamMessage
+	msg     *rawMessage
+}
+
+// pullSubscribe creates a pull consumer and fetches batches of messages for the
+// handler until the subscription is drained
+//
+// Pull consumers must acknowledge messages explicitly; with AckTypeAuto the
+// messages are acknowledged as soon as they are fetched. The messages, or the
+// batches, are handled using the MaxConcurrency and PartitionBy options.
+func (s *Stream) pullSubscribe(topicName string, subCfg am.SubscriberConfig, cfg *nats.ConsumerConfig, handler am.MessageHandler) (am.Subscription, error) {
+	var err error
+	var sub *nats.Subscription
+
+	cfg.DeliverSubject = ""
+	cfg.DeliverGroup = ""
+	cfg.AckPolicy = nats.AckExplicitPolicy
+	if cfg.AckWait == 0 {
+		cfg.AckWait = subCfg.AckWait()
+	}
+
+	if groupName := subCfg.GroupName(); groupName != "" {
+		_, err = s.js.AddConsumer(s.streamName, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err = s.js.PullSubscribe(topicName, groupName, nats.Bind(s.streamName, groupName))
+	} else {
+		opts := []nats.SubOpt{
+			nats.BindStream(s.streamName),
+			nats.MaxDeliver(cfg.MaxDeliver),
+			nats.AckExplicit(),
+			nats.AckWait(cfg.AckWait),
+		}
+		if len(cfg.BackOff) > 0 {
+			opts = append(opts, nats.BackOff(cfg.BackOff))
+		}
+		if cfg.MaxAckPending > 0 {
+			opts = append(opts, nats.MaxAckPending(cfg.MaxAckPending))
+		}
+
+		sub, err = s.js.PullSubscribe(topicName, "", opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dispatcher := am.NewDispatcher(subCfg)
+
+	go s.fetchMsgs(sub, subCfg, dispatcher, handler)
+
+	s.subs = append(s.subs, subscription{s: sub, dispatcher: dispatcher})
+
+	return subscription{s: sub, dispatcher: dispatcher}, nil
+}
+
+func (s *Stream) fetchMsgs(sub *nats.Subscription, cfg am.SubscriberConfig, dispatcher *am.Dispatcher, handler am.MessageHandler) {
+	filters := messageFilters(cfg.MessageFilters())
+
+	for sub.IsValid() {
+		natsMsgs, err := sub.Fetch(cfg.BatchSize(), nats.MaxWait(cfg.BatchMaxWait()))
+		if err != nil {
+			switch {
+			case errors.Is(err, nats.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+				// nothing to fetch; try again
+			case errors.Is(err, nats.ErrBadSubscription), errors.Is(err, nats.ErrConnectionClosed), errors.Is(err, nats.ErrConnectionDraining):
+				return
+			default:
+				s.logger.Warn().Err(err).Msg("failed to fetch messages")
+				time.Sleep(fetchErrorDelay)
+			}
+			continue
+		}
+
+		pulled := make([]pulledMessage, 0, len(natsMsgs))
+		for _, natsMsg := range natsMsgs {
+			m, ok := s.unmarshal(natsMsg, filters)
+			if !ok {
+				continue
+			}
+
+			msg := s.newRawMessage(cfg, natsMsg, m)
+			if cfg.AckType() == am.AckTypeAuto {
+				if err = msg.Ack(); err != nil {
+					s.logger.Warn().Err(err).Msg("failed to auto-Ack a message")
+				}
+			}
+
+			pulled = append(pulled, pulledMessage{natsMsg: natsMsg, m: m, msg: msg})
+		}
+
+		if len(pulled) == 0 {
+			continue
+		}
+
+		batch, ok := handler.(am.BatchMessageHandler)
+		if !ok {
+			for _, p := range pulled {
+				dispatcher.Dispatch(p.msg, func() {
+					s.processMsg(cfg, handler, p.natsMsg, p.m, p.msg)
+				})
+			}
+			continue
+		}
+
+		msgs := make([]am.MessageBase, len(pulled))
+		for i, p := range pulled {
+			msgs[i] = p.msg
+		}
+		dispatcher.DispatchBatch(msgs, func(indexes []int) {
+			part := make([]pulledMessage, len(indexes))
+			for i, index := range indexes {
+				part[i] = pulled[index]
+			}
+			s.processBatch(cfg, batch, handler, part)
+		})
+	}
+}
+
+// processBatch handles the messages as one batch; when the batch fails, and
+// holds more than one message, each message is handled on its own so only the
+// failing messages are redelivered
+func (s *Stream) processBatch(cfg am.SubscriberConfig, batch am.BatchMessageHandler, handler am.MessageHandler, pulled []pulledMessage) {
+	wCtx, cancel := context.WithTimeout(context.Background(), cfg.AckWait())
+	defer cancel()
+
+	msgs := make([]am.IncomingMessage, len(pulled))
+	rawMsgs := make([]*rawMessage, len(pulled))
+	for i, p := range pulled {
+		msgs[i] = p.msg
+		rawMsgs[i] = p.msg
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- batch.HandleMessages(wCtx, msgs)
+	}()
+
+	err := s.awaitHandler(cfg, errc, rawMsgs...)
+	if err == nil {
+		for _, p := range pulled {
+			if ackErr := p.msg.Ack(); ackErr != nil {
+				s.logger.Warn().Err(ackErr).Msg("failed to Ack a message")
+			}
+		}
+		return
+	}
+
+	if len(pulled) == 1 {
+		s.logger.Error().Err(err).Msg("error while handling message")
+		s.failMsg(cfg, pulled[0].natsMsg, pulled[0].m, pulled[0].msg, err)
+		return
+	}
+
+	s.logger.Error().Err(err).Msgf("error while handling a batch of %d messages; handling them one at a time", len(msgs))
+	for _, p := range pulled {
+		if cfg.AckType() != am.AckTypeAuto {
+			if extendErr := p.msg.Extend(); extendErr != nil {
+				s.logger.Warn().Err(extendErr).Msg("failed to extend the ack deadline of a message")
+			}
+		}
+		s.processMsg(cfg, handler, p.natsMsg, p.m, p.msg)
+	}
+}
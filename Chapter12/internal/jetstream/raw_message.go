@@ -1,6 +1,7 @@
 package jetstream
 
 import (
+	"sync"
 	"time"
 
 	"eda-in-golang/internal/am"
@@ -16,6 +17,7 @@ type rawMessage struct {
 	sentAt     time.Time
 	receivedAt time.Time
 	acked      bool
+	mu         sync.Mutex
 	ackFn      func() error
 	nackFn     func() error
 	extendFn   func() error
@@ -33,18 +35,16 @@ func (m *rawMessage) SentAt() time.Time      { return m.sentAt }
 func (m *rawMessage) ReceivedAt() time.Time  { return m.receivedAt }
 
 func (m *rawMessage) Ack() error {
-	if m.acked {
+	if !m.settle() {
 		return nil
 	}
-	m.acked = true
 	return m.ackFn()
 }
 
 func (m *rawMessage) NAck() error {
-	if m.acked {
+	if !m.settle() {
 		return nil
 	}
-	m.acked = true
 	return m.nackFn()
 }
 
@@ -53,10 +53,20 @@ func (m *rawMessage) Extend() error {
 }
 
 func (m *rawMessage) Kill() error {
-	if m.acked {
+	if !m.settle() {
 		return nil
 	}
+	return m.killFn()
+}
+
+// settle marks the message as acknowledged; reporting false if it already was
+func (m *rawMessage) settle() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	if m.acked {
+		return false
+	}
 	m.acked = true
-	return m.killFn()
+	return true
 }
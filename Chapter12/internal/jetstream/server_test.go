@@ -0,0 +1,55 @@
+package jetstream
+
+import (
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+)
+
+const testStreamName = "test"
+
+// runStream starts an embedded JetStream server with a stream for the "test.>"
+// subjects and returns a Stream that uses it
+func runStream(t *testing.T) (*Stream, nats.JetStreamContext) {
+	t.Helper()
+
+	ns, err := natsserver.NewServer(&natsserver.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("the nats server did not start")
+	}
+
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     testStreamName,
+		Subjects: []string{"test.>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStream(testStreamName, js, zerolog.Nop()), js
+}
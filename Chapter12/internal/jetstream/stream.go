@@ -7,6 +7,7 @@ import (
 
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
+	"github.com/stackus/errors"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -14,23 +15,44 @@ import (
 	"eda-in-golang/internal/am"
 )
 
-const maxRetries = 5
+var defaultPublishRetryPolicy = am.WithMaxElapsedTime(
+	am.ExponentialBackoffWithJitter(100*time.Millisecond, 5*time.Second),
+	30*time.Second,
+)
 
 type Stream struct {
-	streamName string
-	js         nats.JetStreamContext
-	mu         sync.Mutex
-	subs       []*nats.Subscription
-	logger     zerolog.Logger
+	streamName  string
+	js          nats.JetStreamContext
+	mu          sync.Mutex
+	subs        []subscription
+	retryPolicy am.RetryPolicy
+	logger      zerolog.Logger
 }
 
+type StreamOption func(s *Stream)
+
 var _ am.MessageStream = (*Stream)(nil)
 
-func NewStream(streamName string, js nats.JetStreamContext, logger zerolog.Logger) *Stream {
-	return &Stream{
-		streamName: streamName,
-		js:         js,
-		logger:     logger,
+func NewStream(streamName string, js nats.JetStreamContext, logger zerolog.Logger, options ...StreamOption) *Stream {
+	s := &Stream{
+		streamName:  streamName,
+		js:          js,
+		retryPolicy: defaultPublishRetryPolicy,
+		logger:      logger,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// PublishRetryPolicy sets the delays used between attempts to publish a message;
+// a policy without a max elapsed time retries until the publish is acknowledged
+func PublishRetryPolicy(policy am.RetryPolicy) StreamOption {
+	return func(s *Stream) {
+		s.retryPolicy = policy
 	}
 }
 
@@ -53,39 +75,47 @@ func (s *Stream) Publish(ctx context.Context, topicName string, rawMsg am.Messag
 		return
 	}
 
+	// replayed messages keep their IDs; they are deduplicated on the replay instead
+	msgID := rawMsg.ID()
+	if replayedAt, ok := rawMsg.Metadata().Get(am.ReplayedAtHdr).(string); ok {
+		msgID += "@" + replayedAt
+	}
+
 	var p nats.PubAckFuture
 	p, err = s.js.PublishMsgAsync(&nats.Msg{
 		Subject: rawMsg.Subject(),
 		Data:    data,
-	}, nats.MsgId(rawMsg.ID()))
+	}, nats.MsgId(msgID))
 	if err != nil {
 		return
 	}
 
-	// retry a handful of times to publish the messages
-	go func(future nats.PubAckFuture, tries int) {
+	// retry publishing the message for as long as the policy allows; a publish
+	// that could not be sent at all, e.g. while reconnecting, is retried as well
+	go func(future nats.PubAckFuture, startedAt time.Time) {
 		var err error
+		natsMsg := future.Msg()
 
-		for {
-			select {
-			case <-future.Ok(): // publish acknowledged
-				return
-			case <-future.Err(): // error ignored; try again
-				// TODO add some variable delay between tries
-				tries = tries - 1
-				if tries <= 0 {
-					s.logger.Error().Msgf("unable to publish message after %d tries", maxRetries)
-					return
-				}
-				future, err = s.js.PublishMsgAsync(future.Msg())
-				if err != nil {
-					// TODO do more than give up
-					s.logger.Error().Err(err).Msg("failed to publish a message")
+		for attempt := 1; ; attempt++ {
+			if future != nil {
+				select {
+				case <-future.Ok(): // publish acknowledged
 					return
+				case <-future.Err(): // error ignored; try again
 				}
 			}
+			if am.RetryExhausted(s.retryPolicy, startedAt) {
+				s.logger.Error().Msgf("unable to publish message %s after %d tries", msgID, attempt)
+				return
+			}
+			time.Sleep(s.retryPolicy.Backoff(attempt))
+			future, err = s.js.PublishMsgAsync(natsMsg)
+			if err != nil {
+				s.logger.Warn().Err(err).Msgf("failed to publish message %s; trying again", msgID)
+				future = nil
+			}
 		}
-	}(p, maxRetries)
+	}(p, time.Now())
 
 	return
 }
@@ -115,17 +145,33 @@ func (s *Stream) Subscribe(topicName string, handler am.MessageHandler, options
 	}
 
 	if ackType := subCfg.AckType(); ackType != am.AckTypeAuto {
-		ackWait := subCfg.AckWait()
-
 		cfg.AckPolicy = nats.AckExplicitPolicy
-		cfg.AckWait = ackWait
+		cfg.AckWait = subCfg.AckWait()
+
+		if policy := subCfg.RetryPolicy(); policy != nil {
+			if backoff := consumerBackoff(policy, subCfg); len(backoff) > 0 {
+				// the server uses the first backoff as the ack wait of the consumer
+				cfg.BackOff = backoff
+				cfg.AckWait = backoff[0]
+				opts = append(opts, nats.BackOff(backoff))
+			}
+		}
 
-		opts = append(opts, nats.AckExplicit(), nats.AckWait(ackWait))
+		opts = append(opts, nats.AckExplicit(), nats.AckWait(cfg.AckWait))
 	} else {
 		cfg.AckPolicy = nats.AckNonePolicy
 		opts = append(opts, nats.AckNone())
 	}
 
+	if maxAckPending := subCfg.MaxAckPending(); maxAckPending > 0 {
+		cfg.MaxAckPending = maxAckPending
+		opts = append(opts, nats.MaxAckPending(maxAckPending))
+	}
+
+	if subCfg.Pull() {
+		return s.pullSubscribe(topicName, subCfg, cfg, handler)
+	}
+
 	_, err = s.js.AddConsumer(s.streamName, cfg)
 	if err != nil {
 		return nil, err
@@ -133,23 +179,29 @@ func (s *Stream) Subscribe(topicName string, handler am.MessageHandler, options
 
 	var sub *nats.Subscription
 
+	dispatcher := am.NewDispatcher(subCfg)
+
 	if groupName := subCfg.GroupName(); groupName == "" {
-		sub, err = s.js.Subscribe(topicName, s.handleMsg(subCfg, handler), opts...)
+		sub, err = s.js.Subscribe(topicName, s.handleMsg(subCfg, dispatcher, handler), opts...)
 	} else {
-		sub, err = s.js.QueueSubscribe(topicName, groupName, s.handleMsg(subCfg, handler), opts...)
+		sub, err = s.js.QueueSubscribe(topicName, groupName, s.handleMsg(subCfg, dispatcher, handler), opts...)
+	}
+	if err != nil {
+		dispatcher.Close()
+		return nil, err
 	}
 
-	s.subs = append(s.subs, sub)
+	s.subs = append(s.subs, subscription{s: sub, dispatcher: dispatcher})
 
-	return subscription{sub}, nil
+	return subscription{s: sub, dispatcher: dispatcher}, nil
 }
 
 func (s *Stream) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for _, sub := range s.subs {
-		if !sub.IsValid() {
-			continue
-		}
-		err := sub.Drain()
+		err := sub.Unsubscribe()
 		if err != nil {
 			return err
 		}
@@ -157,80 +209,269 @@ func (s *Stream) Unsubscribe() error {
 	return nil
 }
 
-func (s *Stream) handleMsg(cfg am.SubscriberConfig, handler am.MessageHandler) func(*nats.Msg) {
-	var filters map[string]struct{}
-	if len(cfg.MessageFilters()) > 0 {
-		filters = make(map[string]struct{})
-		for _, key := range cfg.MessageFilters() {
-			filters[key] = struct{}{}
-		}
-	}
+func (s *Stream) handleMsg(cfg am.SubscriberConfig, dispatcher *am.Dispatcher, handler am.MessageHandler) func(*nats.Msg) {
+	filters := messageFilters(cfg.MessageFilters())
 
 	return func(natsMsg *nats.Msg) {
-		var err error
-
-		m := &StreamMessage{}
-		err = proto.Unmarshal(natsMsg.Data, m)
-		if err != nil {
-			s.logger.Warn().Err(err).Msg("failed to unmarshal the *nats.Msg")
+		m, ok := s.unmarshal(natsMsg, filters)
+		if !ok {
 			return
 		}
 
-		if filters != nil {
-			if _, exists := filters[m.GetName()]; !exists {
-				err = natsMsg.Ack()
-				if err != nil {
-					s.logger.Warn().Err(err).Msg("failed to Ack a filtered message")
-				}
-				return
+		msg := s.newRawMessage(cfg, natsMsg, m)
+
+		dispatcher.Dispatch(msg, func() {
+			s.processMsg(cfg, handler, natsMsg, m, msg)
+		})
+	}
+}
+
+// unmarshal decodes the *nats.Msg; messages that are not wanted by the
+// subscription are acknowledged and skipped
+func (s *Stream) unmarshal(natsMsg *nats.Msg, filters map[string]struct{}) (*StreamMessage, bool) {
+	m := &StreamMessage{}
+	err := proto.Unmarshal(natsMsg.Data, m)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to unmarshal the *nats.Msg")
+		return nil, false
+	}
+
+	if filters != nil {
+		if _, exists := filters[m.GetName()]; !exists {
+			err = natsMsg.Ack()
+			if err != nil {
+				s.logger.Warn().Err(err).Msg("failed to Ack a filtered message")
 			}
+			return nil, false
 		}
+	}
+
+	return m, true
+}
+
+func (s *Stream) newRawMessage(cfg am.SubscriberConfig, natsMsg *nats.Msg, m *StreamMessage) *rawMessage {
+	return &rawMessage{
+		id:         m.GetId(),
+		name:       m.GetName(),
+		subject:    natsMsg.Subject,
+		data:       m.GetData(),
+		metadata:   m.GetMetadata().AsMap(),
+		sentAt:     m.SentAt.AsTime(),
+		receivedAt: time.Now(),
+		acked:      false,
+		ackFn:      func() error { return natsMsg.Ack() },
+		nackFn:     func() error { return s.nak(cfg, natsMsg) },
+		extendFn:   func() error { return natsMsg.InProgress() },
+		killFn:     func() error { return natsMsg.Term() },
+	}
+}
+
+func (s *Stream) processMsg(cfg am.SubscriberConfig, handler am.MessageHandler, natsMsg *nats.Msg, m *StreamMessage, msg *rawMessage) {
+	var err error
+
+	wCtx, cancel := context.WithTimeout(context.Background(), cfg.AckWait())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- handler.HandleMessage(wCtx, msg)
+	}()
 
-		msg := &rawMessage{
-			id:         m.GetId(),
-			name:       m.GetName(),
-			subject:    natsMsg.Subject,
-			data:       m.GetData(),
-			metadata:   m.GetMetadata().AsMap(),
-			sentAt:     m.SentAt.AsTime(),
-			receivedAt: time.Now(),
-			acked:      false,
-			ackFn:      func() error { return natsMsg.Ack() },
-			nackFn:     func() error { return natsMsg.Nak() },
-			extendFn:   func() error { return natsMsg.InProgress() },
-			killFn:     func() error { return natsMsg.Term() },
+	if cfg.AckType() == am.AckTypeAuto {
+		err = msg.Ack()
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("failed to auto-Ack a message")
 		}
+	}
 
-		wCtx, cancel := context.WithTimeout(context.Background(), cfg.AckWait())
-		defer cancel()
+	err = s.awaitHandler(cfg, errc, msg)
+	if err == nil {
+		if ackErr := msg.Ack(); ackErr != nil {
+			s.logger.Warn().Err(ackErr).Msg("failed to Ack a message")
+		}
+		return
+	}
+	if wCtx.Err() != nil {
+		err = errors.Wrap(err, "handling the message took longer than the ack wait")
+	}
+	s.logger.Error().Err(err).Msg("error while handling message")
+	s.failMsg(cfg, natsMsg, m, msg, err)
+}
 
-		errc := make(chan error)
-		go func() {
-			errc <- handler.HandleMessage(wCtx, msg)
-		}()
+// awaitHandler waits for the handler to return. The handler is told to stop
+// once the ack wait is up, but its messages are kept in progress until it
+// returns so that they are not redelivered, nor the next messages of their
+// partitions handled, while they are still being handled
+func (s *Stream) awaitHandler(cfg am.SubscriberConfig, errc <-chan error, msgs ...*rawMessage) error {
+	if cfg.AckType() == am.AckTypeAuto {
+		return <-errc
+	}
 
-		if cfg.AckType() == am.AckTypeAuto {
-			err = msg.Ack()
-			if err != nil {
-				s.logger.Warn().Err(err).Msg("failed to auto-Ack a message")
+	ticker := time.NewTicker(cfg.AckWait() / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-ticker.C:
+			for _, msg := range msgs {
+				if err := msg.Extend(); err != nil {
+					s.logger.Warn().Err(err).Msg("failed to extend the ack deadline of a message")
+				}
 			}
 		}
+	}
+}
 
-		select {
-		case err = <-errc:
-			if err == nil {
-				if ackErr := msg.Ack(); ackErr != nil {
-					s.logger.Warn().Err(err).Msg("failed to Ack a message")
+// failMsg NAcks the message, or when it will not be redelivered, sends it to the
+// dead-letter topic and terminates it
+func (s *Stream) failMsg(cfg am.SubscriberConfig, natsMsg *nats.Msg, m *StreamMessage, msg *rawMessage, err error) {
+	if s.isFinalDelivery(cfg, natsMsg, msg) {
+		if topic := cfg.DeadLetterTopic(); topic != "" {
+			if dlErr := s.deadLetter(topic, cfg, natsMsg, m, err); dlErr != nil {
+				s.logger.Error().Err(dlErr).Msgf("failed to publish message to dead-letter topic %s", topic)
+				if nakErr := msg.NAck(); nakErr != nil {
+					s.logger.Warn().Err(err).Msg("failed to Nack a message")
 				}
 				return
 			}
-			s.logger.Error().Err(err).Msg("error while handling message")
-			if nakErr := msg.NAck(); nakErr != nil {
-				s.logger.Warn().Err(err).Msg("failed to Nack a message")
-			}
-		case <-wCtx.Done():
-			// TODO logging?
-			return
 		}
+		if killErr := msg.Kill(); killErr != nil {
+			s.logger.Warn().Err(killErr).Msg("failed to Term a message")
+		}
+		return
+	}
+	if nakErr := msg.NAck(); nakErr != nil {
+		s.logger.Warn().Err(err).Msg("failed to Nack a message")
+	}
+}
+
+// isFinalDelivery reports whether the message should not be redelivered should
+// this delivery fail
+//
+// JetStream does not record when a message was first delivered, only when it was
+// stored, so the first delivery is taken to have been made at least the retry
+// delays of the earlier deliveries before this one was received. The max elapsed
+// time of the RetryPolicy ends the redeliveries once that much time has passed;
+// it may allow a few more deliveries than it would with the actual time.
+func (s *Stream) isFinalDelivery(cfg am.SubscriberConfig, natsMsg *nats.Msg, msg *rawMessage) bool {
+	if cfg.AckType() == am.AckTypeAuto {
+		return true
+	}
+
+	md, err := natsMsg.Metadata()
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to read the *nats.Msg metadata")
+		return false
+	}
+
+	if md.NumDelivered >= uint64(cfg.MaxRedeliver()) {
+		return true
+	}
+
+	policy := cfg.RetryPolicy()
+	if policy == nil || policy.MaxElapsedTime() == 0 {
+		return false
+	}
+
+	startedAt := msg.ReceivedAt()
+	for attempt := 1; attempt < int(md.NumDelivered); attempt++ {
+		startedAt = startedAt.Add(-policy.Backoff(attempt))
+	}
+
+	return am.RetryExhausted(policy, startedAt)
+}
+
+// consumerBackoff builds the redelivery schedule used by JetStream when a message
+// is not acknowledged in time
+//
+// JetStream waits the nth entry for the ack of the nth delivery before
+// redelivering, so each entry is the ack wait, giving the handler its full time,
+// plus the delay of the policy before the retry that follows.
+func consumerBackoff(policy am.RetryPolicy, cfg am.SubscriberConfig) []time.Duration {
+	if cfg.MaxRedeliver() <= 1 {
+		return nil
+	}
+
+	backoff := make([]time.Duration, cfg.MaxRedeliver()-1)
+	for i := range backoff {
+		backoff[i] = cfg.AckWait() + policy.Backoff(i+1)
+	}
+
+	return backoff
+}
+
+// nak asks for the message to be redelivered, after a delay when the subscription
+// has a RetryPolicy
+func (s *Stream) nak(cfg am.SubscriberConfig, natsMsg *nats.Msg) error {
+	policy := cfg.RetryPolicy()
+	if policy == nil {
+		return natsMsg.Nak()
+	}
+
+	attempt := 1
+	if md, err := natsMsg.Metadata(); err == nil {
+		attempt = int(md.NumDelivered)
+	}
+
+	return natsMsg.NakWithDelay(policy.Backoff(attempt))
+}
+
+// deadLetter republishes the original message onto the dead-letter topic with
+// details about the failure added to its metadata
+func (s *Stream) deadLetter(topicName string, cfg am.SubscriberConfig, natsMsg *nats.Msg, m *StreamMessage, handlerErr error) error {
+	var deliveries uint64 = 1
+	if md, err := natsMsg.Metadata(); err == nil {
+		deliveries = md.NumDelivered
+	}
+
+	handlerName := cfg.GroupName()
+	if handlerName == "" {
+		handlerName = natsMsg.Subject
+	}
+
+	values := m.GetMetadata().AsMap()
+	values[am.DeadLetterSubjectHdr] = natsMsg.Subject
+	values[am.DeadLetterErrorHdr] = handlerErr.Error()
+	values[am.DeadLetterDeliveriesHdr] = deliveries
+	values[am.DeadLetterHandlerHdr] = handlerName
+	values[am.DeadLetterFailedAtHdr] = time.Now().Format(time.RFC3339Nano)
+
+	metadata, err := structpb.NewStruct(values)
+	if err != nil {
+		return err
 	}
+
+	data, err := proto.Marshal(&StreamMessage{
+		Id:       m.GetId(),
+		Name:     m.GetName(),
+		Data:     m.GetData(),
+		Metadata: metadata,
+		SentAt:   m.GetSentAt(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// not using nats.MsgId(); the original message ID is still within the deduplication window
+	_, err = s.js.PublishMsg(&nats.Msg{
+		Subject: topicName,
+		Data:    data,
+	})
+
+	return err
+}
+
+func messageFilters(names []string) map[string]struct{} {
+	if len(names) == 0 {
+		return nil
+	}
+
+	filters := make(map[string]struct{})
+	for _, key := range names {
+		filters[key] = struct{}{}
+	}
+
+	return filters
 }
@@ -0,0 +1,103 @@
+package jetstream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+
+	"eda-in-golang/internal/am"
+)
+
+var _ am.MessageReader = (*Stream)(nil)
+
+// ReadMessages reads the stored messages for the subject, oldest first, using a
+// temporary ordered consumer
+//
+// Reading stops once the end of the stream, the end time or sequence, or the
+// limit has been reached.
+func (s *Stream) ReadMessages(ctx context.Context, subject string, fn func(am.Message) error, options ...am.ReaderOption) error {
+	cfg := am.NewReaderConfig(options)
+
+	opts := []nats.SubOpt{
+		nats.BindStream(s.streamName),
+		nats.OrderedConsumer(),
+	}
+	switch {
+	case cfg.StartSequence() > 0:
+		opts = append(opts, nats.StartSequence(cfg.StartSequence()))
+	case !cfg.StartTime().IsZero():
+		opts = append(opts, nats.StartTime(cfg.StartTime()))
+	default:
+		opts = append(opts, nats.DeliverAll())
+	}
+
+	sub, err := s.js.SubscribeSync(subject, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sub.Unsubscribe(); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to unsubscribe the reader")
+		}
+	}()
+
+	info, err := sub.ConsumerInfo()
+	if err != nil {
+		return err
+	}
+	// messages may have been pushed to the subscription already
+	if info.NumPending == 0 && info.Delivered.Consumer == 0 {
+		return nil
+	}
+
+	filters := messageFilters(cfg.MessageFilters())
+
+	count := 0
+	for {
+		natsMsg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		md, err := natsMsg.Metadata()
+		if err != nil {
+			return err
+		}
+
+		if cfg.EndSequence() > 0 && md.Sequence.Stream > cfg.EndSequence() {
+			return nil
+		}
+		if !cfg.EndTime().IsZero() && md.Timestamp.After(cfg.EndTime()) {
+			return nil
+		}
+
+		m := &StreamMessage{}
+		if err = proto.Unmarshal(natsMsg.Data, m); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to unmarshal the *nats.Msg")
+		} else if _, exists := filters[m.GetName()]; filters == nil || exists {
+			err = fn(&rawMessage{
+				id:         m.GetId(),
+				name:       m.GetName(),
+				subject:    natsMsg.Subject,
+				data:       m.GetData(),
+				metadata:   m.GetMetadata().AsMap(),
+				sentAt:     m.GetSentAt().AsTime(),
+				receivedAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			count++
+			if cfg.Limit() > 0 && count >= cfg.Limit() {
+				return nil
+			}
+		}
+
+		if md.NumPending == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package jetstream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type testMessage struct {
+	id       string
+	name     string
+	subject  string
+	metadata ddd.Metadata
+}
+
+func (m testMessage) ID() string          { return m.id }
+func (m testMessage) Subject() string     { return m.subject }
+func (m testMessage) MessageName() string { return m.name }
+func (m testMessage) Data() []byte        { return []byte(m.id) }
+func (m testMessage) SentAt() time.Time   { return time.Now() }
+
+func (m testMessage) Metadata() ddd.Metadata {
+	metadata := ddd.Metadata{"id": m.id}
+	for key, value := range m.metadata {
+		metadata.Set(key, value)
+	}
+	return metadata
+}
+
+func publish(t *testing.T, s *Stream, js nats.JetStreamContext, msgs ...testMessage) {
+	t.Helper()
+
+	for _, msg := range msgs {
+		if err := s.Publish(context.Background(), msg.subject, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-js.PublishAsyncComplete():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out publishing messages")
+	}
+}
+
+func TestStream_ReadMessages(t *testing.T) {
+	s, js := runStream(t)
+
+	var msgs []testMessage
+	for i := 0; i < 6; i++ {
+		name := "test.Wanted"
+		if i%2 == 1 {
+			name = "test.Unwanted"
+		}
+		msgs = append(msgs, testMessage{id: fmt.Sprintf("msg-%d", i), name: name, subject: "test.topic"})
+	}
+	msgs = append(msgs, testMessage{id: "other", name: "test.Wanted", subject: "test.other"})
+	publish(t, s, js, msgs...)
+
+	tests := map[string]struct {
+		subject string
+		options []am.ReaderOption
+		want    []string
+	}{
+		"All":      {subject: "test.topic", want: []string{"msg-0", "msg-1", "msg-2", "msg-3", "msg-4", "msg-5"}},
+		"Filtered": {subject: "test.topic", options: []am.ReaderOption{am.MessageFilter{"test.Wanted"}}, want: []string{"msg-0", "msg-2", "msg-4"}},
+		"Limited":  {subject: "test.topic", options: []am.ReaderOption{am.MessageFilter{"test.Wanted"}, am.ReadLimit(2)}, want: []string{"msg-0", "msg-2"}},
+		"Sequence": {subject: "test.topic", options: []am.ReaderOption{am.ReadFromSequence(2), am.ReadUntilSequence(4)}, want: []string{"msg-1", "msg-2", "msg-3"}},
+		"Wildcard": {subject: "test.>", options: []am.ReaderOption{am.ReadFromSequence(6)}, want: []string{"msg-5", "other"}},
+		"Empty":    {subject: "test.none"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			err := s.ReadMessages(context.Background(), tt.subject, func(msg am.Message) error {
+				got = append(got, msg.ID())
+				assert.Equal(t, msg.ID(), msg.Metadata().Get("id"))
+				return nil
+			}, tt.options...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStream_ReplayDeadLetters(t *testing.T) {
+	s, js := runStream(t)
+
+	publish(t, s, js, testMessage{id: "msg-1", name: "test.Message", subject: "test.deadletters", metadata: ddd.Metadata{
+		am.DeadLetterSubjectHdr: "test.topic",
+	}})
+
+	replayer := am.NewMessageReplayer(s, s)
+	count, err := replayer.Replay(context.Background(), "test.deadletters", "test.topic")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	select {
+	case <-js.PublishAsyncComplete():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out replaying messages")
+	}
+
+	var replayed []am.Message
+	err = s.ReadMessages(context.Background(), "test.topic", func(msg am.Message) error {
+		replayed = append(replayed, msg)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, replayed, 1) {
+		assert.Equal(t, "msg-1", replayed[0].ID())
+		assert.Equal(t, "test.deadletters", replayed[0].Metadata().Get(am.ReplaySubjectHdr))
+	}
+}
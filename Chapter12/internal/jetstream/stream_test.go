@@ -0,0 +1,207 @@
+package jetstream
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/tm"
+)
+
+func Test_consumerBackoff(t *testing.T) {
+	cfg := am.NewSubscriberConfig([]am.SubscriberOption{
+		am.AckWait(30 * time.Second),
+		am.MaxRedeliver(4),
+	})
+
+	backoff := consumerBackoff(am.ExponentialBackoff(time.Second, 3*time.Second), cfg)
+
+	assert.Equal(t, []time.Duration{31 * time.Second, 32 * time.Second, 33 * time.Second}, backoff)
+}
+
+func Test_consumerBackoff_SingleDelivery(t *testing.T) {
+	cfg := am.NewSubscriberConfig([]am.SubscriberOption{am.MaxRedeliver(1)})
+
+	assert.Nil(t, consumerBackoff(am.ConstantBackoff(time.Second), cfg))
+}
+
+func TestStream_DeadLettersFailingMessages(t *testing.T) {
+	s, js := runStream(t)
+
+	var attempts atomic.Int32
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		attempts.Add(1)
+		return fmt.Errorf("handler failed")
+	})
+
+	deadLetters := make(chan am.IncomingMessage, 1)
+	_, err := s.Subscribe("test.topic", handler,
+		am.GroupName("test-group"),
+		am.MaxRedeliver(3),
+		am.RedeliveryPolicy(am.ConstantBackoff(10*time.Millisecond)),
+		am.DeadLetterTopic("test.deadletters"),
+	)
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.deadletters", collect(deadLetters), am.GroupName("test-deadletters"))
+	assert.NoError(t, err)
+
+	publish(t, s, js, testMessage{id: "msg-1", name: "test.Message", subject: "test.topic"})
+
+	msg := receive(t, deadLetters)
+	assert.Equal(t, "msg-1", msg.ID())
+	assert.Equal(t, "handler failed", msg.Metadata().Get(am.DeadLetterErrorHdr))
+	assert.Equal(t, float64(3), msg.Metadata().Get(am.DeadLetterDeliveriesHdr))
+	assert.Equal(t, "test.topic", msg.Metadata().Get(am.DeadLetterSubjectHdr))
+	assert.Equal(t, "test-group", msg.Metadata().Get(am.DeadLetterHandlerHdr))
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestStream_DeadLettersAfterMaxElapsedTime(t *testing.T) {
+	s, js := runStream(t)
+
+	var attempts atomic.Int32
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		attempts.Add(1)
+		return fmt.Errorf("handler failed")
+	})
+
+	deadLetters := make(chan am.IncomingMessage, 1)
+	_, err := s.Subscribe("test.topic", handler,
+		am.GroupName("test-group"),
+		am.MaxRedeliver(20),
+		am.RedeliveryPolicy(am.WithMaxElapsedTime(am.ConstantBackoff(50*time.Millisecond), 120*time.Millisecond)),
+		am.DeadLetterTopic("test.deadletters"),
+	)
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.deadletters", collect(deadLetters), am.GroupName("test-deadletters"))
+	assert.NoError(t, err)
+
+	publish(t, s, js, testMessage{id: "msg-1", name: "test.Message", subject: "test.topic"})
+
+	msg := receive(t, deadLetters)
+	assert.Equal(t, "msg-1", msg.ID())
+	assert.Equal(t, float64(attempts.Load()), msg.Metadata().Get(am.DeadLetterDeliveriesHdr))
+	assert.Equal(t, int32(4), attempts.Load())
+}
+
+func TestStream_DeadLettersTimedOutMessages(t *testing.T) {
+	s, js := runStream(t)
+
+	var attempts atomic.Int32
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		attempts.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	deadLetters := make(chan am.IncomingMessage, 1)
+	_, err := s.Subscribe("test.topic", handler,
+		am.GroupName("test-group"),
+		am.AckWait(100*time.Millisecond),
+		am.MaxRedeliver(2),
+		am.DeadLetterTopic("test.deadletters"),
+	)
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.deadletters", collect(deadLetters), am.GroupName("test-deadletters"))
+	assert.NoError(t, err)
+
+	publish(t, s, js, testMessage{id: "msg-1", name: "test.Message", subject: "test.topic"})
+
+	msg := receive(t, deadLetters)
+	assert.Equal(t, "msg-1", msg.ID())
+	assert.Contains(t, msg.Metadata().Get(am.DeadLetterErrorHdr), context.DeadlineExceeded.Error())
+	assert.Equal(t, float64(2), msg.Metadata().Get(am.DeadLetterDeliveriesHdr))
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestStream_WaitsForSlowHandlers(t *testing.T) {
+	s, js := runStream(t)
+
+	var attempts, running, overlapped atomic.Int32
+	handled := make(chan am.IncomingMessage, 1)
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		attempts.Add(1)
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		defer running.Add(-1)
+		// ignores the context and succeeds well after the ack wait
+		time.Sleep(300 * time.Millisecond)
+		handled <- msg
+		return nil
+	})
+
+	deadLetters := make(chan am.IncomingMessage, 1)
+	_, err := s.Subscribe("test.topic", handler,
+		am.GroupName("test-group"),
+		am.AckWait(100*time.Millisecond),
+		am.MaxRedeliver(2),
+		am.DeadLetterTopic("test.deadletters"),
+	)
+	assert.NoError(t, err)
+	_, err = s.Subscribe("test.deadletters", collect(deadLetters), am.GroupName("test-deadletters"))
+	assert.NoError(t, err)
+
+	publish(t, s, js, testMessage{id: "msg-1", name: "test.Message", subject: "test.topic"})
+
+	assert.Equal(t, "msg-1", receive(t, handled).ID())
+	select {
+	case msg := <-deadLetters:
+		t.Fatalf("%s was dead-lettered", msg.ID())
+	case <-time.After(500 * time.Millisecond):
+	}
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Equal(t, int32(0), overlapped.Load())
+}
+
+type failedInbox struct {
+	msg am.Message
+}
+
+func (s failedInbox) FindFailed(context.Context, int) ([]tm.InboxEntry, error) { return nil, nil }
+
+func (s failedInbox) Find(_ context.Context, msgID, handler string) (tm.InboxEntry, error) {
+	return tm.InboxEntry{Message: s.msg, Handler: handler, Status: tm.InboxFailed}, nil
+}
+
+func TestStream_PublishesRetriedInboxMessages(t *testing.T) {
+	s, js := runStream(t)
+
+	received := make(chan am.IncomingMessage, 2)
+	_, err := s.Subscribe("test.topic", collect(received), am.GroupName("test-group"))
+	assert.NoError(t, err)
+
+	msg := testMessage{id: "msg-1", name: "test.Message", subject: "test.topic"}
+	publish(t, s, js, msg)
+	assert.Equal(t, "msg-1", receive(t, received).ID())
+
+	// retried within the duplicate window of the original
+	assert.NoError(t, tm.NewInboxAdmin(failedInbox{msg: msg}, s).Retry(context.Background(), "msg-1", "test-group"))
+	retried := receive(t, received)
+	assert.Equal(t, "msg-1", retried.ID())
+	assert.NotNil(t, retried.Metadata().Get(am.ReplayedAtHdr))
+}
+
+func collect(msgs chan<- am.IncomingMessage) am.MessageHandler {
+	return am.MessageHandlerFunc(func(_ context.Context, msg am.IncomingMessage) error {
+		msgs <- msg
+		return nil
+	})
+}
+
+func receive(t *testing.T, msgs <-chan am.IncomingMessage) am.IncomingMessage {
+	t.Helper()
+
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
@@ -2,10 +2,13 @@ package jetstream
 
 import (
 	"github.com/nats-io/nats.go"
+
+	"eda-in-golang/internal/am"
 )
 
 type subscription struct {
-	s *nats.Subscription
+	s          *nats.Subscription
+	dispatcher *am.Dispatcher
 }
 
 func (s subscription) Unsubscribe() error {
@@ -13,5 +16,9 @@ func (s subscription) Unsubscribe() error {
 		return nil
 	}
 
+	if s.dispatcher != nil {
+		defer s.dispatcher.Close()
+	}
+
 	return s.s.Drain()
 }
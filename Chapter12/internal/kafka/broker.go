@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"context"
+)
+
+// StartOffset is where a consumer group without committed offsets starts
+// reading a topic
+type StartOffset int
+
+const (
+	FirstOffset StartOffset = iota
+	LastOffset
+)
+
+type (
+	// Record is a single message as it is written to and read from a topic
+	Record struct {
+		Topic     string
+		Partition int
+		Offset    int64
+		Key       []byte
+		Value     []byte
+	}
+
+	// Broker is the small part of a Kafka client used by the Stream
+	Broker interface {
+		Produce(ctx context.Context, records ...Record) error
+		// NewReader returns a reader that is a member of the consumer group
+		NewReader(topic, groupID string, startOffset StartOffset) Reader
+		Close() error
+	}
+
+	// Reader fetches records for a consumer group member; offsets are only
+	// committed when asked to
+	Reader interface {
+		Fetch(ctx context.Context) (Record, error)
+		Commit(ctx context.Context, records ...Record) error
+		Close() error
+	}
+)
@@ -0,0 +1,157 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/stackus/errors"
+)
+
+// FakeBroker is an in-process Broker for tests
+//
+// Every topic has a single partition. Readers that share a group ID take turns
+// fetching the next record, and the committed offsets are kept for inspection.
+type FakeBroker struct {
+	mu      sync.Mutex
+	topics  map[string][]Record
+	groups  map[string]*fakeGroup
+	changed chan struct{}
+	closed  bool
+}
+
+type fakeGroup struct {
+	next      int64
+	committed int64
+}
+
+type fakeReader struct {
+	broker *FakeBroker
+	topic  string
+	group  *fakeGroup
+}
+
+var _ Broker = (*FakeBroker)(nil)
+var _ Reader = (*fakeReader)(nil)
+
+func NewFakeBroker() *FakeBroker {
+	return &FakeBroker{
+		topics:  make(map[string][]Record),
+		groups:  make(map[string]*fakeGroup),
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *FakeBroker) Produce(_ context.Context, records ...Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return errors.ErrUnavailable.Msg("the broker has been closed")
+	}
+
+	for _, record := range records {
+		record.Partition = 0
+		record.Offset = int64(len(b.topics[record.Topic]))
+		b.topics[record.Topic] = append(b.topics[record.Topic], record)
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+
+	return nil
+}
+
+func (b *FakeBroker) NewReader(topic, groupID string, startOffset StartOffset) Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := topic + "/" + groupID
+	group, exists := b.groups[key]
+	if !exists {
+		group = &fakeGroup{}
+		if startOffset == LastOffset {
+			group.next = int64(len(b.topics[topic]))
+		}
+		b.groups[key] = group
+	}
+
+	return &fakeReader{
+		broker: b,
+		topic:  topic,
+		group:  group,
+	}
+}
+
+func (b *FakeBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.changed)
+	}
+
+	return nil
+}
+
+// Records returns the records written to the topic
+func (b *FakeBroker) Records(topic string) []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Record(nil), b.topics[topic]...)
+}
+
+// Committed returns the next offset the group would start reading the topic from
+func (b *FakeBroker) Committed(topic, groupID string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if group, exists := b.groups[topic+"/"+groupID]; exists {
+		return group.committed
+	}
+
+	return 0
+}
+
+func (r *fakeReader) Fetch(ctx context.Context) (Record, error) {
+	for {
+		r.broker.mu.Lock()
+		if r.broker.closed {
+			r.broker.mu.Unlock()
+			return Record{}, errors.ErrUnavailable.Msg("the broker has been closed")
+		}
+		records := r.broker.topics[r.topic]
+		if r.group.next < int64(len(records)) {
+			record := records[r.group.next]
+			r.group.next++
+			r.broker.mu.Unlock()
+			return record, nil
+		}
+		changed := r.broker.changed
+		r.broker.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Record{}, ctx.Err()
+		}
+	}
+}
+
+func (r *fakeReader) Commit(_ context.Context, records ...Record) error {
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+
+	for _, record := range records {
+		if record.Offset+1 > r.group.committed {
+			r.group.committed = record.Offset + 1
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type kafkaBroker struct {
+	brokers []string
+	writer  *kafkago.Writer
+}
+
+type kafkaReader struct {
+	reader *kafkago.Reader
+}
+
+var _ Broker = (*kafkaBroker)(nil)
+var _ Reader = (*kafkaReader)(nil)
+
+// NewBroker connects to a Kafka cluster
+//
+// Topics are created when they are first written to; records with the same key
+// are written to the same partition.
+func NewBroker(brokers []string) Broker {
+	return kafkaBroker{
+		brokers: brokers,
+		writer: &kafkago.Writer{
+			Addr:                   kafkago.TCP(brokers...),
+			Balancer:               &kafkago.Hash{},
+			RequiredAcks:           kafkago.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (b kafkaBroker) Produce(ctx context.Context, records ...Record) error {
+	msgs := make([]kafkago.Message, len(records))
+	for i, record := range records {
+		msgs[i] = kafkago.Message{
+			Topic: record.Topic,
+			Key:   record.Key,
+			Value: record.Value,
+		}
+	}
+
+	return b.writer.WriteMessages(ctx, msgs...)
+}
+
+func (b kafkaBroker) NewReader(topic, groupID string, startOffset StartOffset) Reader {
+	offset := kafkago.FirstOffset
+	if startOffset == LastOffset {
+		offset = kafkago.LastOffset
+	}
+
+	return kafkaReader{
+		reader: kafkago.NewReader(kafkago.ReaderConfig{
+			Brokers:     b.brokers,
+			GroupID:     groupID,
+			Topic:       topic,
+			StartOffset: offset,
+		}),
+	}
+}
+
+func (b kafkaBroker) Close() error {
+	return b.writer.Close()
+}
+
+func (r kafkaReader) Fetch(ctx context.Context) (Record, error) {
+	msg, err := r.reader.FetchMessage(ctx)
+	if err != nil {
+		return Record{}, err
+	}
+
+	return Record{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+	}, nil
+}
+
+func (r kafkaReader) Commit(ctx context.Context, records ...Record) error {
+	msgs := make([]kafkago.Message, len(records))
+	for i, record := range records {
+		msgs[i] = kafkago.Message{
+			Topic:     record.Topic,
+			Partition: record.Partition,
+			Offset:    record.Offset,
+		}
+	}
+
+	return r.reader.CommitMessages(ctx, msgs...)
+}
+
+func (r kafkaReader) Close() error {
+	return r.reader.Close()
+}
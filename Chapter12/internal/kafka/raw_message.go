@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"sync"
+	"time"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type outcome int
+
+const (
+	outcomeNone outcome = iota
+	outcomeAck
+	outcomeNAck
+	outcomeKill
+)
+
+type rawMessage struct {
+	id         string
+	name       string
+	subject    string
+	data       []byte
+	metadata   ddd.Metadata
+	sentAt     time.Time
+	receivedAt time.Time
+	mu         sync.Mutex
+	outcome    outcome
+}
+
+var _ am.IncomingMessage = (*rawMessage)(nil)
+
+func (m *rawMessage) ID() string             { return m.id }
+func (m *rawMessage) Subject() string        { return m.subject }
+func (m *rawMessage) MessageName() string    { return m.name }
+func (m *rawMessage) Data() []byte           { return m.data }
+func (m *rawMessage) Metadata() ddd.Metadata { return m.metadata }
+func (m *rawMessage) SentAt() time.Time      { return m.sentAt }
+func (m *rawMessage) ReceivedAt() time.Time  { return m.receivedAt }
+
+func (m *rawMessage) Ack() error {
+	m.settle(outcomeAck)
+	return nil
+}
+
+func (m *rawMessage) NAck() error {
+	m.settle(outcomeNAck)
+	return nil
+}
+
+// Extend does nothing; Kafka does not redeliver messages that take too long to handle
+func (m *rawMessage) Extend() error {
+	return nil
+}
+
+func (m *rawMessage) Kill() error {
+	m.settle(outcomeKill)
+	return nil
+}
+
+func (m *rawMessage) settle(o outcome) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.outcome == outcomeNone {
+		m.outcome = o
+	}
+}
+
+func (m *rawMessage) result() outcome {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.outcome
+}
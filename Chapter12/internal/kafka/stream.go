@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/jetstream"
+)
+
+// Stream is an am.MessageStream that uses Kafka topics
+//
+// Messages are written using the same jetstream.StreamMessage envelope to a
+// topic named after the message subject. A GroupName becomes the consumer group,
+// a manual Ack commits the offset, and redeliveries are made by the subscription
+// itself since Kafka has no negative acknowledgements. Messages are handled one
+// at a time for each subscription so that offsets are committed in order.
+type Stream struct {
+	broker Broker
+	mu     sync.Mutex
+	subs   []*subscription
+	logger zerolog.Logger
+}
+
+var _ am.MessageStream = (*Stream)(nil)
+
+func NewStream(broker Broker, logger zerolog.Logger) *Stream {
+	return &Stream{
+		broker: broker,
+		logger: logger,
+	}
+}
+
+func (s *Stream) Publish(ctx context.Context, topicName string, rawMsg am.Message) error {
+	metadata, err := structpb.NewStruct(rawMsg.Metadata())
+	if err != nil {
+		return err
+	}
+
+	data, err := proto.Marshal(&jetstream.StreamMessage{
+		Id:       rawMsg.ID(),
+		Name:     rawMsg.MessageName(),
+		Data:     rawMsg.Data(),
+		Metadata: metadata,
+		SentAt:   timestamppb.New(rawMsg.SentAt()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.broker.Produce(ctx, Record{
+		Topic: topicName,
+		Key:   []byte(recordKey(rawMsg)),
+		Value: data,
+	})
+}
+
+func (s *Stream) Subscribe(topicName string, handler am.MessageHandler, options ...am.SubscriberOption) (am.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subCfg := am.NewSubscriberConfig(options)
+
+	// an ungrouped subscription is given a group of its own that only receives
+	// new records and never commits, so it does not replay the topic, nor leave
+	// offsets behind once it is gone
+	groupID, startOffset := subCfg.GroupName(), FirstOffset
+	if groupID == "" {
+		groupID, startOffset = fmt.Sprintf("%s-%s", topicName, uuid.New().String()), LastOffset
+	}
+
+	sub := newSubscription(s, topicName, s.broker.NewReader(topicName, groupID, startOffset), subCfg, handler)
+	s.subs = append(s.subs, sub)
+
+	go sub.run()
+
+	return sub, nil
+}
+
+func (s *Stream) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		if err := sub.Unsubscribe(); err != nil {
+			return err
+		}
+	}
+	s.subs = nil
+
+	return nil
+}
+
+func (s *Stream) deadLetter(ctx context.Context, topicName string, cfg am.SubscriberConfig, rec Record, m *jetstream.StreamMessage, deliveries int, handlerErr error) error {
+	handlerName := cfg.GroupName()
+	if handlerName == "" {
+		handlerName = rec.Topic
+	}
+
+	metadata := ddd.Metadata(m.GetMetadata().AsMap())
+	metadata.Set(am.DeadLetterSubjectHdr, rec.Topic)
+	metadata.Set(am.DeadLetterErrorHdr, handlerErr.Error())
+	metadata.Set(am.DeadLetterDeliveriesHdr, deliveries)
+	metadata.Set(am.DeadLetterHandlerHdr, handlerName)
+	metadata.Set(am.DeadLetterFailedAtHdr, time.Now().Format(time.RFC3339Nano))
+
+	return s.Publish(ctx, topicName, &rawMessage{
+		id:       m.GetId(),
+		name:     m.GetName(),
+		subject:  topicName,
+		data:     m.GetData(),
+		metadata: metadata,
+		sentAt:   m.GetSentAt().AsTime(),
+	})
+}
+
+// recordKey keeps the messages for an aggregate in the same partition
+func recordKey(msg am.Message) string {
+	if id, ok := msg.Metadata().Get(ddd.AggregateIDKey).(string); ok && id != "" {
+		return id
+	}
+
+	return msg.ID()
+}
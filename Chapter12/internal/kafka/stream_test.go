@@ -0,0 +1,268 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stackus/errors"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type testMessage struct {
+	id       string
+	name     string
+	metadata ddd.Metadata
+}
+
+func (m testMessage) ID() string             { return m.id }
+func (m testMessage) Subject() string        { return "" }
+func (m testMessage) MessageName() string    { return m.name }
+func (m testMessage) Data() []byte           { return []byte(m.id) }
+func (m testMessage) Metadata() ddd.Metadata { return m.metadata }
+func (m testMessage) SentAt() time.Time      { return time.Now() }
+
+func publish(t *testing.T, s *Stream, topicName string, count int, name string) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		err := s.Publish(context.Background(), topicName, testMessage{id: fmt.Sprintf("%s-%d", name, i), name: name, metadata: ddd.Metadata{}})
+		assert.NoError(t, err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	assert.Eventually(t, cond, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestStream_RoundTrip(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+	defer s.Unsubscribe()
+
+	received := make(chan am.IncomingMessage, 1)
+	_, err := s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		received <- msg
+		return nil
+	}), am.GroupName("group"))
+	assert.NoError(t, err)
+
+	err = s.Publish(context.Background(), "test.topic", testMessage{
+		id:       "msg-1",
+		name:     "test.Message",
+		metadata: ddd.Metadata{ddd.AggregateIDKey: "aggregate-1"},
+	})
+	assert.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "msg-1", msg.ID())
+		assert.Equal(t, "test.Message", msg.MessageName())
+		assert.Equal(t, "test.topic", msg.Subject())
+		assert.Equal(t, []byte("msg-1"), msg.Data())
+		assert.Equal(t, "aggregate-1", msg.Metadata().Get(ddd.AggregateIDKey))
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not received")
+	}
+
+	assert.Equal(t, []byte("aggregate-1"), broker.Records("test.topic")[0].Key)
+	waitFor(t, func() bool { return broker.Committed("test.topic", "group") == 1 })
+}
+
+func TestStream_GroupCompetes(t *testing.T) {
+	s := NewStream(NewFakeBroker(), zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var mu sync.Mutex
+	seen := map[string]int{}
+	handler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		mu.Lock()
+		seen[msg.ID()]++
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Subscribe("test.topic", handler, am.GroupName("group"))
+		assert.NoError(t, err)
+	}
+	publish(t, s, "test.topic", 10, "test.Message")
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == 10
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	for id, count := range seen {
+		assert.Equal(t, 1, count, id)
+	}
+}
+
+func TestStream_Filter(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var handled atomic.Int32
+	_, err := s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		assert.Equal(t, "test.Wanted", msg.MessageName())
+		handled.Add(1)
+		return nil
+	}), am.GroupName("group"), am.MessageFilter{"test.Wanted"})
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 3, "test.Unwanted")
+	publish(t, s, "test.topic", 2, "test.Wanted")
+
+	// filtered records are committed too
+	waitFor(t, func() bool { return broker.Committed("test.topic", "group") == 5 })
+	assert.Equal(t, int32(2), handled.Load())
+}
+
+func TestStream_ManualAck(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+	defer s.Unsubscribe()
+
+	release := make(chan struct{})
+	_, err := s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		<-release
+		return msg.Ack()
+	}), am.GroupName("group"), am.AckType(am.AckTypeManual))
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.Message")
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int64(0), broker.Committed("test.topic", "group"))
+
+	close(release)
+	waitFor(t, func() bool { return broker.Committed("test.topic", "group") == 1 })
+}
+
+func TestStream_RedeliverThenDeadLetter(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+	defer s.Unsubscribe()
+
+	dead := make(chan am.IncomingMessage, 1)
+	_, err := s.Subscribe("test.deadletters", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		dead <- msg
+		return nil
+	}))
+	assert.NoError(t, err)
+
+	var attempts atomic.Int32
+	_, err = s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		attempts.Add(1)
+		return errors.ErrInternal.Msg("handler failed")
+	}),
+		am.GroupName("group"),
+		am.AckType(am.AckTypeManual),
+		am.MaxRedeliver(3),
+		am.RedeliveryPolicy(am.ConstantBackoff(time.Millisecond)),
+		am.DeadLetterTopic("test.deadletters"),
+	)
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.Message")
+
+	waitFor(t, func() bool { return broker.Committed("test.topic", "group") == 1 })
+	assert.Equal(t, int32(3), attempts.Load())
+
+	select {
+	case msg := <-dead:
+		assert.Equal(t, "test.Message-0", msg.ID())
+		assert.Equal(t, "test.topic", msg.Metadata().Get(am.DeadLetterSubjectHdr))
+		assert.Equal(t, "group", msg.Metadata().Get(am.DeadLetterHandlerHdr))
+		assert.EqualValues(t, 3, msg.Metadata().Get(am.DeadLetterDeliveriesHdr))
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not dead-lettered")
+	}
+}
+
+func TestStream_UncommittedAfterUnsubscribe(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+
+	started := make(chan struct{})
+	_, err := s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}), am.GroupName("group"), am.AckType(am.AckTypeManual))
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.Message")
+	<-started
+
+	assert.NoError(t, s.Unsubscribe())
+	assert.Equal(t, int64(0), broker.Committed("test.topic", "group"))
+}
+
+func TestStream_WaitsForSlowHandlers(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+	defer s.Unsubscribe()
+
+	var attempts, running, overlapped atomic.Int32
+	_, err := s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		defer running.Add(-1)
+		if attempts.Add(1) == 1 {
+			// ignores the context and fails well after the ack wait
+			time.Sleep(100 * time.Millisecond)
+			return errors.ErrInternal.Msg("handler failed")
+		}
+		return nil
+	}),
+		am.GroupName("group"),
+		am.AckType(am.AckTypeManual),
+		am.AckWait(10*time.Millisecond),
+		am.MaxRedeliver(3),
+		am.RedeliveryPolicy(am.ConstantBackoff(time.Millisecond)),
+	)
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.Message")
+
+	waitFor(t, func() bool { return broker.Committed("test.topic", "group") == 1 })
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, int32(0), overlapped.Load())
+}
+
+func TestStream_UngroupedReceivesNewRecords(t *testing.T) {
+	broker := NewFakeBroker()
+	s := NewStream(broker, zerolog.Nop())
+	defer s.Unsubscribe()
+
+	publish(t, s, "test.topic", 2, "test.Old")
+
+	received := make(chan am.IncomingMessage, 3)
+	_, err := s.Subscribe("test.topic", am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
+		received <- msg
+		return nil
+	}))
+	assert.NoError(t, err)
+
+	publish(t, s, "test.topic", 1, "test.New")
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "test.New-0", msg.ID())
+	case <-time.After(2 * time.Second):
+		t.Fatal("message was not received")
+	}
+	assert.Empty(t, received)
+}
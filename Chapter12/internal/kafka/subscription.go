@@ -0,0 +1,212 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/stackus/errors"
+	"google.golang.org/protobuf/proto"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/jetstream"
+)
+
+// subscription fetches the records for a consumer group member and handles them
+// in order, committing the offset of each once it has been settled
+type subscription struct {
+	stream   *Stream
+	topic    string
+	reader   Reader
+	cfg      am.SubscriberConfig
+	handler  am.MessageHandler
+	filters  map[string]struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newSubscription(stream *Stream, topicName string, reader Reader, cfg am.SubscriberConfig, handler am.MessageHandler) *subscription {
+	var filters map[string]struct{}
+	if len(cfg.MessageFilters()) > 0 {
+		filters = make(map[string]struct{})
+		for _, key := range cfg.MessageFilters() {
+			filters[key] = struct{}{}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &subscription{
+		stream:  stream,
+		topic:   topicName,
+		reader:  reader,
+		cfg:     cfg,
+		handler: handler,
+		filters: filters,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *subscription) Unsubscribe() (err error) {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		<-s.done
+		err = s.reader.Close()
+	})
+
+	return
+}
+
+func (s *subscription) run() {
+	defer close(s.done)
+
+	for {
+		rec, err := s.reader.Fetch(s.ctx)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.stream.logger.Error().Err(err).Msgf("failed to fetch from topic %s", s.topic)
+			}
+			return
+		}
+
+		s.handle(rec)
+
+		// leave the record uncommitted for the next member of the group
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		if s.cfg.GroupName() == "" {
+			continue
+		}
+
+		if err = s.reader.Commit(s.ctx, rec); err != nil {
+			s.stream.logger.Error().Err(err).Msgf("failed to commit offset %d of topic %s", rec.Offset, rec.Topic)
+		}
+	}
+}
+
+// handle delivers the record until the handler settles it, or it is delivered
+// for the last time
+func (s *subscription) handle(rec Record) {
+	m := &jetstream.StreamMessage{}
+	if err := proto.Unmarshal(rec.Value, m); err != nil {
+		s.stream.logger.Warn().Err(err).Msgf("failed to unmarshal the record at offset %d of topic %s", rec.Offset, rec.Topic)
+		return
+	}
+
+	if !s.wants(m) {
+		return
+	}
+
+	startedAt := time.Now()
+
+	for deliveries := 1; ; deliveries++ {
+		msg := s.newRawMessage(rec, m)
+
+		err := s.deliver(msg)
+		if err != nil {
+			s.stream.logger.Error().Err(err).Msg("error while handling message")
+			_ = msg.NAck()
+		}
+
+		switch msg.result() {
+		case outcomeAck, outcomeKill:
+			return
+		}
+
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		if s.isFinalDelivery(deliveries, startedAt) {
+			if err != nil {
+				s.fail(rec, m, deliveries, err)
+			}
+			return
+		}
+
+		var delay time.Duration
+		if policy := s.cfg.RetryPolicy(); policy != nil {
+			delay = policy.Backoff(deliveries)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver runs the handler; a handler that runs past the AckWait is told to
+// stop, but the message is only redelivered once the handler has returned so
+// that it is never handled twice at the same time
+func (s *subscription) deliver(msg *rawMessage) error {
+	wCtx, cancel := context.WithTimeout(s.ctx, s.cfg.AckWait())
+	defer cancel()
+
+	err := s.handler.HandleMessage(wCtx, msg)
+	if err == nil {
+		_ = msg.Ack()
+		return nil
+	}
+	if wCtx.Err() != nil && s.ctx.Err() == nil {
+		err = errors.Wrap(err, "handling the message took longer than the ack wait")
+	}
+
+	return err
+}
+
+func (s *subscription) newRawMessage(rec Record, m *jetstream.StreamMessage) *rawMessage {
+	return &rawMessage{
+		id:         m.GetId(),
+		name:       m.GetName(),
+		subject:    rec.Topic,
+		data:       m.GetData(),
+		metadata:   ddd.Metadata(m.GetMetadata().AsMap()),
+		sentAt:     m.GetSentAt().AsTime(),
+		receivedAt: time.Now(),
+	}
+}
+
+// fail sends the message to the dead-letter topic, when there is one
+func (s *subscription) fail(rec Record, m *jetstream.StreamMessage, deliveries int, err error) {
+	topic := s.cfg.DeadLetterTopic()
+	if topic == "" {
+		return
+	}
+
+	if dlErr := s.stream.deadLetter(s.ctx, topic, s.cfg, rec, m, deliveries, err); dlErr != nil {
+		s.stream.logger.Error().Err(dlErr).Msgf("failed to publish message to dead-letter topic %s", topic)
+	}
+}
+
+// isFinalDelivery reports whether the message should not be redelivered should
+// this delivery fail
+func (s *subscription) isFinalDelivery(deliveries int, startedAt time.Time) bool {
+	if s.cfg.AckType() == am.AckTypeAuto {
+		return true
+	}
+
+	if policy := s.cfg.RetryPolicy(); policy != nil && am.RetryExhausted(policy, startedAt) {
+		return true
+	}
+
+	return deliveries >= s.cfg.MaxRedeliver()
+}
+
+// wants reports whether the message passes the subscription filters
+func (s *subscription) wants(m *jetstream.StreamMessage) bool {
+	if s.filters == nil {
+		return true
+	}
+
+	_, exists := s.filters[m.GetName()]
+	return exists
+}
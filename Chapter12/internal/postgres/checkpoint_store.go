@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/projection"
+)
+
+type CheckpointStore struct {
+	tableName string
+	db        DB
+}
+
+var _ projection.CheckpointStore = (*CheckpointStore)(nil)
+
+func NewCheckpointStore(tableName string, db DB) CheckpointStore {
+	return CheckpointStore{
+		tableName: tableName,
+		db:        db,
+	}
+}
+
+func (s CheckpointStore) Load(ctx context.Context, name string) (projection.Checkpoint, error) {
+	const query = `SELECT name, position, status, handled, rebuild_requested_at IS NOT NULL, started_at, updated_at
+FROM %s WHERE name = $1 FOR UPDATE`
+
+	checkpoint, err := s.scan(s.db.QueryRowContext(ctx, s.table(query), name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return checkpoint, errors.ErrNotFound.Msgf("the %s projection has not been built", name)
+	}
+
+	return checkpoint, err
+}
+
+func (s CheckpointStore) Save(ctx context.Context, previous, checkpoint projection.Checkpoint) error {
+	const query = `UPDATE %s SET position = $2, status = $3, handled = $4, updated_at = CURRENT_TIMESTAMP
+WHERE name = $1 AND position = $5 AND handled = $6 AND started_at = $7`
+
+	result, err := s.db.ExecContext(ctx, s.table(query),
+		checkpoint.Name, checkpoint.Position, checkpoint.Status, checkpoint.Handled,
+		previous.Position, previous.Handled, previous.StartedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return errors.Wrapf(projection.ErrCheckpointMoved, "saving the %s projection checkpoint", checkpoint.Name)
+	}
+
+	return nil
+}
+
+func (s CheckpointStore) StartRebuild(ctx context.Context, name string) (projection.Checkpoint, error) {
+	const query = `INSERT INTO %s (name, position, status, handled) VALUES ($1, 0, $2, 0)
+ON CONFLICT (name) DO
+UPDATE SET position = 0, status = EXCLUDED.status, handled = 0, rebuild_requested_at = NULL,
+started_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+RETURNING name, position, status, handled, rebuild_requested_at IS NOT NULL, started_at, updated_at`
+
+	return s.scan(s.db.QueryRowContext(ctx, s.table(query), name, projection.StatusRebuilding))
+}
+
+func (s CheckpointStore) RequestRebuild(ctx context.Context, name string) error {
+	const query = `UPDATE %s SET rebuild_requested_at = CURRENT_TIMESTAMP WHERE name = $1`
+
+	result, err := s.db.ExecContext(ctx, s.table(query), name)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return errors.ErrNotFound.Msgf("the %s projection has not been built", name)
+	}
+
+	return nil
+}
+
+func (s CheckpointStore) List(ctx context.Context) (checkpoints []projection.Checkpoint, err error) {
+	const query = `SELECT name, position, status, handled, rebuild_requested_at IS NOT NULL, started_at, updated_at
+FROM %s ORDER BY name`
+
+	var rows *sql.Rows
+	rows, err = s.db.QueryContext(ctx, s.table(query))
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing checkpoint rows")
+		}
+	}(rows)
+
+	for rows.Next() {
+		var checkpoint projection.Checkpoint
+		if checkpoint, err = s.scan(rows); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "finishing checkpoint rows")
+	}
+
+	return checkpoints, nil
+}
+
+func (s CheckpointStore) scan(row interface{ Scan(dest ...any) error }) (projection.Checkpoint, error) {
+	var checkpoint projection.Checkpoint
+	err := row.Scan(&checkpoint.Name, &checkpoint.Position, &checkpoint.Status, &checkpoint.Handled, &checkpoint.RebuildRequested,
+		&checkpoint.StartedAt, &checkpoint.UpdatedAt)
+
+	return checkpoint, err
+}
+
+func (s CheckpointStore) table(query string) string {
+	return fmt.Sprintf(query, s.tableName)
+}
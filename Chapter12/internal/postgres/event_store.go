@@ -3,10 +3,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
 	"github.com/stackus/errors"
 
 	"eda-in-golang/internal/ddd"
@@ -25,15 +28,30 @@ type (
 		id         string
 		name       string
 		payload    ddd.EventPayload
+		metadata   ddd.Metadata
 		occurredAt time.Time
 		aggregate  es.EventSourcedAggregate
 		version    int
 	}
+
+	streamEvent struct {
+		id            string
+		name          string
+		payload       ddd.EventPayload
+		metadata      ddd.Metadata
+		occurredAt    time.Time
+		aggregateName string
+		aggregateID   string
+		version       int
+		position      int64
+	}
 )
 
 var _ es.AggregateStore = (*EventStore)(nil)
+var _ es.EventStreamReader = (*EventStore)(nil)
 
 var _ ddd.AggregateEvent = (*aggregateEvent)(nil)
+var _ es.StreamEvent = (*streamEvent)(nil)
 
 func NewEventStore(tableName string, db DB, registry registry.Registry) EventStore {
 	return EventStore{
@@ -44,7 +62,7 @@ func NewEventStore(tableName string, db DB, registry registry.Registry) EventSto
 }
 
 func (s EventStore) Load(ctx context.Context, aggregate es.EventSourcedAggregate) (err error) {
-	const query = `SELECT stream_version, event_id, event_name, event_data, occurred_at FROM %s WHERE stream_id = $1 AND stream_name = $2 AND stream_version > $3 ORDER BY stream_version ASC`
+	const query = `SELECT stream_version, event_id, event_name, event_data, schema_version, metadata, occurred_at FROM %s WHERE stream_id = $1 AND stream_name = $2 AND stream_version > $3 ORDER BY stream_version ASC`
 
 	aggregateID := aggregate.ID()
 	aggregateName := aggregate.AggregateName()
@@ -64,16 +82,21 @@ func (s EventStore) Load(ctx context.Context, aggregate es.EventSourcedAggregate
 
 	for rows.Next() {
 		var eventID, eventName string
-		var payloadData []byte
-		var aggregateVersion int
+		var payloadData, metadataData []byte
+		var aggregateVersion, schemaVersion int
 		var occurredAt time.Time
-		err := rows.Scan(&aggregateVersion, &eventID, &eventName, &payloadData, &occurredAt)
+		err := rows.Scan(&aggregateVersion, &eventID, &eventName, &payloadData, &schemaVersion, &metadataData, &occurredAt)
 		if err != nil {
 			return err
 		}
 
+		metadata := make(ddd.Metadata)
+		if err = json.Unmarshal(metadataData, &metadata); err != nil {
+			return err
+		}
+
 		var payload interface{}
-		payload, err = s.registry.Deserialize(eventName, payloadData)
+		payload, err = s.registry.DeserializeSchema(eventName, registry.Schema{Version: schemaVersion}, payloadData)
 		if err != nil {
 			return err
 		}
@@ -82,6 +105,7 @@ func (s EventStore) Load(ctx context.Context, aggregate es.EventSourcedAggregate
 			id:         eventID,
 			name:       eventName,
 			payload:    payload,
+			metadata:   metadata,
 			aggregate:  aggregate,
 			version:    aggregateVersion,
 			occurredAt: occurredAt,
@@ -96,43 +120,166 @@ func (s EventStore) Load(ctx context.Context, aggregate es.EventSourcedAggregate
 }
 
 func (s EventStore) Save(ctx context.Context, aggregate es.EventSourcedAggregate) (err error) {
-	const query = `INSERT INTO %s (stream_id, stream_name, stream_version, event_id, event_name, event_data, occurred_at) VALUES`
+	const query = `INSERT INTO %s (stream_id, stream_name, stream_version, event_id, event_name, event_data, schema_version, metadata, occurred_at) VALUES`
 
 	aggregateID := aggregate.ID()
 	aggregateName := aggregate.AggregateName()
 
 	placeholders := make([]string, len(aggregate.Events()))
-	values := make([]any, len(aggregate.Events())*7)
+	values := make([]any, len(aggregate.Events())*9)
 
 	for i, event := range aggregate.Events() {
-		var payloadData []byte
+		var payloadData, metadataData []byte
+		var schema registry.Schema
 
 		payloadData, err = s.registry.Serialize(event.EventName(), event.Payload())
 		if err != nil {
 			return err
 		}
 
-		placeholders[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			i*7+1, i*7+2, i*7+3, i*7+4, i*7+5, i*7+6, i*7+7,
+		schema, err = s.registry.Schema(event.EventName())
+		if err != nil {
+			return err
+		}
+
+		metadataData, err = json.Marshal(eventMetadata(event))
+		if err != nil {
+			return err
+		}
+
+		placeholders[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			i*9+1, i*9+2, i*9+3, i*9+4, i*9+5, i*9+6, i*9+7, i*9+8, i*9+9,
 		)
 
-		values[i*7] = aggregateID
-		values[i*1+1] = aggregateName
-		values[i*7+2] = event.AggregateVersion()
-		values[i*7+3] = event.ID()
-		values[i*7+4] = event.EventName()
-		values[i*7+5] = payloadData
-		values[i*7+6] = event.OccurredAt()
+		values[i*9] = aggregateID
+		values[i*9+1] = aggregateName
+		values[i*9+2] = event.AggregateVersion()
+		values[i*9+3] = event.ID()
+		values[i*9+4] = event.EventName()
+		values[i*9+5] = payloadData
+		values[i*9+6] = schema.Version
+		values[i*9+7] = metadataData
+		values[i*9+8] = event.OccurredAt()
+	}
+
+	// inside a transaction the failed insert is rolled back to a savepoint so
+	// that the transaction can still be used to reload the aggregate
+	_, isDB := s.db.(TxBeginner)
+	inTx := !isDB
+	if inTx {
+		if _, err = s.db.ExecContext(ctx, "SAVEPOINT event_store_save"); err != nil {
+			return err
+		}
 	}
-	if _, err = s.db.ExecContext(
+
+	_, err = s.db.ExecContext(
 		ctx,
 		fmt.Sprintf("%s %s", s.table(query), strings.Join(placeholders, ",")),
 		values...,
-	); err != nil {
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+			return err
+		}
+		if inTx {
+			if _, err = s.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT event_store_save"); err != nil {
+				return err
+			}
+		}
+		return s.concurrencyConflict(ctx, aggregate)
+	}
+
+	if inTx {
+		_, err = s.db.ExecContext(ctx, "RELEASE SAVEPOINT event_store_save")
+	}
+
+	return err
+}
+
+func (s EventStore) concurrencyConflict(ctx context.Context, aggregate es.EventSourcedAggregate) error {
+	const query = `SELECT coalesce(max(stream_version), 0) FROM %s WHERE stream_id = $1 AND stream_name = $2`
+
+	var actualVersion int
+	err := s.db.QueryRowContext(ctx, s.table(query), aggregate.ID(), aggregate.AggregateName()).Scan(&actualVersion)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return es.ErrConcurrencyConflict{
+		AggregateName:   aggregate.AggregateName(),
+		AggregateID:     aggregate.ID(),
+		ExpectedVersion: aggregate.Version(),
+		ActualVersion:   actualVersion,
+	}
+}
+
+// ReadEvents reads the events committed by transactions that finished before
+// any transaction still in progress began; ordering by transaction means an
+// event is never read after events committed later, so none are skipped by a
+// reader continuing from the last position it read
+func (s EventStore) ReadEvents(ctx context.Context, filter es.EventStreamFilter) (events []es.StreamEvent, err error) {
+	const query = `SELECT e.global_position, e.stream_id, e.stream_name, e.stream_version, e.event_id, e.event_name,
+e.event_data, e.schema_version, e.metadata, e.occurred_at
+FROM %[1]s e, (SELECT coalesce((SELECT transaction_id FROM %[1]s WHERE global_position = $1), '0'::xid8) AS transaction_id) c
+WHERE (e.transaction_id, e.global_position) > (c.transaction_id, $1)
+AND e.transaction_id < pg_snapshot_xmin(pg_current_snapshot())
+AND ($2 = '' OR e.stream_name = $2)
+ORDER BY e.transaction_id, e.global_position
+LIMIT %[2]d`
+
+	var rows *sql.Rows
+
+	rows, err = s.db.QueryContext(ctx, fmt.Sprintf(query, s.tableName, filter.Limit), filter.After, filter.AggregateName)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing event rows")
+		}
+	}(rows)
+
+	for rows.Next() {
+		var event streamEvent
+		var payloadData, metadataData []byte
+		var schemaVersion int
+		err = rows.Scan(&event.position, &event.aggregateID, &event.aggregateName, &event.version, &event.id, &event.name,
+			&payloadData, &schemaVersion, &metadataData, &event.occurredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		event.payload, err = s.registry.DeserializeSchema(event.name, registry.Schema{Version: schemaVersion}, payloadData)
+		if err != nil {
+			return nil, err
+		}
+
+		event.metadata = make(ddd.Metadata)
+		if err = json.Unmarshal(metadataData, &event.metadata); err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// eventMetadata returns the metadata to keep with an event; the aggregate
+// name, id and version are kept in their own columns
+func eventMetadata(event ddd.AggregateEvent) ddd.Metadata {
+	metadata := make(ddd.Metadata, len(event.Metadata()))
+	for key, value := range event.Metadata() {
+		switch key {
+		case ddd.AggregateNameKey, ddd.AggregateIDKey, ddd.AggregateVersionKey:
+			continue
+		}
+		metadata[key] = value
+	}
+
+	return metadata
 }
 
 func (s EventStore) table(query string) string {
@@ -142,8 +289,18 @@ func (s EventStore) table(query string) string {
 func (e aggregateEvent) ID() string                { return e.id }
 func (e aggregateEvent) EventName() string         { return e.name }
 func (e aggregateEvent) Payload() ddd.EventPayload { return e.payload }
-func (e aggregateEvent) Metadata() ddd.Metadata    { return ddd.Metadata{} }
+func (e aggregateEvent) Metadata() ddd.Metadata    { return e.metadata }
 func (e aggregateEvent) OccurredAt() time.Time     { return e.occurredAt }
 func (e aggregateEvent) AggregateName() string     { return e.aggregate.AggregateName() }
 func (e aggregateEvent) AggregateID() string       { return e.aggregate.ID() }
 func (e aggregateEvent) AggregateVersion() int     { return e.version }
+
+func (e streamEvent) ID() string                { return e.id }
+func (e streamEvent) EventName() string         { return e.name }
+func (e streamEvent) Payload() ddd.EventPayload { return e.payload }
+func (e streamEvent) Metadata() ddd.Metadata    { return e.metadata }
+func (e streamEvent) OccurredAt() time.Time     { return e.occurredAt }
+func (e streamEvent) AggregateName() string     { return e.aggregateName }
+func (e streamEvent) AggregateID() string       { return e.aggregateID }
+func (e streamEvent) AggregateVersion() int     { return e.version }
+func (e streamEvent) GlobalPosition() int64     { return e.position }
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"eda-in-golang/internal/es"
+)
+
+// EventStreamListener listens for the notifications sent by the trigger on an
+// events table; the channel is the name of the table
+type EventStreamListener struct {
+	tableName string
+	db        *sql.DB
+}
+
+var _ es.EventStreamNotifier = (*EventStreamListener)(nil)
+
+func NewEventStreamListener(tableName string, db *sql.DB) EventStreamListener {
+	return EventStreamListener{
+		tableName: tableName,
+		db:        db,
+	}
+}
+
+func (l EventStreamListener) Listen(ctx context.Context, notify func()) error {
+	return listen(ctx, l.db, l.tableName, notify)
+}
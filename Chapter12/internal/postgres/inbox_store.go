@@ -2,11 +2,10 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 
-	"github.com/jackc/pgconn"
-	"github.com/jackc/pgerrcode"
 	"github.com/stackus/errors"
 
 	"eda-in-golang/internal/am"
@@ -16,38 +15,148 @@ import (
 type InboxStore struct {
 	tableName string
 	db        DB
+	failureDB DB
 }
 
 var _ tm.InboxStore = (*InboxStore)(nil)
+var _ tm.InboxEntryStore = (*InboxStore)(nil)
 
-func NewInboxStore(tableName string, db DB) InboxStore {
+// NewInboxStore creates an inbox that records completed messages with db and
+// failures with failureDB, which should not be the transaction the handlers use
+func NewInboxStore(tableName string, db DB, failureDB DB) InboxStore {
 	return InboxStore{
 		tableName: tableName,
 		db:        db,
+		failureDB: failureDB,
 	}
 }
 
-func (s InboxStore) Save(ctx context.Context, msg am.IncomingMessage) error {
-	const query = "INSERT INTO %s (id, NAME, subject, DATA, metadata, sent_at, received_at) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+// Completed also finds the messages recorded before the inbox kept track of
+// handlers; those rows have no handler and were all completed
+func (s InboxStore) Completed(ctx context.Context, msgID, handler string) (bool, error) {
+	const query = "SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND handler IN ($2, '') AND status = $3)"
+
+	var completed bool
+	err := s.db.QueryRowContext(ctx, s.table(query), msgID, handler, tm.InboxCompleted.String()).Scan(&completed)
+	if err != nil {
+		return false, err
+	}
+
+	return completed, nil
+}
+
+func (s InboxStore) Complete(ctx context.Context, msg am.IncomingMessage, handler string) error {
+	const query = `INSERT INTO %s AS i (id, handler, name, subject, data, metadata, sent_at, received_at, status, attempts, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, CURRENT_TIMESTAMP)
+ON CONFLICT (id, handler) DO UPDATE SET status = EXCLUDED.status, attempts = i.attempts + 1, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE i.status <> 'completed'`
 
 	metadata, err := json.Marshal(msg.Metadata())
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.ExecContext(ctx, s.table(query), msg.ID(), msg.MessageName(), msg.Subject(), msg.Data(), metadata, msg.SentAt(), msg.ReceivedAt())
+	result, err := s.db.ExecContext(ctx, s.table(query), msg.ID(), handler, msg.MessageName(), msg.Subject(), msg.Data(), metadata, msg.SentAt(), msg.ReceivedAt(), tm.InboxCompleted.String())
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				return tm.ErrDuplicateMessage(msg.ID())
-			}
-		}
+		return err
+	}
+
+	// nothing is changed when the handler had already completed the message
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return tm.ErrDuplicateMessage(msg.ID())
+	}
+
+	return nil
+}
+
+func (s InboxStore) Fail(ctx context.Context, msg am.IncomingMessage, handler string, handlerErr error) error {
+	const query = `INSERT INTO %s AS i (id, handler, name, subject, data, metadata, sent_at, received_at, status, attempts, last_error, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, $10, CURRENT_TIMESTAMP)
+ON CONFLICT (id, handler) DO UPDATE SET status = EXCLUDED.status, attempts = i.attempts + 1, last_error = EXCLUDED.last_error, updated_at = CURRENT_TIMESTAMP
+WHERE i.status <> 'completed'`
+
+	metadata, err := json.Marshal(msg.Metadata())
+	if err != nil {
+		return err
 	}
 
+	_, err = s.failureDB.ExecContext(ctx, s.table(query), msg.ID(), handler, msg.MessageName(), msg.Subject(), msg.Data(), metadata, msg.SentAt(), msg.ReceivedAt(),
+		tm.InboxFailed.String(), handlerErr.Error())
+
 	return err
 }
 
+func (s InboxStore) FindFailed(ctx context.Context, limit int) ([]tm.InboxEntry, error) {
+	const query = `SELECT id, handler, name, subject, data, metadata, sent_at, received_at, status, attempts, last_error, updated_at
+FROM %s WHERE status = $1 ORDER BY updated_at LIMIT %d`
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(query, s.tableName, limit), tm.InboxFailed.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing inbox rows")
+		}
+	}(rows)
+
+	var entries []tm.InboxEntry
+
+	for rows.Next() {
+		entry, err := s.scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s InboxStore) Find(ctx context.Context, msgID, handler string) (tm.InboxEntry, error) {
+	const query = `SELECT id, handler, name, subject, data, metadata, sent_at, received_at, status, attempts, last_error, updated_at
+FROM %s WHERE id = $1 AND handler = $2`
+
+	entry, err := s.scanEntry(s.db.QueryRowContext(ctx, s.table(query), msgID, handler))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entry, errors.ErrNotFound.Msgf("inbox entry %s for %s not found", msgID, handler)
+		}
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+func (s InboxStore) scanEntry(row interface{ Scan(dest ...any) error }) (tm.InboxEntry, error) {
+	var entry tm.InboxEntry
+	var metadata []byte
+	var status string
+	var lastError sql.NullString
+	var updatedAt sql.NullTime
+	msg := outboxMessage{}
+
+	err := row.Scan(&msg.id, &entry.Handler, &msg.name, &msg.subject, &msg.data, &metadata, &msg.sentAt, &entry.ReceivedAt,
+		&status, &entry.Attempts, &lastError, &updatedAt)
+	if err != nil {
+		return entry, err
+	}
+
+	if err = json.Unmarshal(metadata, &msg.metadata); err != nil {
+		return entry, err
+	}
+
+	entry.Message = msg
+	entry.Status = tm.InboxStatus(status)
+	entry.LastError = lastError.String
+	entry.UpdatedAt = updatedAt.Time
+
+	return entry, nil
+}
+
 func (s InboxStore) table(query string) string {
 	return fmt.Sprintf(query, s.tableName)
 }
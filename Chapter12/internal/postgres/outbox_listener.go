@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+
+	"eda-in-golang/internal/config"
+	"eda-in-golang/internal/tm"
+)
+
+// OutboxListener listens for the notifications sent by the outbox_notify_trigger
+// on the outbox table; the channel is the name of the table
+type OutboxListener struct {
+	tableName string
+	db        *sql.DB
+}
+
+var _ tm.OutboxNotifier = (*OutboxListener)(nil)
+
+func NewOutboxListener(tableName string, db *sql.DB) OutboxListener {
+	return OutboxListener{
+		tableName: tableName,
+		db:        db,
+	}
+}
+
+func (l OutboxListener) Listen(ctx context.Context, notify func()) error {
+	return listen(ctx, l.db, l.tableName, notify)
+}
+
+// listen calls notify for each notification sent on the channel
+func listen(ctx context.Context, db *sql.DB, channel string, notify func()) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func(conn *sql.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("%T is not a pgx connection", driverConn)
+		}
+		pgConn := stdConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return err
+		}
+
+		// anything saved before listening began will be found by the next poll
+		for {
+			if _, err := pgConn.WaitForNotification(ctx); err != nil {
+				return err
+			}
+			notify()
+		}
+	})
+}
+
+// OutboxProcessorOptions configures an outbox processor for the outbox table
+func OutboxProcessorOptions(cfg config.OutboxConfig, tableName string, db *sql.DB) []tm.OutboxProcessorOption {
+	options := []tm.OutboxProcessorOption{
+		tm.OutboxBatchSize(cfg.BatchSize),
+		tm.OutboxPollingInterval(cfg.PollingInterval),
+		tm.OutboxMaxAttempts(cfg.MaxAttempts),
+	}
+
+	if cfg.Notify {
+		options = append(options, tm.OutboxNotifications(NewOutboxListener(tableName, db)))
+	}
+
+	return options
+}
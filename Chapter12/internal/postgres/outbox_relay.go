@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/rs/zerolog"
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/tm"
+)
+
+const relayStatusInterval = 10 * time.Second
+const relayRetryInterval = 5 * time.Second
+
+// OutboxRelay publishes the messages saved to an outbox table as they are
+// committed, reading them from the write-ahead log with logical replication.
+//
+// The table must be in a publication named after it, e.g. "ordering_outbox_pub"
+// for "ordering.outbox", and the position reached is kept in the table's
+// "_relay_positions" table. Messages are published in commit order, at least
+// once. Messages saved with a delivery time are skipped and left for a
+// processor using the Scheduled store to publish once they are due. The
+// replication slot holds on to the WAL while the relay is not running, so it
+// should be dropped when switching back to polling.
+type OutboxRelay struct {
+	tableName      string
+	positionsTable string
+	publication    string
+	slotName       string
+	connString     string
+	db             *sql.DB
+	publisher      am.MessagePublisher
+	logger         zerolog.Logger
+}
+
+type relayTransaction struct {
+	relations map[uint32]*pglogrepl.RelationMessage
+	msgs      []am.Message
+}
+
+var _ tm.OutboxProcessor = (*OutboxRelay)(nil)
+
+// NewOutboxRelay creates a relay for the outbox table; connString is used to open
+// the replication connection and db to record what has been published
+func NewOutboxRelay(tableName, connString string, db *sql.DB, publisher am.MessagePublisher, logger zerolog.Logger) *OutboxRelay {
+	name := strings.ReplaceAll(tableName, ".", "_")
+
+	return &OutboxRelay{
+		tableName:      tableName,
+		positionsTable: tableName + "_relay_positions",
+		publication:    name + "_pub",
+		slotName:       name + "_relay",
+		connString:     connString,
+		db:             db,
+		publisher:      publisher,
+		logger:         logger,
+	}
+}
+
+// Start relays messages until the context is done, reconnecting after failures
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	for {
+		err := r.relay(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		r.logger.Error().Err(err).Msgf("outbox relay failed; reconnecting in %s", relayRetryInterval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(relayRetryInterval):
+		}
+	}
+}
+
+func (r *OutboxRelay) relay(ctx context.Context) error {
+	cfg, err := pgconn.ParseConfig(r.connString)
+	if err != nil {
+		return err
+	}
+	cfg.RuntimeParams["replication"] = "database"
+
+	conn, err := pgconn.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if err = r.createSlot(ctx, conn); err != nil {
+		return err
+	}
+
+	position, err := r.loadPosition(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = pglogrepl.StartReplication(ctx, conn, r.slotName, position, pglogrepl.StartReplicationOptions{
+		PluginArgs: []string{"proto_version '1'", fmt.Sprintf("publication_names '%s'", r.publication)},
+	})
+	if err != nil {
+		return err
+	}
+
+	txn := relayTransaction{relations: make(map[uint32]*pglogrepl.RelationMessage)}
+	statusDeadline := time.Now().Add(relayStatusInterval)
+
+	for {
+		if !time.Now().Before(statusDeadline) {
+			err = pglogrepl.SendStandbyStatusUpdate(ctx, conn, pglogrepl.StandbyStatusUpdate{WALWritePosition: position})
+			if err != nil {
+				return err
+			}
+			statusDeadline = time.Now().Add(relayStatusInterval)
+		}
+
+		receiveCtx, cancel := context.WithDeadline(ctx, statusDeadline)
+		msg, err := conn.ReceiveMessage(receiveCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && pgconn.Timeout(err) {
+				continue
+			}
+			return err
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			switch msg.Data[0] {
+			case pglogrepl.PrimaryKeepaliveMessageByteID:
+				keepalive, err := pglogrepl.ParsePrimaryKeepaliveMessage(msg.Data[1:])
+				if err != nil {
+					return err
+				}
+				if keepalive.ReplyRequested {
+					statusDeadline = time.Time{}
+				}
+			case pglogrepl.XLogDataByteID:
+				xld, err := pglogrepl.ParseXLogData(msg.Data[1:])
+				if err != nil {
+					return err
+				}
+				committed, err := txn.apply(xld.WALData)
+				if err != nil {
+					return err
+				}
+				if committed == nil {
+					continue
+				}
+				if err = r.publish(ctx, txn.msgs, committed.TransactionEndLSN); err != nil {
+					return err
+				}
+				position = committed.TransactionEndLSN
+				txn.msgs = nil
+			}
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		}
+	}
+}
+
+func (r *OutboxRelay) createSlot(ctx context.Context, conn *pgconn.PgConn) error {
+	_, err := pglogrepl.CreateReplicationSlot(ctx, conn, r.slotName, "pgoutput", pglogrepl.CreateReplicationSlotOptions{})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.DuplicateObject {
+			return nil
+		}
+	}
+	return err
+}
+
+// publish sends the messages of a committed transaction, then marks them
+// published and records the position together
+func (r *OutboxRelay) publish(ctx context.Context, msgs []am.Message, position pglogrepl.LSN) (err error) {
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		if err = r.publisher.Publish(ctx, msg.Subject(), msg); err != nil {
+			return err
+		}
+		ids[i] = msg.ID()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if len(ids) > 0 {
+		if err = NewOutboxStore(r.tableName, tx).markPublished(ctx, tx, ids...); err != nil {
+			return err
+		}
+	}
+
+	return r.savePosition(ctx, tx, position)
+}
+
+func (r *OutboxRelay) loadPosition(ctx context.Context) (pglogrepl.LSN, error) {
+	const query = "SELECT lsn FROM %s WHERE slot_name = $1"
+
+	var lsn string
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf(query, r.positionsTable), r.slotName).Scan(&lsn)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return pglogrepl.ParseLSN(lsn)
+}
+
+func (r *OutboxRelay) savePosition(ctx context.Context, db DB, position pglogrepl.LSN) error {
+	const query = `INSERT INTO %s (slot_name, lsn, updated_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT (slot_name) DO UPDATE SET lsn = EXCLUDED.lsn, updated_at = EXCLUDED.updated_at`
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(query, r.positionsTable), r.slotName, position.String())
+
+	return err
+}
+
+// apply decodes a pgoutput message; the commit is returned once a whole
+// transaction has been read
+func (t *relayTransaction) apply(walData []byte) (*pglogrepl.CommitMessage, error) {
+	logicalMsg, err := pglogrepl.Parse(walData)
+	if err != nil {
+		return nil, err
+	}
+
+	switch logicalMsg := logicalMsg.(type) {
+	case *pglogrepl.RelationMessage:
+		t.relations[logicalMsg.RelationID] = logicalMsg
+	case *pglogrepl.BeginMessage:
+		t.msgs = nil
+	case *pglogrepl.InsertMessage:
+		relation, exists := t.relations[logicalMsg.RelationID]
+		if !exists {
+			return nil, errors.ErrInternal.Msgf("unknown relation id %d", logicalMsg.RelationID)
+		}
+		msg, err := decodeOutboxRow(relation, logicalMsg.Tuple)
+		if err != nil {
+			return nil, err
+		}
+		if am.MessageDeliverAt(msg).IsZero() {
+			t.msgs = append(t.msgs, msg)
+		}
+	case *pglogrepl.CommitMessage:
+		return logicalMsg, nil
+	}
+
+	return nil, nil
+}
+
+func decodeOutboxRow(relation *pglogrepl.RelationMessage, tuple *pglogrepl.TupleData) (am.Message, error) {
+	msg := outboxMessage{}
+
+	for i, column := range tuple.Columns {
+		if i >= len(relation.Columns) || column.DataType != pglogrepl.TupleDataTypeText {
+			continue
+		}
+
+		var err error
+		switch relation.Columns[i].Name {
+		case "id":
+			msg.id = string(column.Data)
+		case "name":
+			msg.name = string(column.Data)
+		case "subject":
+			msg.subject = string(column.Data)
+		case "data":
+			msg.data, err = decodeBytea(column.Data)
+		case "metadata":
+			var metadata []byte
+			if metadata, err = decodeBytea(column.Data); err == nil {
+				err = json.Unmarshal(metadata, &msg.metadata)
+			}
+		case "sent_at":
+			var sentAt pgtype.Timestamptz
+			if err = sentAt.DecodeText(nil, column.Data); err == nil {
+				msg.sentAt = sentAt.Time
+			}
+		case "deliver_at":
+			var deliverAt pgtype.Timestamptz
+			if err = deliverAt.DecodeText(nil, column.Data); err == nil {
+				msg.deliverAt = deliverAt.Time
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding the %s column", relation.Columns[i].Name)
+		}
+	}
+
+	return msg, nil
+}
+
+func decodeBytea(src []byte) ([]byte, error) {
+	var value pgtype.Bytea
+	if err := value.DecodeText(nil, src); err != nil {
+		return nil, err
+	}
+	return value.Bytes, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeOutboxRow(t *testing.T) {
+	relation := &pglogrepl.RelationMessage{
+		Columns: []*pglogrepl.RelationMessageColumn{
+			{Name: "id"}, {Name: "name"}, {Name: "subject"}, {Name: "data"}, {Name: "metadata"},
+			{Name: "sent_at"}, {Name: "published_at"},
+		},
+	}
+	text := func(value string) *pglogrepl.TupleDataColumn {
+		return &pglogrepl.TupleDataColumn{DataType: pglogrepl.TupleDataTypeText, Data: []byte(value)}
+	}
+	tuple := &pglogrepl.TupleData{
+		Columns: []*pglogrepl.TupleDataColumn{
+			text("msg-id"),
+			text("ordering.OrderCreated"),
+			text("ordering.Order"),
+			text(`\x01020304`),
+			text(`\x7b22666f6f223a22626172227d`), // {"foo":"bar"}
+			text("2024-03-01 12:30:00.5+00"),
+			{DataType: pglogrepl.TupleDataTypeNull},
+		},
+	}
+
+	msg, err := decodeOutboxRow(relation, tuple)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "msg-id", msg.ID())
+		assert.Equal(t, "ordering.OrderCreated", msg.MessageName())
+		assert.Equal(t, "ordering.Order", msg.Subject())
+		assert.Equal(t, []byte{1, 2, 3, 4}, msg.Data())
+		assert.Equal(t, "bar", msg.Metadata().Get("foo"))
+		assert.True(t, time.Date(2024, 3, 1, 12, 30, 0, 5e8, time.UTC).Equal(msg.SentAt()))
+	}
+}
@@ -18,21 +18,24 @@ import (
 )
 
 type OutboxStore struct {
-	tableName string
-	db        DB
+	tableName     string
+	db            DB
+	scheduledOnly bool
 }
 
 type outboxMessage struct {
-	id       string
-	name     string
-	subject  string
-	data     []byte
-	metadata ddd.Metadata
-	sentAt   time.Time
+	id        string
+	name      string
+	subject   string
+	data      []byte
+	metadata  ddd.Metadata
+	sentAt    time.Time
+	deliverAt time.Time
+	attempts  int
 }
 
 var _ tm.OutboxStore = (*OutboxStore)(nil)
-var _ am.Message = (*outboxMessage)(nil)
+var _ tm.OutboxMessage = (*outboxMessage)(nil)
 
 func NewOutboxStore(tableName string, db DB) OutboxStore {
 	return OutboxStore{
@@ -41,15 +44,27 @@ func NewOutboxStore(tableName string, db DB) OutboxStore {
 	}
 }
 
+// Scheduled returns a store that only processes the messages saved with a
+// delivery time; the outbox relay leaves those to be polled for
+func (s OutboxStore) Scheduled() OutboxStore {
+	s.scheduledOnly = true
+	return s
+}
+
 func (s OutboxStore) Save(ctx context.Context, msg am.Message) error {
-	const query = "INSERT INTO %s (id, NAME, subject, DATA, metadata, sent_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	const query = "INSERT INTO %s (id, NAME, subject, DATA, metadata, sent_at, deliver_at) VALUES ($1, $2, $3, $4, $5, $6, $7)"
 
 	metadata, err := json.Marshal(msg.Metadata())
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.ExecContext(ctx, s.table(query), msg.ID(), msg.MessageName(), msg.Subject(), msg.Data(), metadata, msg.SentAt())
+	var deliverAt sql.NullTime
+	if at := am.MessageDeliverAt(msg); !at.IsZero() {
+		deliverAt = sql.NullTime{Time: at, Valid: true}
+	}
+
+	_, err = s.db.ExecContext(ctx, s.table(query), msg.ID(), msg.MessageName(), msg.Subject(), msg.Data(), metadata, msg.SentAt(), deliverAt)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -62,10 +77,83 @@ func (s OutboxStore) Save(ctx context.Context, msg am.Message) error {
 	return err
 }
 
-func (s OutboxStore) FindUnpublished(ctx context.Context, limit int) ([]am.Message, error) {
-	const query = "SELECT id, name, subject, data, metadata, sent_at FROM %s WHERE published_at IS NULL LIMIT %d"
+func (s OutboxStore) ProcessUnpublished(ctx context.Context, limit int, fn func(msg tm.OutboxMessage) *tm.OutboxFailure) (processed int, err error) {
+	db := s.db
+
+	// claim the rows in a transaction of our own unless the store is already using one
+	if beginner, ok := s.db.(TxBeginner); ok {
+		var tx *sql.Tx
+		tx, err = beginner.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			if err != nil {
+				_ = tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+		db = tx
+	}
+
+	msgs, err := s.claimUnpublished(ctx, db, limit)
+	if err != nil || len(msgs) == 0 {
+		return 0, err
+	}
+
+	var ids []string
+	for _, msg := range msgs {
+		if failure := fn(msg); failure != nil {
+			if err = s.recordFailure(ctx, db, msg.ID(), failure); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		ids = append(ids, msg.ID())
+	}
+
+	if len(ids) > 0 {
+		if err = s.markPublished(ctx, db, ids...); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(msgs), nil
+}
+
+func (s OutboxStore) Stats(ctx context.Context) (tm.OutboxStats, error) {
+	const query = `SELECT count(*) FILTER (WHERE parked_at IS NULL), count(*) FILTER (WHERE parked_at IS NOT NULL),
+min(coalesce(deliver_at, sent_at)) FILTER (WHERE parked_at IS NULL AND (deliver_at IS NULL OR deliver_at <= CURRENT_TIMESTAMP))
+FROM %s WHERE published_at IS NULL`
+
+	var stats tm.OutboxStats
+	var oldest sql.NullTime
 
-	rows, err := s.db.QueryContext(ctx, s.table(query, limit))
+	err := s.db.QueryRowContext(ctx, s.table(query)).Scan(&stats.Unpublished, &stats.Parked, &oldest)
+	if err != nil {
+		return stats, err
+	}
+	if oldest.Valid {
+		stats.OldestUnpublished = oldest.Time
+	}
+
+	return stats, nil
+}
+
+// claimUnpublished locks the oldest unpublished rows that are due; rows locked
+// by other processors are skipped
+func (s OutboxStore) claimUnpublished(ctx context.Context, db DB, limit int) ([]outboxMessage, error) {
+	const query = `SELECT id, name, subject, data, metadata, sent_at, deliver_at, attempts FROM %s
+WHERE published_at IS NULL AND parked_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= CURRENT_TIMESTAMP)
+AND %s ORDER BY seq LIMIT %d FOR UPDATE SKIP LOCKED`
+
+	deliverable := "(deliver_at IS NULL OR deliver_at <= CURRENT_TIMESTAMP)"
+	if s.scheduledOnly {
+		deliverable = "deliver_at <= CURRENT_TIMESTAMP"
+	}
+
+	rows, err := db.QueryContext(ctx, s.table(query, deliverable, limit))
 	if err != nil {
 		return nil, err
 	}
@@ -76,17 +164,22 @@ func (s OutboxStore) FindUnpublished(ctx context.Context, limit int) ([]am.Messa
 		}
 	}(rows)
 
-	var msgs []am.Message
+	var msgs []outboxMessage
 
 	for rows.Next() {
 		var metadata []byte
+		var deliverAt sql.NullTime
 		msg := outboxMessage{}
-		err = rows.Scan(&msg.id, &msg.name, &msg.subject, &msg.data, &metadata, &msg.sentAt)
+		err = rows.Scan(&msg.id, &msg.name, &msg.subject, &msg.data, &metadata, &msg.sentAt, &deliverAt, &msg.attempts)
 		if err != nil {
 			return msgs, err
 		}
+		msg.deliverAt = deliverAt.Time
 
 		err = json.Unmarshal(metadata, &msg.metadata)
+		if err != nil {
+			return msgs, err
+		}
 
 		msgs = append(msgs, msg)
 	}
@@ -94,7 +187,7 @@ func (s OutboxStore) FindUnpublished(ctx context.Context, limit int) ([]am.Messa
 	return msgs, rows.Err()
 }
 
-func (s OutboxStore) MarkPublished(ctx context.Context, ids ...string) error {
+func (s OutboxStore) markPublished(ctx context.Context, db DB, ids ...string) error {
 	const query = "UPDATE %s SET published_at = CURRENT_TIMESTAMP WHERE id = ANY ($1)"
 
 	msgIDs := &pgtype.TextArray{}
@@ -103,7 +196,26 @@ func (s OutboxStore) MarkPublished(ctx context.Context, ids ...string) error {
 		return err
 	}
 
-	_, err = s.db.ExecContext(ctx, s.table(query), msgIDs)
+	_, err = db.ExecContext(ctx, s.table(query), msgIDs)
+
+	return err
+}
+
+func (s OutboxStore) recordFailure(ctx context.Context, db DB, id string, failure *tm.OutboxFailure) error {
+	const query = `UPDATE %s SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3,
+parked_at = CASE WHEN $4 THEN CURRENT_TIMESTAMP END WHERE id = $1`
+
+	var lastError sql.NullString
+	if failure.Err != nil {
+		lastError = sql.NullString{String: failure.Err.Error(), Valid: true}
+	}
+
+	var nextAttemptAt sql.NullTime
+	if !failure.NextAttemptAt.IsZero() {
+		nextAttemptAt = sql.NullTime{Time: failure.NextAttemptAt, Valid: true}
+	}
+
+	_, err := db.ExecContext(ctx, s.table(query), id, lastError, nextAttemptAt, failure.Parked)
 
 	return err
 }
@@ -120,3 +232,5 @@ func (m outboxMessage) MessageName() string    { return m.name }
 func (m outboxMessage) Data() []byte           { return m.data }
 func (m outboxMessage) Metadata() ddd.Metadata { return m.metadata }
 func (m outboxMessage) SentAt() time.Time      { return m.sentAt }
+func (m outboxMessage) Attempts() int          { return m.attempts }
+func (m outboxMessage) DeliverAt() time.Time   { return m.deliverAt }
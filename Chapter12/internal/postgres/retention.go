@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/config"
+	"eda-in-golang/internal/tm"
+)
+
+var _ tm.RetentionStore = (*OutboxStore)(nil)
+var _ tm.RetentionStore = (*InboxStore)(nil)
+
+// Purge deletes the messages that were published before the cutoff
+func (s OutboxStore) Purge(ctx context.Context, cutoff time.Time, limit int, archive func(msgs []am.Message) error) (int, error) {
+	const query = `DELETE FROM %[1]s WHERE id IN (SELECT id FROM %[1]s WHERE published_at < $1 ORDER BY published_at LIMIT %[2]d FOR UPDATE SKIP LOCKED)
+RETURNING id, name, subject, data, metadata, sent_at`
+
+	return purge(ctx, s.db, fmt.Sprintf(query, s.tableName, limit), archive, cutoff)
+}
+
+// Purge deletes the entries that were received before the cutoff; failed
+// entries are kept so they can still be retried
+func (s InboxStore) Purge(ctx context.Context, cutoff time.Time, limit int, archive func(msgs []am.Message) error) (int, error) {
+	const query = `DELETE FROM %[1]s WHERE (id, handler) IN (SELECT id, handler FROM %[1]s WHERE received_at < $1 AND status <> $2 ORDER BY received_at LIMIT %[2]d FOR UPDATE SKIP LOCKED)
+RETURNING id, name, subject, data, metadata, sent_at`
+
+	return purge(ctx, s.db, fmt.Sprintf(query, s.tableName, limit), archive, cutoff, tm.InboxFailed.String())
+}
+
+// RetentionPolicies purges the outbox and inbox tables as configured
+func RetentionPolicies(cfg config.RetentionConfig, outboxTableName, inboxTableName string, db *sql.DB) []tm.RetentionPolicy {
+	var archiver tm.Archiver
+	if cfg.ArchiveDir != "" {
+		archiver = tm.NewFileArchiver(cfg.ArchiveDir)
+	}
+
+	return []tm.RetentionPolicy{
+		{
+			Name:     outboxTableName,
+			Store:    NewOutboxStore(outboxTableName, db),
+			MaxAge:   cfg.OutboxMaxAge,
+			Archiver: archiver,
+		},
+		{
+			Name:     inboxTableName,
+			Store:    NewInboxStore(inboxTableName, db, db),
+			MaxAge:   cfg.InboxMaxAge,
+			Archiver: archiver,
+		},
+	}
+}
+
+// purge runs the delete query, which returns the deleted messages, and archives them
+func purge(ctx context.Context, db DB, query string, archive func([]am.Message) error, args ...any) (purged int, err error) {
+	// archive and delete the rows together unless the store is already in a transaction
+	if beginner, ok := db.(TxBeginner); ok {
+		var tx *sql.Tx
+		tx, err = beginner.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		defer func() {
+			if err != nil {
+				_ = tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+		db = tx
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing purged rows")
+		}
+	}(rows)
+
+	var msgs []am.Message
+
+	for rows.Next() {
+		var metadata []byte
+		msg := outboxMessage{}
+		err = rows.Scan(&msg.id, &msg.name, &msg.subject, &msg.data, &metadata, &msg.sentAt)
+		if err != nil {
+			return 0, err
+		}
+
+		err = json.Unmarshal(metadata, &msg.metadata)
+		if err != nil {
+			return 0, err
+		}
+
+		msgs = append(msgs, msg)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if archive != nil && len(msgs) > 0 {
+		if err = archive(msgs); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(msgs), nil
+}
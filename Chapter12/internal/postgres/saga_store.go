@@ -2,50 +2,220 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
+	"github.com/stackus/errors"
+
 	"eda-in-golang/internal/registry"
 	"eda-in-golang/internal/sec"
 )
 
 type SagaStore struct {
-	tableName string
-	db        DB
-	registry  registry.Registry
+	tableName    string
+	historyTable string
+	db           DB
+	registry     registry.Registry
 }
 
 var _ sec.SagaStore = (*SagaStore)(nil)
+var _ sec.SagaAdminStore = (*SagaStore)(nil)
 
 func NewSagaStore(tableName string, db DB, registry registry.Registry) SagaStore {
 	return SagaStore{
-		tableName: tableName,
-		db:        db,
-		registry:  registry,
+		tableName:    tableName,
+		historyTable: tableName + "_history",
+		db:           db,
+		registry:     registry,
 	}
 }
 
 func (s SagaStore) Load(ctx context.Context, sagaName, sagaID string) (*sec.SagaContext[[]byte], error) {
-	const query = "SELECT data, step, done, compensating FROM %s WHERE name = $1 AND id = $2"
+	const query = "SELECT data, step, done, compensating, attempts, deadline FROM %s WHERE name = $1 AND id = $2 FOR UPDATE"
 
 	sagaCtx := &sec.SagaContext[[]byte]{
 		ID: sagaID,
 	}
-	err := s.db.QueryRowContext(ctx, s.table(query), sagaName, sagaID).Scan(&sagaCtx.Data, &sagaCtx.Step, &sagaCtx.Done, &sagaCtx.Compensating)
+	var deadline sql.NullTime
+	err := s.db.QueryRowContext(ctx, s.table(query), sagaName, sagaID).Scan(&sagaCtx.Data, &sagaCtx.Step, &sagaCtx.Done, &sagaCtx.Compensating,
+		&sagaCtx.Attempts, &deadline)
+	sagaCtx.Deadline = deadline.Time
 
 	return sagaCtx, err
 }
 
 func (s SagaStore) Save(ctx context.Context, sagaName string, sagaCtx *sec.SagaContext[[]byte]) error {
-	const query = `INSERT INTO %s (name, id, data, step, done, compensating) 
-VALUES ($1, $2, $3, $4, $5, $6) 
+	const query = `INSERT INTO %s (name, id, data, step, done, compensating, attempts, deadline) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
 ON CONFLICT (name, id) DO
-UPDATE SET data = EXCLUDED.data, step = EXCLUDED.step, done = EXCLUDED.done, compensating = EXCLUDED.compensating`
+UPDATE SET data = EXCLUDED.data, step = EXCLUDED.step, done = EXCLUDED.done, compensating = EXCLUDED.compensating,
+attempts = EXCLUDED.attempts, deadline = EXCLUDED.deadline`
+
+	var deadline sql.NullTime
+	if !sagaCtx.Deadline.IsZero() {
+		deadline = sql.NullTime{Time: sagaCtx.Deadline, Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, s.table(query), sagaName, sagaCtx.ID, sagaCtx.Data, sagaCtx.Step, sagaCtx.Done, sagaCtx.Compensating,
+		sagaCtx.Attempts, deadline)
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, sagaName, sagaCtx, deadline)
+}
+
+// record adds the state the saga was saved in to its history
+func (s SagaStore) record(ctx context.Context, sagaName string, sagaCtx *sec.SagaContext[[]byte], deadline sql.NullTime) error {
+	const query = `INSERT INTO %s (name, id, step, done, compensating, attempts, deadline) 
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
-	_, err := s.db.ExecContext(ctx, s.table(query), sagaName, sagaCtx.ID, sagaCtx.Data, sagaCtx.Step, sagaCtx.Done, sagaCtx.Compensating)
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(query, s.historyTable), sagaName, sagaCtx.ID, sagaCtx.Step, sagaCtx.Done, sagaCtx.Compensating,
+		sagaCtx.Attempts, deadline)
 
 	return err
 }
 
+func (s SagaStore) FindExpired(ctx context.Context, sagaName string, limit int) ([]string, error) {
+	const query = "SELECT id FROM %s WHERE name = $1 AND NOT done AND deadline <= CURRENT_TIMESTAMP ORDER BY deadline LIMIT %d"
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(query, s.tableName, limit), sagaName)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing saga rows")
+		}
+	}(rows)
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (s SagaStore) List(ctx context.Context, filter sec.SagaFilter) ([]sec.SagaRecord, error) {
+	const query = `SELECT name, id, data, step, done, compensating, attempts, deadline, updated_at FROM %s
+WHERE ($1 = '' OR name = $1) AND ($2::timestamptz IS NULL OR updated_at < $2) AND %s
+ORDER BY updated_at LIMIT %d`
+
+	var status string
+	switch filter.Status {
+	case sec.SagaRunning:
+		status = "NOT done AND NOT compensating"
+	case sec.SagaCompensating:
+		status = "NOT done AND compensating"
+	case sec.SagaDone:
+		status = "done AND NOT compensating"
+	case sec.SagaCompensated:
+		status = "done AND compensating"
+	case "":
+		status = "TRUE"
+	default:
+		return nil, errors.ErrBadRequest.Msgf("unknown saga status %q", filter.Status)
+	}
+
+	var updatedBefore sql.NullTime
+	if !filter.UpdatedBefore.IsZero() {
+		updatedBefore = sql.NullTime{Time: filter.UpdatedBefore, Valid: true}
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(query, s.tableName, status, filter.Limit), filter.Name, updatedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing saga rows")
+		}
+	}(rows)
+
+	var sagas []sec.SagaRecord
+
+	for rows.Next() {
+		saga, err := s.scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, saga)
+	}
+
+	return sagas, rows.Err()
+}
+
+func (s SagaStore) Find(ctx context.Context, sagaName, sagaID string) (sec.SagaRecord, error) {
+	const query = `SELECT name, id, data, step, done, compensating, attempts, deadline, updated_at FROM %s
+WHERE name = $1 AND id = $2`
+
+	saga, err := s.scanRecord(s.db.QueryRowContext(ctx, s.table(query), sagaName, sagaID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return saga, errors.ErrNotFound.Msgf("saga %s %s not found", sagaName, sagaID)
+		}
+		return saga, err
+	}
+
+	return saga, nil
+}
+
+func (s SagaStore) History(ctx context.Context, sagaName, sagaID string) ([]sec.SagaStepRecord, error) {
+	const query = `SELECT step, done, compensating, attempts, deadline, recorded_at FROM %s
+WHERE name = $1 AND id = $2 ORDER BY seq`
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(query, s.historyTable), sagaName, sagaID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			err = errors.Wrap(err, "closing saga history rows")
+		}
+	}(rows)
+
+	var history []sec.SagaStepRecord
+
+	for rows.Next() {
+		var record sec.SagaStepRecord
+		var done, compensating bool
+		var deadline sql.NullTime
+		if err = rows.Scan(&record.Step, &done, &compensating, &record.Attempts, &deadline, &record.RecordedAt); err != nil {
+			return nil, err
+		}
+		record.Status = sec.NewSagaStatus(done, compensating)
+		record.Deadline = deadline.Time
+		history = append(history, record)
+	}
+
+	return history, rows.Err()
+}
+
+func (s SagaStore) scanRecord(row interface{ Scan(dest ...any) error }) (sec.SagaRecord, error) {
+	var saga sec.SagaRecord
+	var done, compensating bool
+	var deadline sql.NullTime
+
+	err := row.Scan(&saga.Name, &saga.ID, &saga.Data, &saga.Step, &done, &compensating, &saga.Attempts, &deadline, &saga.UpdatedAt)
+	if err != nil {
+		return saga, err
+	}
+
+	saga.Status = sec.NewSagaStatus(done, compensating)
+	saga.Deadline = deadline.Time
+
+	return saga, nil
+}
+
 func (s SagaStore) table(query string) string {
 	return fmt.Sprintf(query, s.tableName)
 }
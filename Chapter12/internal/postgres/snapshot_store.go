@@ -4,27 +4,60 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/stackus/errors"
 
+	"eda-in-golang/internal/config"
 	"eda-in-golang/internal/es"
 	"eda-in-golang/internal/registry"
 )
 
-type SnapshotStore struct {
-	es.AggregateStore
-	tableName string
-	db        DB
-	registry  registry.Registry
-}
+type (
+	SnapshotStoreOption interface {
+		configureSnapshotStore(*SnapshotStore)
+	}
+
+	snapshotStrategy struct {
+		strategy es.SnapshotStrategy
+	}
+
+	aggregateSnapshotStrategy struct {
+		aggregateName string
+		strategy      es.SnapshotStrategy
+	}
+
+	asyncSnapshots struct {
+		snapshotter *Snapshotter
+	}
+
+	SnapshotStore struct {
+		es.AggregateStore
+		tableName   string
+		db          DB
+		registry    registry.Registry
+		fallback    es.SnapshotStrategy
+		strategies  map[string]es.SnapshotStrategy
+		snapshotter *Snapshotter
+		// last holds the snapshots the aggregates were loaded from
+		last map[string]es.LastSnapshot
+	}
+)
 
 var _ es.AggregateStore = (*SnapshotStore)(nil)
 
-func NewSnapshotStore(tableName string, db DB, registry registry.Registry) es.AggregateStoreMiddleware {
+func NewSnapshotStore(tableName string, db DB, registry registry.Registry, options ...SnapshotStoreOption) es.AggregateStoreMiddleware {
 	snapshots := SnapshotStore{
-		tableName: tableName,
-		db:        db,
-		registry:  registry,
+		tableName:  tableName,
+		db:         db,
+		registry:   registry,
+		fallback:   es.SnapshotEvery(3),
+		strategies: make(map[string]es.SnapshotStrategy),
+		last:       make(map[string]es.LastSnapshot),
+	}
+
+	for _, option := range options {
+		option.configureSnapshotStore(&snapshots)
 	}
 
 	return func(store es.AggregateStore) es.AggregateStore {
@@ -33,21 +66,77 @@ func NewSnapshotStore(tableName string, db DB, registry registry.Registry) es.Ag
 	}
 }
 
+// SnapshotStrategy decides when aggregates without their own strategy are
+// snapshotted; the default is every 3 events
+func SnapshotStrategy(strategy es.SnapshotStrategy) SnapshotStoreOption {
+	return snapshotStrategy{strategy: strategy}
+}
+
+// AggregateSnapshotStrategy decides when the named aggregate is snapshotted
+func AggregateSnapshotStrategy(aggregateName string, strategy es.SnapshotStrategy) SnapshotStoreOption {
+	return aggregateSnapshotStrategy{
+		aggregateName: aggregateName,
+		strategy:      strategy,
+	}
+}
+
+// SnapshotStrategies returns the configured strategies of the named aggregates;
+// aggregates without their own strategy are snapshotted every Every events
+func SnapshotStrategies(cfg config.SnapshotConfig, aggregateNames ...string) ([]SnapshotStoreOption, error) {
+	options := []SnapshotStoreOption{
+		SnapshotStrategy(es.SnapshotEvery(cfg.Every)),
+	}
+
+	for _, aggregateName := range aggregateNames {
+		spec, exists := cfg.Aggregates[aggregateName]
+		if !exists {
+			continue
+		}
+		strategy, err := es.ParseSnapshotStrategy(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "snapshot strategy of %s", aggregateName)
+		}
+		options = append(options, AggregateSnapshotStrategy(aggregateName, strategy))
+	}
+
+	return options, nil
+}
+
+// AsyncSnapshots leaves the snapshots to be taken by the snapshotter once the
+// events have been committed, instead of as part of the save
+func AsyncSnapshots(snapshotter *Snapshotter) SnapshotStoreOption {
+	return asyncSnapshots{snapshotter: snapshotter}
+}
+
+func (o snapshotStrategy) configureSnapshotStore(s *SnapshotStore) {
+	s.fallback = o.strategy
+}
+
+func (o aggregateSnapshotStrategy) configureSnapshotStore(s *SnapshotStore) {
+	s.strategies[o.aggregateName] = o.strategy
+}
+
+func (o asyncSnapshots) configureSnapshotStore(s *SnapshotStore) {
+	s.snapshotter = o.snapshotter
+}
+
 func (s SnapshotStore) Load(ctx context.Context, aggregate es.EventSourcedAggregate) error {
-	const query = `SELECT stream_version, snapshot_name, snapshot_data FROM %s WHERE stream_id = $1 AND stream_name = $2 LIMIT 1`
+	const query = `SELECT stream_version, snapshot_name, snapshot_data, schema_version, updated_at FROM %s WHERE stream_id = $1 AND stream_name = $2 LIMIT 1`
 
-	var entityVersion int
+	var entityVersion, schemaVersion int
 	var snapshotName string
 	var snapshotData []byte
+	var takenAt time.Time
 
-	if err := s.db.QueryRowContext(ctx, s.table(query), aggregate.ID(), aggregate.AggregateName()).Scan(&entityVersion, &snapshotName, &snapshotData); err != nil {
+	delete(s.last, s.key(aggregate))
+	if err := s.db.QueryRowContext(ctx, s.table(query), aggregate.ID(), aggregate.AggregateName()).Scan(&entityVersion, &snapshotName, &snapshotData, &schemaVersion, &takenAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return s.AggregateStore.Load(ctx, aggregate)
 		}
 		return err
 	}
 
-	v, err := s.registry.Deserialize(snapshotName, snapshotData, registry.ValidateImplements((*es.Snapshot)(nil)))
+	v, err := s.registry.DeserializeSchema(snapshotName, registry.Schema{Version: schemaVersion}, snapshotData, registry.ValidateImplements((*es.Snapshot)(nil)))
 	if err != nil {
 		return err
 	}
@@ -55,24 +144,54 @@ func (s SnapshotStore) Load(ctx context.Context, aggregate es.EventSourcedAggreg
 	if err := es.LoadSnapshot(aggregate, v.(es.Snapshot), entityVersion); err != nil {
 		return err
 	}
+	s.last[s.key(aggregate)] = es.LastSnapshot{Version: entityVersion, TakenAt: takenAt}
 
 	return s.AggregateStore.Load(ctx, aggregate)
 }
 
 func (s SnapshotStore) Save(ctx context.Context, aggregate es.EventSourcedAggregate) error {
-	const query = `INSERT INTO %s (stream_id, stream_name, stream_version, snapshot_name, snapshot_data) 
-VALUES ($1, $2, $3, $4, $5) 
-ON CONFLICT (stream_id, stream_name) DO
-UPDATE SET stream_version = EXCLUDED.stream_version, snapshot_name = EXCLUDED.snapshot_name, snapshot_data = EXCLUDED.snapshot_data`
-
 	if err := s.AggregateStore.Save(ctx, aggregate); err != nil {
 		return err
 	}
 
-	if !s.shouldSnapshot(aggregate) {
+	if !s.strategy(aggregate.AggregateName()).ShouldSnapshot(aggregate, s.last[s.key(aggregate)]) {
 		return nil
 	}
 
+	if s.snapshotter != nil {
+		s.snapshotter.Request(aggregate.AggregateName(), aggregate.ID(), aggregate.PendingVersion())
+	} else if err := saveSnapshot(ctx, s.db, s.tableName, s.registry, aggregate, aggregate.PendingVersion()); err != nil {
+		return err
+	}
+	s.last[s.key(aggregate)] = es.LastSnapshot{Version: aggregate.PendingVersion(), TakenAt: time.Now()}
+
+	return nil
+}
+
+func (s SnapshotStore) strategy(aggregateName string) es.SnapshotStrategy {
+	if strategy, exists := s.strategies[aggregateName]; exists {
+		return strategy
+	}
+	return s.fallback
+}
+
+func (s SnapshotStore) key(aggregate es.EventSourcedAggregate) string {
+	return aggregate.AggregateName() + ":" + aggregate.ID()
+}
+
+func (s SnapshotStore) table(query string) string {
+	return fmt.Sprintf(query, s.tableName)
+}
+
+// saveSnapshot saves the snapshot of the aggregate at the version unless a
+// later snapshot has already been saved
+func saveSnapshot(ctx context.Context, db DB, tableName string, reg registry.Registry, aggregate es.EventSourcedAggregate, version int) error {
+	const query = `INSERT INTO %[1]s (stream_id, stream_name, stream_version, snapshot_name, snapshot_data, schema_version) 
+VALUES ($1, $2, $3, $4, $5, $6) 
+ON CONFLICT (stream_id, stream_name) DO
+UPDATE SET stream_version = EXCLUDED.stream_version, snapshot_name = EXCLUDED.snapshot_name, snapshot_data = EXCLUDED.snapshot_data, schema_version = EXCLUDED.schema_version
+WHERE %[1]s.stream_version < EXCLUDED.stream_version`
+
 	sser, ok := aggregate.(es.Snapshotter)
 	if !ok {
 		return fmt.Errorf("%T does not implelement es.Snapshotter", aggregate)
@@ -80,27 +199,17 @@ UPDATE SET stream_version = EXCLUDED.stream_version, snapshot_name = EXCLUDED.sn
 
 	snapshot := sser.ToSnapshot()
 
-	data, err := s.registry.Serialize(snapshot.SnapshotName(), snapshot)
+	data, err := reg.Serialize(snapshot.SnapshotName(), snapshot)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.ExecContext(ctx, s.table(query), aggregate.ID(), aggregate.AggregateName(), aggregate.PendingVersion(), snapshot.SnapshotName(), data)
-
-	return err
-}
-
-// TODO use injected & configurable strategies
-func (SnapshotStore) shouldSnapshot(aggregate es.EventSourcedAggregate) bool {
-	var maxChanges = 3 // low for demonstration; production envs should use higher values 50, 75, 100...
-	var pendingVersion = aggregate.PendingVersion()
-	var pendingChanges = len(aggregate.Events())
+	schema, err := reg.Schema(snapshot.SnapshotName())
+	if err != nil {
+		return err
+	}
 
-	return pendingVersion >= maxChanges && ((pendingChanges >= maxChanges) ||
-		(pendingVersion%maxChanges < pendingChanges) ||
-		(pendingVersion%maxChanges == 0))
-}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(query, tableName), aggregate.ID(), aggregate.AggregateName(), version, snapshot.SnapshotName(), data, schema.Version)
 
-func (s SnapshotStore) table(query string) string {
-	return fmt.Sprintf(query, s.tableName)
+	return err
 }
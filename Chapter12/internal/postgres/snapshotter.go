@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/es"
+	"eda-in-golang/internal/registry"
+)
+
+const snapshotQueueSize = 256
+const snapshotRetryDelay = 250 * time.Millisecond
+const snapshotMaxAttempts = 5
+
+type (
+	snapshotRequest struct {
+		aggregateName string
+		aggregateID   string
+		version       int
+		attempts      int
+	}
+
+	// Snapshotter takes snapshots in the background for snapshot stores using
+	// AsyncSnapshots. The aggregates are loaded again outside of the command
+	// transaction, so only events that have been committed are snapshotted.
+	Snapshotter struct {
+		tableName string
+		db        DB
+		registry  registry.Registry
+		events    es.AggregateStore
+		requests  chan snapshotRequest
+		logger    zerolog.Logger
+	}
+)
+
+// NewSnapshotter takes snapshots into the table with aggregates loaded from
+// their latest snapshot and the events store, which should not be part of any
+// command transaction
+func NewSnapshotter(tableName string, db DB, registry registry.Registry, events es.AggregateStore, logger zerolog.Logger) *Snapshotter {
+	return &Snapshotter{
+		tableName: tableName,
+		db:        db,
+		registry:  registry,
+		events:    events,
+		requests:  make(chan snapshotRequest, snapshotQueueSize),
+		logger:    logger,
+	}
+}
+
+// Request asks for a snapshot of the aggregate once the version has been
+// committed; the request is dropped when the snapshotter is too busy
+func (s *Snapshotter) Request(aggregateName, aggregateID string, version int) {
+	s.enqueue(snapshotRequest{
+		aggregateName: aggregateName,
+		aggregateID:   aggregateID,
+		version:       version,
+	})
+}
+
+// Run takes the requested snapshots until the context is done; it is meant to
+// be added to the waiter
+func (s *Snapshotter) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case request := <-s.requests:
+			if err := s.snapshot(ctx, request); err != nil && ctx.Err() == nil {
+				s.logger.Error().Err(err).
+					Str("AggregateName", request.aggregateName).
+					Str("AggregateID", request.aggregateID).
+					Msg("failed to snapshot the aggregate")
+			}
+		}
+	}
+}
+
+func (s *Snapshotter) snapshot(ctx context.Context, request snapshotRequest) error {
+	v, err := s.registry.Build(
+		request.aggregateName,
+		ddd.SetID(request.aggregateID),
+		ddd.SetName(request.aggregateName),
+	)
+	if err != nil {
+		return err
+	}
+
+	aggregate, ok := v.(es.EventSourcedAggregate)
+	if !ok {
+		return fmt.Errorf("%T is not an event sourced aggregate", v)
+	}
+
+	// a snapshot store remembers the snapshots it loaded, so one is not kept
+	// around for the life of the snapshotter
+	store := es.AggregateStoreWithMiddleware(s.events, NewSnapshotStore(s.tableName, s.db, s.registry))
+	if err = store.Load(ctx, aggregate); err != nil {
+		return err
+	}
+
+	if aggregate.Version() < request.version {
+		// the command saving the events has yet to commit, or it rolled back
+		request.attempts++
+		if request.attempts < snapshotMaxAttempts {
+			time.AfterFunc(snapshotRetryDelay, func() { s.enqueue(request) })
+		}
+		return nil
+	}
+
+	return saveSnapshot(ctx, s.db, s.tableName, s.registry, aggregate, aggregate.Version())
+}
+
+func (s *Snapshotter) enqueue(request snapshotRequest) {
+	select {
+	case s.requests <- request:
+	default:
+		// a snapshot will be requested again as the aggregate changes
+	}
+}
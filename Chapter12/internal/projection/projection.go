@@ -0,0 +1,71 @@
+package projection
+
+import (
+	"context"
+	"time"
+
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/ddd"
+)
+
+const (
+	StatusRebuilding Status = "rebuilding"
+	StatusLive       Status = "live"
+)
+
+// ErrCheckpointMoved is returned when another runner of the projection has moved
+// the checkpoint; the work that was to be saved with it must be rolled back
+var ErrCheckpointMoved = errors.Wrap(errors.ErrAborted, "the checkpoint was moved by another runner")
+
+type (
+	// Projector keeps a read model up to date with the events it handles
+	//
+	// The events are read from the event store of the module the projector
+	// belongs to. Read models built from the integration events of other
+	// modules, like the orders of the search module, are not projections; those
+	// events are only kept by the message stream for a while, so they could not
+	// be rebuilt from the start.
+	Projector interface {
+		ddd.EventHandler[ddd.AggregateEvent]
+		// Events are the names of the events the projector handles
+		Events() []string
+		// Reset removes everything projected so far ahead of a rebuild; the read
+		// model is empty until the rebuild catches up
+		Reset(ctx context.Context) error
+	}
+
+	Status string
+
+	// Checkpoint is how far a projection has got through the event stream
+	Checkpoint struct {
+		Name string
+		// Position is the position of the last event projected
+		Position int64
+		Status   Status
+		// Handled is the number of events projected since the last rebuild
+		Handled          int64
+		RebuildRequested bool
+		StartedAt        time.Time
+		UpdatedAt        time.Time
+	}
+
+	CheckpointStore interface {
+		// Load returns an ErrNotFound error when the projection has never been built;
+		// within a transaction the checkpoint is locked until it ends
+		Load(ctx context.Context, name string) (Checkpoint, error)
+		// Save records the progress of a projection from the previous checkpoint;
+		// ErrCheckpointMoved is returned when the checkpoint is no longer there
+		Save(ctx context.Context, previous, checkpoint Checkpoint) error
+		// StartRebuild moves the projection back to the start of the stream and
+		// clears any requested rebuild
+		StartRebuild(ctx context.Context, name string) (Checkpoint, error)
+		// RequestRebuild asks the running projection to rebuild itself
+		RequestRebuild(ctx context.Context, name string) error
+		List(ctx context.Context) ([]Checkpoint, error)
+	}
+
+	// WorkFunc runs fn in a transaction with the projector and the checkpoints
+	// that use it, so each event and its checkpoint are saved together
+	WorkFunc func(ctx context.Context, fn func(ctx context.Context, projector Projector, checkpoints CheckpointStore) error) error
+)
@@ -0,0 +1,228 @@
+package projection
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/es"
+)
+
+const defaultRebuildCheckInterval = 2 * time.Second
+const projectionRetryInterval = 5 * time.Second
+
+type (
+	ProjectionOption interface {
+		configureProjection(*Projection)
+	}
+
+	// RebuildCheckInterval is how often a running projection looks for a
+	// requested rebuild
+	RebuildCheckInterval time.Duration
+
+	subscriptionOptions []es.CatchUpSubscriptionOption
+
+	// Projection feeds the events read from the event stream to a projector,
+	// saving its checkpoint with each event; a projection that has never been
+	// built, or that has been asked to rebuild, is reset and built from the
+	// start of the stream
+	//
+	// Each checkpoint is saved only from the one before it, so when several
+	// instances run the same projection every event is projected once; the
+	// others roll back and start again from the checkpoint.
+	Projection struct {
+		name          string
+		reader        es.EventStreamReader
+		work          WorkFunc
+		subOptions    []es.CatchUpSubscriptionOption
+		checkInterval time.Duration
+		logger        zerolog.Logger
+	}
+)
+
+func NewProjection(name string, reader es.EventStreamReader, work WorkFunc, logger zerolog.Logger, options ...ProjectionOption) *Projection {
+	p := &Projection{
+		name:          name,
+		reader:        reader,
+		work:          work,
+		checkInterval: defaultRebuildCheckInterval,
+		logger:        logger,
+	}
+
+	for _, option := range options {
+		option.configureProjection(p)
+	}
+
+	return p
+}
+
+// SubscriptionOptions configures the subscription to the event stream, e.g.
+// with es.StreamNotifications
+func SubscriptionOptions(options ...es.CatchUpSubscriptionOption) ProjectionOption {
+	return subscriptionOptions(options)
+}
+
+func (i RebuildCheckInterval) configureProjection(p *Projection) {
+	if i > 0 {
+		p.checkInterval = time.Duration(i)
+	}
+}
+
+func (o subscriptionOptions) configureProjection(p *Projection) {
+	p.subOptions = append(p.subOptions, o...)
+}
+
+// Run keeps the projection up to date until the context is done; it is meant
+// to be added to the waiter
+func (p *Projection) Run(ctx context.Context) error {
+	for {
+		err := p.project(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			// a rebuild was requested
+			continue
+		}
+
+		if errors.Is(err, ErrCheckpointMoved) {
+			p.logger.Warn().Msgf("the %s projection is being run elsewhere; starting again from its checkpoint", p.name)
+		} else {
+			p.logger.Error().Err(err).Msgf("the %s projection failed", p.name)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(projectionRetryInterval):
+		}
+	}
+}
+
+func (p *Projection) project(ctx context.Context) error {
+	var checkpoint Checkpoint
+	handles := make(map[string]struct{})
+	err := p.work(ctx, func(ctx context.Context, projector Projector, checkpoints CheckpointStore) (err error) {
+		for _, name := range projector.Events() {
+			handles[name] = struct{}{}
+		}
+
+		checkpoint, err = checkpoints.Load(ctx, p.name)
+		if errors.Is(err, errors.ErrNotFound) || (err == nil && checkpoint.RebuildRequested) {
+			p.logger.Info().Msgf("rebuilding the %s projection", p.name)
+			if err = projector.Reset(ctx); err != nil {
+				return err
+			}
+			checkpoint, err = checkpoints.StartRebuild(ctx, p.name)
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	sub := es.NewCatchUpSubscription(p.reader, ddd.EventHandlerFunc[es.StreamEvent](func(ctx context.Context, event es.StreamEvent) error {
+		if _, exists := handles[event.EventName()]; !exists {
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		next := checkpoint
+		next.Position = event.GlobalPosition()
+		next.Handled++
+		err := p.work(ctx, func(ctx context.Context, projector Projector, checkpoints CheckpointStore) error {
+			if err := projector.HandleEvent(ctx, event); err != nil {
+				return err
+			}
+			return checkpoints.Save(ctx, checkpoint, next)
+		})
+		if err != nil {
+			return err
+		}
+		checkpoint = next
+
+		return nil
+	}), p.subOptions...)
+
+	after := checkpoint.Position
+	go p.watch(subCtx, cancel, sub, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if checkpoint.Status == StatusLive {
+			return true
+		}
+
+		next := checkpoint
+		next.Status = StatusLive
+		err := p.work(subCtx, func(ctx context.Context, _ Projector, checkpoints CheckpointStore) error {
+			return checkpoints.Save(ctx, checkpoint, next)
+		})
+		if err != nil {
+			p.logger.Error().Err(err).Msgf("failed to mark the %s projection as live", p.name)
+			return false
+		}
+		checkpoint = next
+		p.logger.Info().Msgf("the %s projection is live after %d events", p.name, next.Handled)
+
+		return true
+	})
+
+	err = sub.Subscribe(subCtx, after)
+	if subCtx.Err() != nil && ctx.Err() == nil {
+		// stopped for a rebuild
+		return nil
+	}
+
+	return err
+}
+
+// watch marks the projection live once the subscription has caught up and
+// stops the subscription when a rebuild is requested
+func (p *Projection) watch(ctx context.Context, stop context.CancelFunc, sub *es.CatchUpSubscription, goLive func() bool) {
+	ticker := time.NewTicker(p.checkInterval)
+	defer ticker.Stop()
+
+	caughtUp := sub.CaughtUp()
+	live := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-caughtUp:
+			caughtUp = nil
+			live = goLive()
+		case <-ticker.C:
+			if caughtUp == nil && !live {
+				live = goLive()
+			}
+
+			var requested bool
+			err := p.work(ctx, func(ctx context.Context, _ Projector, checkpoints CheckpointStore) error {
+				checkpoint, err := checkpoints.Load(ctx, p.name)
+				requested = checkpoint.RebuildRequested
+				return err
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					p.logger.Error().Err(err).Msgf("failed to check the %s projection for a rebuild", p.name)
+				}
+				continue
+			}
+			if requested {
+				stop()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stackus/errors"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/es"
+)
+
+type testStreamEvent struct {
+	ddd.AggregateEvent
+	position int64
+}
+
+func (e testStreamEvent) GlobalPosition() int64 { return e.position }
+
+type testEventStream struct {
+	events []es.StreamEvent
+}
+
+func (s *testEventStream) append(eventName string) {
+	agg := es.NewAggregate(fmt.Sprintf("agg-%d", len(s.events)), "projection.TestAggregate")
+	agg.AddEvent(eventName, nil)
+	s.events = append(s.events, testStreamEvent{
+		AggregateEvent: agg.Events()[0],
+		position:       int64(len(s.events) + 1),
+	})
+}
+
+func (s *testEventStream) ReadEvents(_ context.Context, filter es.EventStreamFilter) ([]es.StreamEvent, error) {
+	var events []es.StreamEvent
+	for _, event := range s.events {
+		if event.GlobalPosition() <= filter.After {
+			continue
+		}
+		if len(events) == filter.Limit {
+			break
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+type testProjector struct {
+	projected []int64
+	resets    int
+}
+
+func (p *testProjector) Events() []string { return []string{"projection.Tracked"} }
+
+func (p *testProjector) Reset(context.Context) error {
+	p.resets++
+	p.projected = nil
+	return nil
+}
+
+func (p *testProjector) HandleEvent(_ context.Context, event ddd.AggregateEvent) error {
+	p.projected = append(p.projected, event.(es.StreamEvent).GlobalPosition())
+	return nil
+}
+
+type testCheckpointStore struct {
+	checkpoints map[string]Checkpoint
+}
+
+func (s *testCheckpointStore) Load(_ context.Context, name string) (Checkpoint, error) {
+	checkpoint, exists := s.checkpoints[name]
+	if !exists {
+		return checkpoint, errors.ErrNotFound
+	}
+	return checkpoint, nil
+}
+
+func (s *testCheckpointStore) Save(_ context.Context, previous, checkpoint Checkpoint) error {
+	current := s.checkpoints[checkpoint.Name]
+	if current.Position != previous.Position || current.Handled != previous.Handled || current.StartedAt != previous.StartedAt {
+		return ErrCheckpointMoved
+	}
+	checkpoint.RebuildRequested = current.RebuildRequested
+	s.checkpoints[checkpoint.Name] = checkpoint
+	return nil
+}
+
+func (s *testCheckpointStore) StartRebuild(_ context.Context, name string) (Checkpoint, error) {
+	s.checkpoints[name] = Checkpoint{Name: name, Status: StatusRebuilding}
+	return s.checkpoints[name], nil
+}
+
+func (s *testCheckpointStore) RequestRebuild(_ context.Context, name string) error {
+	checkpoint := s.checkpoints[name]
+	checkpoint.RebuildRequested = true
+	s.checkpoints[name] = checkpoint
+	return nil
+}
+
+func (s *testCheckpointStore) List(context.Context) ([]Checkpoint, error) { return nil, nil }
+
+func TestProjection_Run(t *testing.T) {
+	stream := &testEventStream{}
+	for i := 0; i < 3; i++ {
+		stream.append("projection.Tracked")
+		stream.append("projection.Ignored")
+	}
+
+	var mu sync.Mutex
+	projector := &testProjector{}
+	checkpoints := &testCheckpointStore{checkpoints: map[string]Checkpoint{}}
+	work := func(ctx context.Context, fn func(context.Context, Projector, CheckpointStore) error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return fn(ctx, projector, checkpoints)
+	}
+	inspect := func(fn func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		fn()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewProjection("test", stream, work, zerolog.Nop(),
+		RebuildCheckInterval(10*time.Millisecond),
+		SubscriptionOptions(es.StreamPollingInterval(10*time.Millisecond)),
+	)
+	done := make(chan error)
+	go func() { done <- p.Run(ctx) }()
+
+	assert.Eventually(t, func() (live bool) {
+		inspect(func() { live = checkpoints.checkpoints["test"].Status == StatusLive })
+		return
+	}, time.Second, 10*time.Millisecond)
+	inspect(func() {
+		assert.Equal(t, []int64{1, 3, 5}, projector.projected)
+		assert.Equal(t, Checkpoint{Name: "test", Position: 5, Status: StatusLive, Handled: 3}, checkpoints.checkpoints["test"])
+		assert.NoError(t, checkpoints.RequestRebuild(ctx, "test"))
+	})
+
+	assert.Eventually(t, func() (rebuilt bool) {
+		inspect(func() {
+			checkpoint := checkpoints.checkpoints["test"]
+			rebuilt = projector.resets == 2 && checkpoint.Status == StatusLive && !checkpoint.RebuildRequested
+		})
+		return
+	}, time.Second, 10*time.Millisecond)
+	inspect(func() {
+		assert.Equal(t, []int64{1, 3, 5}, projector.projected)
+	})
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -7,6 +7,29 @@ import (
 type (
 	UnregisteredKey      string
 	AlreadyRegisteredKey string
+
+	UnsupportedContentType struct {
+		Key         string
+		ContentType string
+	}
+
+	ConflictingRegistration struct {
+		Key            string
+		ContentType    string
+		Type           string
+		RegisteredType string
+	}
+
+	AlreadyRegisteredUpcaster struct {
+		Key     string
+		Version int
+	}
+
+	UpcastFailed struct {
+		Key     string
+		Version int
+		Err     error
+	}
 )
 
 func (key UnregisteredKey) Error() string {
@@ -16,3 +39,24 @@ func (key UnregisteredKey) Error() string {
 func (key AlreadyRegisteredKey) Error() string {
 	return fmt.Sprintf("something with the key `%s` has already been registered", string(key))
 }
+
+func (e UnsupportedContentType) Error() string {
+	return fmt.Sprintf("no serde for the content type `%s` has been registered with the key `%s`", e.ContentType, e.Key)
+}
+
+func (e ConflictingRegistration) Error() string {
+	return fmt.Sprintf("cannot register `%s` with the key `%s` for the content type `%s`; `%s` has already been registered with the key",
+		e.Type, e.Key, e.ContentType, e.RegisteredType)
+}
+
+func (e AlreadyRegisteredUpcaster) Error() string {
+	return fmt.Sprintf("an upcaster from version %d has already been registered with the key `%s`", e.Version, e.Key)
+}
+
+func (e UpcastFailed) Error() string {
+	return fmt.Sprintf("upcasting `%s` from version %d: %s", e.Key, e.Version, e.Err)
+}
+
+func (e UpcastFailed) Unwrap() error {
+	return e.Err
+}
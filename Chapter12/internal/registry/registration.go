@@ -5,7 +5,7 @@ import (
 	"reflect"
 )
 
-func Register(reg Registry, v Registrable, s Serializer, d Deserializer, os []BuildOption) error {
+func Register(reg Registry, v Registrable, contentType string, s Serializer, d Deserializer, os []BuildOption) error {
 	var key string
 
 	t := reflect.TypeOf(v)
@@ -19,22 +19,22 @@ func Register(reg Registry, v Registrable, s Serializer, d Deserializer, os []Bu
 		key = v.Key()
 	}
 
-	return RegisterKey(reg, key, v, s, d, os)
+	return RegisterKey(reg, key, v, contentType, s, d, os)
 }
 
-func RegisterKey(reg Registry, key string, v interface{}, s Serializer, d Deserializer, os []BuildOption) error {
+func RegisterKey(reg Registry, key string, v interface{}, contentType string, s Serializer, d Deserializer, os []BuildOption) error {
 	t := reflect.TypeOf(v)
 
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
-	return reg.register(key, func() interface{} {
+	return reg.register(key, contentType, func() interface{} {
 		return reflect.New(t).Interface()
 	}, s, d, os)
 }
 
-func RegisterFactory(reg Registry, key string, fn func() interface{}, s Serializer, d Deserializer,
+func RegisterFactory(reg Registry, key string, fn func() interface{}, contentType string, s Serializer, d Deserializer,
 	os []BuildOption,
 ) error {
 	if v := fn(); v == nil {
@@ -45,5 +45,5 @@ func RegisterFactory(reg Registry, key string, fn func() interface{}, s Serializ
 		return fmt.Errorf("factory for item `%s` does not return a pointer receiver", key)
 	}
 
-	return reg.register(key, fn, s, d, os)
+	return reg.register(key, contentType, fn, s, d, os)
 }
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"reflect"
 	"sync"
 )
 
@@ -9,9 +10,25 @@ type (
 		Key() string
 	}
 
+	// Versioned may be implemented by registered values to declare the version
+	// of their schema; values that do not implement it are at version 1
+	Versioned interface {
+		SchemaVersion() int
+	}
+
 	Serializer   func(v interface{}) ([]byte, error)
 	Deserializer func(d []byte, v interface{}) error
 
+	// Upcaster converts data serialized using one version of a schema into the
+	// data for the next version
+	Upcaster func(data []byte) ([]byte, error)
+
+	// Schema describes how a registered value is serialized
+	Schema struct {
+		ContentType string
+		Version     int
+	}
+
 	Registry interface {
 		Serialize(key string, v interface{}) ([]byte, error)
 		MustSerialize(key string, v interface{}) []byte
@@ -19,19 +36,40 @@ type (
 		MustBuild(key string, options ...BuildOption) interface{}
 		Deserialize(key string, data []byte, options ...BuildOption) (interface{}, error)
 		MustDeserialize(key string, data []byte, options ...BuildOption) interface{}
-		register(key string, fn func() interface{}, s Serializer, d Deserializer, o []BuildOption) error
+		// Schema returns the content type and version that Serialize uses for the key
+		Schema(key string) (Schema, error)
+		// DeserializeSchema deserializes data that was serialized using the schema;
+		// data from older versions is upcast first. An empty content type or zero
+		// version is taken to be the one Serialize uses
+		DeserializeSchema(key string, schema Schema, data []byte, options ...BuildOption) (interface{}, error)
+		// RegisterUpcaster adds the upcaster for data of the key from the version
+		// to the next; versions without an upcaster are left as they are
+		RegisterUpcaster(key string, fromVersion int, fn Upcaster) error
+		// UseSerializer makes Serialize use the serde registered for the key with
+		// the content type in place of the first one registered
+		UseSerializer(key, contentType string) error
+		register(key, contentType string, fn func() interface{}, s Serializer, d Deserializer, o []BuildOption) error
 	}
 )
 
-type registered struct {
-	factory      func() interface{}
+type serde struct {
 	serializer   Serializer
 	deserializer Deserializer
-	options      []BuildOption
+}
+
+// registered keeps every serde registered for a key; the first one registered
+// is used to serialize unless another is chosen with UseSerializer
+type registered struct {
+	factory     func() interface{}
+	contentType string
+	version     int
+	serdes      map[string]serde
+	options     []BuildOption
 }
 
 type registry struct {
-	registered map[string]registered
+	registered map[string]*registered
+	upcasters  map[string]map[int]Upcaster
 	mu         sync.RWMutex
 }
 
@@ -39,16 +77,17 @@ var _ Registry = (*registry)(nil)
 
 func New() *registry {
 	return &registry{
-		registered: make(map[string]registered),
+		registered: make(map[string]*registered),
+		upcasters:  make(map[string]map[int]Upcaster),
 	}
 }
 
 func (r *registry) Serialize(key string, v interface{}) ([]byte, error) {
-	reg, exists := r.registered[key]
+	reg, exists := r.lookup(key)
 	if !exists {
 		return nil, UnregisteredKey(key)
 	}
-	return reg.serializer(v)
+	return reg.serdes[reg.contentType].serializer(v)
 }
 
 func (r *registry) MustSerialize(key string, v interface{}) []byte {
@@ -60,12 +99,44 @@ func (r *registry) MustSerialize(key string, v interface{}) []byte {
 }
 
 func (r *registry) Deserialize(key string, data []byte, options ...BuildOption) (interface{}, error) {
+	return r.DeserializeSchema(key, Schema{}, data, options...)
+}
+
+func (r *registry) MustDeserialize(key string, data []byte, options ...BuildOption) interface{} {
+	v, err := r.Deserialize(key, data, options...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (r *registry) DeserializeSchema(key string, schema Schema, data []byte, options ...BuildOption) (interface{}, error) {
+	reg, exists := r.lookup(key)
+	if !exists {
+		return nil, UnregisteredKey(key)
+	}
+
+	contentType := schema.ContentType
+	if contentType == "" {
+		contentType = reg.contentType
+	}
+
+	s, exists := reg.serdes[contentType]
+	if !exists {
+		return nil, UnsupportedContentType{Key: key, ContentType: contentType}
+	}
+
+	data, err := r.upcast(key, schema.Version, reg.version, data)
+	if err != nil {
+		return nil, err
+	}
+
 	v, err := r.Build(key, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.registered[key].deserializer(data, v)
+	err = s.deserializer(data, v)
 	if err != nil {
 		return nil, err
 	}
@@ -73,22 +144,65 @@ func (r *registry) Deserialize(key string, data []byte, options ...BuildOption)
 	return v, nil
 }
 
-func (r *registry) MustDeserialize(key string, data []byte, options ...BuildOption) interface{} {
-	v, err := r.Deserialize(key, data, options...)
-	if err != nil {
-		panic(err)
+func (r *registry) Schema(key string) (Schema, error) {
+	reg, exists := r.lookup(key)
+	if !exists {
+		return Schema{}, UnregisteredKey(key)
 	}
-	return v
+
+	return Schema{
+		ContentType: reg.contentType,
+		Version:     reg.version,
+	}, nil
 }
 
-func (r *registry) Build(key string, options ...BuildOption) (interface{}, error) {
+func (r *registry) RegisterUpcaster(key string, fromVersion int, fn Upcaster) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	upcasters, exists := r.upcasters[key]
+	if !exists {
+		upcasters = make(map[int]Upcaster)
+		r.upcasters[key] = upcasters
+	}
+
+	if _, exists := upcasters[fromVersion]; exists {
+		return AlreadyRegisteredUpcaster{Key: key, Version: fromVersion}
+	}
+
+	upcasters[fromVersion] = fn
+
+	return nil
+}
+
+func (r *registry) UseSerializer(key, contentType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	reg, exists := r.registered[key]
+	if !exists {
+		return UnregisteredKey(key)
+	}
+	if _, exists := reg.serdes[contentType]; !exists {
+		return UnsupportedContentType{Key: key, ContentType: contentType}
+	}
+
+	// replaced rather than changed in place for the lookups made without the lock
+	chosen := *reg
+	chosen.contentType = contentType
+	r.registered[key] = &chosen
+
+	return nil
+}
+
+func (r *registry) Build(key string, options ...BuildOption) (interface{}, error) {
+	reg, exists := r.lookup(key)
 	if !exists {
 		return nil, UnregisteredKey(key)
 	}
 
 	v := reg.factory()
-	uos := append(r.registered[key].options, options...)
+	uos := append(reg.options, options...)
 
 	for _, option := range uos {
 		err := option(v)
@@ -108,19 +222,73 @@ func (r *registry) MustBuild(key string, options ...BuildOption) interface{} {
 	return v
 }
 
-func (r *registry) register(key string, fn func() interface{}, s Serializer, d Deserializer, o []BuildOption) error {
+// upcast runs the data through the upcasters from its version up to the current version
+func (r *registry) upcast(key string, from, to int, data []byte) ([]byte, error) {
+	if from == 0 || from >= to {
+		return data, nil
+	}
+
+	r.mu.RLock()
+	upcasters := r.upcasters[key]
+	r.mu.RUnlock()
+
+	var err error
+	for version := from; version < to; version++ {
+		fn, exists := upcasters[version]
+		if !exists {
+			continue
+		}
+
+		data, err = fn(data)
+		if err != nil {
+			return nil, UpcastFailed{Key: key, Version: version, Err: err}
+		}
+	}
+
+	return data, nil
+}
+
+func (r *registry) lookup(key string) (*registered, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reg, exists := r.registered[key]
+	return reg, exists
+}
+
+func (r *registry) register(key, contentType string, fn func() interface{}, s Serializer, d Deserializer, o []BuildOption) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.registered[key]; exists {
+	reg, exists := r.registered[key]
+	if !exists {
+		version := 1
+		if v, ok := fn().(Versioned); ok {
+			version = v.SchemaVersion()
+		}
+
+		reg = &registered{
+			factory:     fn,
+			contentType: contentType,
+			version:     version,
+			serdes:      make(map[string]serde),
+			options:     o,
+		}
+		r.registered[key] = reg
+	}
+
+	if _, exists := reg.serdes[contentType]; exists {
 		return AlreadyRegisteredKey(key)
 	}
 
-	r.registered[key] = registered{
-		factory:      fn,
+	// every serde for a key must build the same type
+	if registeredType, t := reflect.TypeOf(reg.factory()), reflect.TypeOf(fn()); registeredType != t {
+		return ConflictingRegistration{Key: key, ContentType: contentType, Type: t.String(), RegisteredType: registeredType.String()}
+	}
+
+	reg.serdes[contentType] = serde{
 		serializer:   s,
 		deserializer: d,
-		options:      o,
 	}
 
 	return nil
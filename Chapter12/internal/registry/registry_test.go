@@ -0,0 +1,130 @@
+package registry_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"eda-in-golang/internal/registry"
+	"eda-in-golang/internal/registry/serdes"
+)
+
+const testKey = "test.Value"
+
+func TestRegistry_MultipleSerdes(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewProtoSerde(reg).RegisterKey(testKey, &wrapperspb.StringValue{}))
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testKey, &wrapperspb.StringValue{}))
+
+	schema, err := reg.Schema(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, registry.Schema{ContentType: serdes.ProtoContentType, Version: 1}, schema)
+
+	protoData, err := reg.Serialize(testKey, wrapperspb.String("proto"))
+	assert.NoError(t, err)
+	v, err := reg.DeserializeSchema(testKey, registry.Schema{ContentType: serdes.ProtoContentType}, protoData)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "proto", v.(*wrapperspb.StringValue).GetValue())
+	}
+
+	v, err = reg.DeserializeSchema(testKey, registry.Schema{ContentType: serdes.JsonContentType}, []byte(`{"value":"json"}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "json", v.(*wrapperspb.StringValue).GetValue())
+	}
+
+	// no content type uses the serde that Serialize uses
+	v, err = reg.DeserializeSchema(testKey, registry.Schema{}, protoData)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "proto", v.(*wrapperspb.StringValue).GetValue())
+	}
+
+	_, err = reg.DeserializeSchema(testKey, registry.Schema{ContentType: "application/xml"}, nil)
+	assert.ErrorAs(t, err, &registry.UnsupportedContentType{})
+}
+
+func TestRegistry_DuplicateContentType(t *testing.T) {
+	reg := registry.New()
+	serde := serdes.NewJsonSerde(reg)
+	assert.NoError(t, serde.RegisterKey(testKey, &wrapperspb.StringValue{}))
+
+	err := serde.RegisterKey(testKey, &wrapperspb.StringValue{})
+	assert.Equal(t, registry.AlreadyRegisteredKey(testKey), err)
+}
+
+func TestRegistry_UseSerializer(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewProtoSerde(reg).RegisterKey(testKey, &wrapperspb.StringValue{}))
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testKey, &wrapperspb.StringValue{}))
+
+	assert.NoError(t, reg.UseSerializer(testKey, serdes.JsonContentType))
+
+	schema, err := reg.Schema(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, serdes.JsonContentType, schema.ContentType)
+
+	data, err := reg.Serialize(testKey, wrapperspb.String("json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"value":"json"}`, string(data))
+
+	assert.ErrorAs(t, reg.UseSerializer(testKey, "application/xml"), &registry.UnsupportedContentType{})
+	assert.Equal(t, registry.UnregisteredKey("test.Other"), reg.UseSerializer("test.Other", serdes.JsonContentType))
+}
+
+func TestRegistry_ConflictingRegistration(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewProtoSerde(reg).RegisterKey(testKey, &wrapperspb.StringValue{}))
+
+	err := serdes.NewJsonSerde(reg).RegisterKey(testKey, &wrapperspb.Int64Value{})
+	assert.ErrorAs(t, err, &registry.ConflictingRegistration{})
+
+	schema, err := reg.Schema(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, serdes.ProtoContentType, schema.ContentType)
+}
+
+type versionedValue struct {
+	Name string `json:"name"`
+}
+
+func (versionedValue) SchemaVersion() int { return 3 }
+
+func TestRegistry_Upcasting(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testKey, versionedValue{}))
+
+	// version 1 used "title" for the name; version 2 to 3 did not change the data
+	assert.NoError(t, reg.RegisterUpcaster(testKey, 1, func(data []byte) ([]byte, error) {
+		return bytes.Replace(data, []byte(`"title"`), []byte(`"name"`), 1), nil
+	}))
+
+	schema, err := reg.Schema(testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, schema.Version)
+
+	v, err := reg.DeserializeSchema(testKey, registry.Schema{Version: 1}, []byte(`{"title":"old"}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "old", v.(*versionedValue).Name)
+	}
+
+	v, err = reg.DeserializeSchema(testKey, registry.Schema{Version: 3}, []byte(`{"name":"current"}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "current", v.(*versionedValue).Name)
+	}
+
+	err = reg.RegisterUpcaster(testKey, 1, func(data []byte) ([]byte, error) { return data, nil })
+	assert.Equal(t, registry.AlreadyRegisteredUpcaster{Key: testKey, Version: 1}, err)
+}
+
+func TestRegistry_UpcastFailed(t *testing.T) {
+	reg := registry.New()
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testKey, versionedValue{}))
+
+	failure := errors.New("bad data")
+	assert.NoError(t, reg.RegisterUpcaster(testKey, 2, func([]byte) ([]byte, error) { return nil, failure }))
+
+	_, err := reg.DeserializeSchema(testKey, registry.Schema{Version: 1}, []byte(`{}`))
+	assert.ErrorIs(t, err, failure)
+}
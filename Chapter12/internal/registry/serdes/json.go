@@ -6,6 +6,9 @@ import (
 	"eda-in-golang/internal/registry"
 )
 
+// JsonContentType is the content type of the payloads serialized by the JsonSerde
+const JsonContentType = "application/json"
+
 type JsonSerde struct {
 	r registry.Registry
 }
@@ -17,15 +20,15 @@ func NewJsonSerde(r registry.Registry) *JsonSerde {
 }
 
 func (c JsonSerde) Register(v registry.Registrable, options ...registry.BuildOption) error {
-	return registry.Register(c.r, v, c.serialize, c.deserialize, options)
+	return registry.Register(c.r, v, JsonContentType, c.serialize, c.deserialize, options)
 }
 
 func (c JsonSerde) RegisterKey(key string, v interface{}, options ...registry.BuildOption) error {
-	return registry.RegisterKey(c.r, key, v, c.serialize, c.deserialize, options)
+	return registry.RegisterKey(c.r, key, v, JsonContentType, c.serialize, c.deserialize, options)
 }
 
 func (c JsonSerde) RegisterFactory(key string, fn func() interface{}, options ...registry.BuildOption) error {
-	return registry.RegisterFactory(c.r, key, fn, c.serialize, c.deserialize, options)
+	return registry.RegisterFactory(c.r, key, fn, JsonContentType, c.serialize, c.deserialize, options)
 }
 
 func (JsonSerde) serialize(v interface{}) ([]byte, error) {
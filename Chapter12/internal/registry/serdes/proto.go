@@ -9,6 +9,9 @@ import (
 	"eda-in-golang/internal/registry"
 )
 
+// ProtoContentType is the content type of the payloads serialized by the ProtoSerde
+const ProtoContentType = "application/protobuf"
+
 type ProtoSerde struct {
 	r registry.Registry
 }
@@ -24,14 +27,14 @@ func (c ProtoSerde) Register(v registry.Registrable, options ...registry.BuildOp
 	if !reflect.TypeOf(v).Implements(protoT) {
 		return fmt.Errorf("%T does not implement proto.Message", v)
 	}
-	return registry.Register(c.r, v, c.serialize, c.deserialize, options)
+	return registry.Register(c.r, v, ProtoContentType, c.serialize, c.deserialize, options)
 }
 
 func (c ProtoSerde) RegisterKey(key string, v interface{}, options ...registry.BuildOption) error {
 	if !reflect.TypeOf(v).Implements(protoT) {
 		return fmt.Errorf("%T does not implement proto.Message", v)
 	}
-	return registry.RegisterKey(c.r, key, v, c.serialize, c.deserialize, options)
+	return registry.RegisterKey(c.r, key, v, ProtoContentType, c.serialize, c.deserialize, options)
 }
 
 func (c ProtoSerde) RegisterFactory(key string, fn func() interface{}, options ...registry.BuildOption) error {
@@ -40,7 +43,7 @@ func (c ProtoSerde) RegisterFactory(key string, fn func() interface{}, options .
 	} else if _, ok := v.(proto.Message); !ok {
 		return fmt.Errorf("%s does not implement proto.Message", key)
 	}
-	return registry.RegisterFactory(c.r, key, fn, c.serialize, c.deserialize, options)
+	return registry.RegisterFactory(c.r, key, fn, ProtoContentType, c.serialize, c.deserialize, options)
 }
 
 func (ProtoSerde) serialize(v interface{}) ([]byte, error) {
@@ -2,6 +2,7 @@ package sec
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/stackus/errors"
@@ -18,6 +19,14 @@ type (
 		Start(ctx context.Context, id string, data T) error
 		ReplyTopic() string
 		HandleReply(ctx context.Context, reply ddd.Reply) error
+		// HandleTimeout retries the current step of the saga, or compensates, when
+		// its deadline has passed
+		HandleTimeout(ctx context.Context, sagaID string) error
+		// Retry sends the command of the current step of the saga again
+		Retry(ctx context.Context, sagaID string) error
+		// Compensate abandons the saga and runs the compensations of its completed
+		// steps; the step it was waiting on is compensated should it succeed later
+		Compensate(ctx context.Context, sagaID string) error
 	}
 
 	orchestrator[T any] struct {
@@ -90,6 +99,12 @@ func (o orchestrator[T]) HandleReply(ctx context.Context, reply ddd.Reply) (err
 		return err
 	}
 
+	if token, ok := reply.Metadata().Get(SagaReplyStepHdr).(string); ok && token != sagaCtx.stepToken() {
+		// replies to steps that have timed out are dropped
+		span.AddEvent("Dropped stale reply")
+		return o.compensateAbandoned(ctx, sagaCtx, token, reply)
+	}
+
 	result, err := o.handle(ctx, sagaCtx, reply)
 	if err != nil {
 		return err
@@ -98,6 +113,109 @@ func (o orchestrator[T]) HandleReply(ctx context.Context, reply ddd.Reply) (err
 	return o.processResult(ctx, result)
 }
 
+func (o orchestrator[T]) HandleTimeout(ctx context.Context, sagaID string) error {
+	sagaCtx, err := o.repo.Load(ctx, o.saga.Name(), sagaID)
+	if err != nil {
+		return err
+	}
+
+	// the reply may have arrived, or another sweeper got here first
+	if sagaCtx.Done || sagaCtx.Deadline.IsZero() || sagaCtx.Deadline.After(time.Now()) {
+		return nil
+	}
+
+	step := o.saga.getSteps()[sagaCtx.Step]
+	if sagaCtx.Compensating || sagaCtx.Attempts < step.getRetries() {
+		return o.retry(ctx, sagaCtx)
+	}
+
+	return o.compensate(ctx, sagaCtx)
+}
+
+func (o orchestrator[T]) Retry(ctx context.Context, sagaID string) error {
+	sagaCtx, err := o.repo.Load(ctx, o.saga.Name(), sagaID)
+	if err != nil {
+		return err
+	}
+
+	if sagaCtx.Done || sagaCtx.Step < 0 {
+		return errors.ErrFailedPrecondition.Msgf("saga %s has no step to retry", sagaID)
+	}
+
+	return o.retry(ctx, sagaCtx)
+}
+
+func (o orchestrator[T]) Compensate(ctx context.Context, sagaID string) error {
+	sagaCtx, err := o.repo.Load(ctx, o.saga.Name(), sagaID)
+	if err != nil {
+		return err
+	}
+
+	if sagaCtx.Done || sagaCtx.Compensating {
+		return errors.ErrFailedPrecondition.Msgf("saga %s is already done or compensating", sagaID)
+	}
+
+	return o.compensate(ctx, sagaCtx)
+}
+
+func (o orchestrator[T]) retry(ctx context.Context, sagaCtx *SagaContext[T]) error {
+	sagaCtx.Attempts++
+	result := o.saga.getSteps()[sagaCtx.Step].execute(ctx, sagaCtx)
+	if result.err != nil {
+		return result.err
+	}
+
+	return o.processResult(ctx, result)
+}
+
+func (o orchestrator[T]) compensate(ctx context.Context, sagaCtx *SagaContext[T]) error {
+	sagaCtx.compensate()
+	result := o.execute(ctx, sagaCtx)
+	if result.err != nil {
+		return result.err
+	}
+
+	return o.processResult(ctx, result)
+}
+
+// compensateAbandoned sends the compensation of a step that timed out, and was
+// abandoned as the saga compensated, when its command succeeded after all
+func (o orchestrator[T]) compensateAbandoned(ctx context.Context, sagaCtx *SagaContext[T], token string, reply ddd.Reply) error {
+	var stepIndex, attempts int
+	var compensating bool
+	if _, err := fmt.Sscanf(token, "%d:%t:%d", &stepIndex, &compensating, &attempts); err != nil {
+		return nil
+	}
+
+	steps := o.saga.getSteps()
+	if compensating || !sagaCtx.Compensating || stepIndex < 0 || stepIndex >= len(steps) {
+		return nil
+	}
+	if outcome, ok := reply.Metadata().Get(am.ReplyOutcomeHdr).(string); !ok || outcome != am.OutcomeSuccess {
+		return nil
+	}
+
+	step := steps[stepIndex]
+	if !step.isInvocable(isCompensating) {
+		return nil
+	}
+
+	// the reply may hold what the compensation needs, such as the ID of what was created
+	abandoned := &SagaContext[T]{ID: sagaCtx.ID, Data: sagaCtx.Data, Step: stepIndex}
+	if err := step.handle(ctx, abandoned, reply); err != nil {
+		return err
+	}
+	abandoned.compensate()
+
+	result := step.execute(ctx, abandoned)
+	if result.err != nil {
+		return result.err
+	}
+
+	// the reply to the compensation is dropped as the saga has moved on
+	return o.publishCommand(ctx, result)
+}
+
 func (o orchestrator[T]) handle(ctx context.Context, sagaCtx *SagaContext[T], reply ddd.Reply) (stepResult[T], error) {
 	step := o.saga.getSteps()[sagaCtx.Step]
 
@@ -175,6 +293,11 @@ func (o orchestrator[T]) execute(ctx context.Context, sagaCtx *SagaContext[T]) (
 }
 
 func (o orchestrator[T]) processResult(ctx context.Context, result stepResult[T]) (err error) {
+	result.ctx.Deadline = time.Time{}
+	if result.cmd != nil && result.timeout > 0 {
+		result.ctx.Deadline = time.Now().Add(result.timeout)
+	}
+
 	if result.cmd != nil {
 		err = o.publishCommand(ctx, result)
 		if err != nil {
@@ -191,6 +314,7 @@ func (o orchestrator[T]) publishCommand(ctx context.Context, result stepResult[T
 	cmd.Metadata().Set(am.CommandReplyChannelHdr, o.saga.ReplyTopic())
 	cmd.Metadata().Set(SagaCommandIDHdr, result.ctx.ID)
 	cmd.Metadata().Set(SagaCommandNameHdr, o.saga.Name())
+	cmd.Metadata().Set(SagaCommandStepHdr, result.ctx.stepToken())
 
 	return o.publisher.Publish(ctx, result.destination, cmd)
 }
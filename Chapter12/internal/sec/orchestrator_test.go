@@ -0,0 +1,174 @@
+package sec
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+	"eda-in-golang/internal/registry/serdes"
+)
+
+const testSagaName = "test.Saga"
+
+type testSagaData struct {
+	OrderID string
+}
+
+type fakeSagaStore struct {
+	sagas map[string]*SagaContext[[]byte]
+}
+
+func (s *fakeSagaStore) Load(_ context.Context, _, sagaID string) (*SagaContext[[]byte], error) {
+	sagaCtx := *s.sagas[sagaID]
+	return &sagaCtx, nil
+}
+
+func (s *fakeSagaStore) Save(_ context.Context, _ string, sagaCtx *SagaContext[[]byte]) error {
+	saved := *sagaCtx
+	s.sagas[sagaCtx.ID] = &saved
+	return nil
+}
+
+func (s *fakeSagaStore) FindExpired(_ context.Context, _ string, _ int) ([]string, error) {
+	var ids []string
+	for id, sagaCtx := range s.sagas {
+		if !sagaCtx.Done && !sagaCtx.Deadline.IsZero() && !sagaCtx.Deadline.After(time.Now()) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *fakeSagaStore) expire(sagaID string) {
+	s.sagas[sagaID].Deadline = time.Now().Add(-time.Second)
+}
+
+type sentCommands []ddd.Command
+
+func (c *sentCommands) Publish(_ context.Context, _ string, cmd ddd.Command) error {
+	*c = append(*c, cmd)
+	return nil
+}
+
+func setupTimeoutSaga(t *testing.T) (Orchestrator[*testSagaData], *fakeSagaStore, *sentCommands) {
+	t.Helper()
+
+	reg := registry.New()
+	assert.NoError(t, serdes.NewJsonSerde(reg).RegisterKey(testSagaName, testSagaData{}))
+
+	command := func(name string) StepActionFunc[*testSagaData] {
+		return func(context.Context, *testSagaData) (string, ddd.Command, error) {
+			return "test.commands", ddd.NewCommand(name, nil), nil
+		}
+	}
+
+	saga := NewSaga[*testSagaData](testSagaName, "test.replies")
+	saga.AddStep().
+		Compensation(command("test.Reject"))
+	saga.AddStep().
+		Action(command("test.Reserve")).
+		Compensation(command("test.Release")).
+		Timeout(time.Minute).
+		RetryOnTimeout(1)
+
+	store := &fakeSagaStore{sagas: map[string]*SagaContext[[]byte]{}}
+	sent := &sentCommands{}
+
+	return NewOrchestrator[*testSagaData](saga, NewSagaRepository[*testSagaData](reg, store), sent), store, sent
+}
+
+func TestOrchestrator_HandleTimeout(t *testing.T) {
+	ctx := context.Background()
+	orchestrator, store, sent := setupTimeoutSaga(t)
+
+	assert.NoError(t, orchestrator.Start(ctx, "saga-1", &testSagaData{OrderID: "order-1"}))
+	assert.False(t, store.sagas["saga-1"].Deadline.IsZero())
+
+	// not expired yet
+	assert.NoError(t, orchestrator.HandleTimeout(ctx, "saga-1"))
+	assert.Len(t, *sent, 1)
+
+	// the first timeout retries the step
+	store.expire("saga-1")
+	assert.NoError(t, orchestrator.HandleTimeout(ctx, "saga-1"))
+	if assert.Len(t, *sent, 2) {
+		assert.Equal(t, "test.Reserve", (*sent)[1].CommandName())
+	}
+	assert.Equal(t, 1, store.sagas["saga-1"].Attempts)
+
+	// the reply to the first attempt is dropped
+	stale := ddd.NewReply(am.SuccessReply, nil)
+	stale.Metadata().Set(SagaReplyIDHdr, "saga-1")
+	stale.Metadata().Set(SagaReplyNameHdr, testSagaName)
+	stale.Metadata().Set(SagaReplyStepHdr, (*sent)[0].Metadata().Get(SagaCommandStepHdr))
+	stale.Metadata().Set(am.ReplyOutcomeHdr, am.OutcomeSuccess)
+	assert.NoError(t, orchestrator.HandleReply(ctx, stale))
+	assert.False(t, store.sagas["saga-1"].Done)
+
+	// the second timeout compensates
+	store.expire("saga-1")
+	assert.NoError(t, orchestrator.HandleTimeout(ctx, "saga-1"))
+	if assert.Len(t, *sent, 3) {
+		assert.Equal(t, "test.Reject", (*sent)[2].CommandName())
+	}
+	assert.True(t, store.sagas["saga-1"].Compensating)
+	assert.Equal(t, 0, store.sagas["saga-1"].Step)
+}
+
+func TestOrchestrator_RetryAndCompensate(t *testing.T) {
+	ctx := context.Background()
+	orchestrator, store, sent := setupTimeoutSaga(t)
+
+	assert.NoError(t, orchestrator.Start(ctx, "saga-1", &testSagaData{OrderID: "order-1"}))
+
+	assert.NoError(t, orchestrator.Retry(ctx, "saga-1"))
+	if assert.Len(t, *sent, 2) {
+		assert.Equal(t, "test.Reserve", (*sent)[1].CommandName())
+	}
+
+	assert.NoError(t, orchestrator.Compensate(ctx, "saga-1"))
+	if assert.Len(t, *sent, 3) {
+		assert.Equal(t, "test.Reject", (*sent)[2].CommandName())
+	}
+	assert.True(t, store.sagas["saga-1"].Compensating)
+
+	// a saga can only be compensated once
+	assert.Error(t, orchestrator.Compensate(ctx, "saga-1"))
+}
+
+func TestOrchestrator_CompensatesLateReplies(t *testing.T) {
+	ctx := context.Background()
+	orchestrator, store, sent := setupTimeoutSaga(t)
+
+	assert.NoError(t, orchestrator.Start(ctx, "saga-1", &testSagaData{OrderID: "order-1"}))
+	assert.NoError(t, orchestrator.Compensate(ctx, "saga-1"))
+	if assert.Len(t, *sent, 2) {
+		assert.Equal(t, "test.Reject", (*sent)[1].CommandName())
+	}
+
+	// the abandoned step succeeded after all and is compensated
+	late := ddd.NewReply(am.SuccessReply, nil)
+	late.Metadata().Set(SagaReplyIDHdr, "saga-1")
+	late.Metadata().Set(SagaReplyNameHdr, testSagaName)
+	late.Metadata().Set(SagaReplyStepHdr, (*sent)[0].Metadata().Get(SagaCommandStepHdr))
+	late.Metadata().Set(am.ReplyOutcomeHdr, am.OutcomeSuccess)
+	assert.NoError(t, orchestrator.HandleReply(ctx, late))
+	if assert.Len(t, *sent, 3) {
+		assert.Equal(t, "test.Release", (*sent)[2].CommandName())
+	}
+	assert.Equal(t, 0, store.sagas["saga-1"].Step)
+
+	// a late failure needs no compensation
+	failed := ddd.NewReply(am.FailureReply, nil)
+	failed.Metadata().Set(SagaReplyIDHdr, "saga-1")
+	failed.Metadata().Set(SagaReplyNameHdr, testSagaName)
+	failed.Metadata().Set(SagaReplyStepHdr, (*sent)[0].Metadata().Get(SagaCommandStepHdr))
+	failed.Metadata().Set(am.ReplyOutcomeHdr, am.OutcomeFailure)
+	assert.NoError(t, orchestrator.HandleReply(ctx, failed))
+	assert.Len(t, *sent, 3)
+}
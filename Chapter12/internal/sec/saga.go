@@ -1,6 +1,9 @@
 package sec
 
 import (
+	"fmt"
+	"time"
+
 	"eda-in-golang/internal/am"
 )
 
@@ -8,8 +11,11 @@ const (
 	SagaCommandIDHdr   = am.CommandHdrPrefix + "SAGA_ID"
 	SagaCommandNameHdr = am.CommandHdrPrefix + "SAGA_NAME"
 
+	SagaCommandStepHdr = am.CommandHdrPrefix + "SAGA_STEP"
+
 	SagaReplyIDHdr   = am.ReplyHdrPrefix + "SAGA_ID"
 	SagaReplyNameHdr = am.ReplyHdrPrefix + "SAGA_NAME"
+	SagaReplyStepHdr = am.ReplyHdrPrefix + "SAGA_STEP"
 )
 
 type (
@@ -19,6 +25,10 @@ type (
 		Step         int
 		Done         bool
 		Compensating bool
+		// Attempts is the number of times the step has been retried after timing out
+		Attempts int
+		// Deadline is when the step times out; the zero time means it never does
+		Deadline time.Time
 	}
 
 	Saga[T any] interface {
@@ -83,6 +93,7 @@ func (s *SagaContext[T]) advance(steps int) {
 	}
 
 	s.Step += dir * steps
+	s.Attempts = 0
 }
 
 func (s *SagaContext[T]) complete() {
@@ -92,3 +103,9 @@ func (s *SagaContext[T]) complete() {
 func (s *SagaContext[T]) compensate() {
 	s.Compensating = true
 }
+
+// stepToken identifies the execution of the current step so that replies to
+// earlier attempts, or to a step that has timed out, can be told apart
+func (s *SagaContext[T]) stepToken() string {
+	return fmt.Sprintf("%d:%t:%d", s.Step, s.Compensating, s.Attempts)
+}
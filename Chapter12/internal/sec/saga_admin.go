@@ -0,0 +1,71 @@
+package sec
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	SagaRunning      SagaStatus = "running"
+	SagaCompensating SagaStatus = "compensating"
+	SagaDone         SagaStatus = "done"
+	SagaCompensated  SagaStatus = "compensated"
+)
+
+type (
+	SagaStatus string
+
+	// SagaRecord is a saga as it is stored, with its data still serialized
+	SagaRecord struct {
+		Name      string
+		ID        string
+		Data      []byte
+		Step      int
+		Status    SagaStatus
+		Attempts  int
+		Deadline  time.Time
+		UpdatedAt time.Time
+	}
+
+	// SagaStepRecord is the state a saga was saved in as it moved through its steps
+	SagaStepRecord struct {
+		Step       int
+		Status     SagaStatus
+		Attempts   int
+		Deadline   time.Time
+		RecordedAt time.Time
+	}
+
+	// SagaFilter selects sagas; the zero values match every saga
+	SagaFilter struct {
+		Name          string
+		Status        SagaStatus
+		UpdatedBefore time.Time
+		Limit         int
+	}
+
+	// SagaAdminStore reads the sagas for the administration of them
+	SagaAdminStore interface {
+		List(ctx context.Context, filter SagaFilter) ([]SagaRecord, error)
+		Find(ctx context.Context, sagaName, sagaID string) (SagaRecord, error)
+		History(ctx context.Context, sagaName, sagaID string) ([]SagaStepRecord, error)
+	}
+)
+
+// NewSagaStatus is the status of a saga from whether it is done and compensating
+func NewSagaStatus(done, compensating bool) SagaStatus {
+	switch {
+	case done && compensating:
+		return SagaCompensated
+	case done:
+		return SagaDone
+	case compensating:
+		return SagaCompensating
+	default:
+		return SagaRunning
+	}
+}
+
+func (s SagaStatus) String() string {
+	return string(s)
+}
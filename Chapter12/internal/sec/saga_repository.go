@@ -9,8 +9,12 @@ import (
 )
 
 type SagaStore interface {
+	// Load reads the saga and, within a transaction, locks it until the transaction ends
 	Load(ctx context.Context, sagaName, sagaID string) (*SagaContext[[]byte], error)
 	Save(ctx context.Context, sagaName string, sagaCtx *SagaContext[[]byte]) error
+	// FindExpired returns the ids of up to limit of the unfinished sagas whose step
+	// deadline has passed
+	FindExpired(ctx context.Context, sagaName string, limit int) ([]string, error)
 }
 
 type SagaRepository[T any] struct {
@@ -48,6 +52,8 @@ func (r SagaRepository[T]) Load(ctx context.Context, sagaName, sagaID string) (*
 		Step:         byteCtx.Step,
 		Done:         byteCtx.Done,
 		Compensating: byteCtx.Compensating,
+		Attempts:     byteCtx.Attempts,
+		Deadline:     byteCtx.Deadline,
 	}, nil
 }
 
@@ -63,5 +69,7 @@ func (r SagaRepository[T]) Save(ctx context.Context, sagaName string, sagaCtx *S
 		Step:         sagaCtx.Step,
 		Done:         sagaCtx.Done,
 		Compensating: sagaCtx.Compensating,
+		Attempts:     sagaCtx.Attempts,
+		Deadline:     sagaCtx.Deadline,
 	})
 }
@@ -2,6 +2,7 @@ package sec
 
 import (
 	"context"
+	"time"
 
 	"eda-in-golang/internal/ddd"
 )
@@ -15,6 +16,13 @@ type (
 		Compensation(fn StepActionFunc[T]) SagaStep[T]
 		OnActionReply(replyName string, fn StepReplyHandlerFunc[T]) SagaStep[T]
 		OnCompensationReply(replyName string, fn StepReplyHandlerFunc[T]) SagaStep[T]
+		// Timeout is how long to wait for the reply to the step's command before the
+		// step is retried or the saga compensates
+		Timeout(timeout time.Duration) SagaStep[T]
+		// RetryOnTimeout is how many times the action is sent again after timing out
+		// before the saga compensates; compensations are always sent again
+		RetryOnTimeout(retries int) SagaStep[T]
+		getRetries() int
 		isInvocable(compensating bool) bool
 		execute(ctx context.Context, sagaCtx *SagaContext[T]) stepResult[T]
 		handle(ctx context.Context, sagaCtx *SagaContext[T], reply ddd.Reply) error
@@ -23,12 +31,15 @@ type (
 	sagaStep[T any] struct {
 		actions  map[bool]StepActionFunc[T]
 		handlers map[bool]map[string]StepReplyHandlerFunc[T]
+		timeout  time.Duration
+		retries  int
 	}
 
 	stepResult[T any] struct {
 		ctx         *SagaContext[T]
 		destination string
 		cmd         ddd.Command
+		timeout     time.Duration
 		err         error
 	}
 )
@@ -55,6 +66,20 @@ func (s *sagaStep[T]) OnCompensationReply(replyName string, fn StepReplyHandlerF
 	return s
 }
 
+func (s *sagaStep[T]) Timeout(timeout time.Duration) SagaStep[T] {
+	s.timeout = timeout
+	return s
+}
+
+func (s *sagaStep[T]) RetryOnTimeout(retries int) SagaStep[T] {
+	s.retries = retries
+	return s
+}
+
+func (s sagaStep[T]) getRetries() int {
+	return s.retries
+}
+
 func (s sagaStep[T]) isInvocable(compensating bool) bool {
 	return s.actions[compensating] != nil
 }
@@ -66,6 +91,7 @@ func (s sagaStep[T]) execute(ctx context.Context, sagaCtx *SagaContext[T]) stepR
 			ctx:         sagaCtx,
 			destination: destination,
 			cmd:         cmd,
+			timeout:     s.timeout,
 			err:         err,
 		}
 	}
@@ -105,3 +131,15 @@ func OnCompensationReply[T any](replyName string, fn StepReplyHandlerFunc[T]) St
 		step.handlers[isCompensating][replyName] = fn
 	}
 }
+
+func WithTimeout[T any](timeout time.Duration) StepOption[T] {
+	return func(step *sagaStep[T]) {
+		step.timeout = timeout
+	}
+}
+
+func WithRetryOnTimeout[T any](retries int) StepOption[T] {
+	return func(step *sagaStep[T]) {
+		step.retries = retries
+	}
+}
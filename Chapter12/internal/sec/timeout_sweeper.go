@@ -0,0 +1,100 @@
+package sec
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultSweepInterval = 5 * time.Second
+const defaultSweepBatchSize = 100
+
+type (
+	// TimeoutHandlerFunc handles a saga whose step deadline has passed, e.g. with
+	// Orchestrator.HandleTimeout inside a transaction
+	TimeoutHandlerFunc func(ctx context.Context, sagaID string) error
+
+	TimeoutSweeperOption interface {
+		configureTimeoutSweeper(*TimeoutSweeper)
+	}
+
+	// SweepInterval is how long the sweeper waits between looking for expired sagas
+	SweepInterval time.Duration
+
+	// SweepBatchSize is the most expired sagas handled for each look
+	SweepBatchSize int
+
+	TimeoutSweeper struct {
+		sagaName  string
+		store     SagaStore
+		handler   TimeoutHandlerFunc
+		interval  time.Duration
+		batchSize int
+		logger    zerolog.Logger
+	}
+)
+
+func NewTimeoutSweeper(sagaName string, store SagaStore, handler TimeoutHandlerFunc, logger zerolog.Logger, options ...TimeoutSweeperOption) *TimeoutSweeper {
+	s := &TimeoutSweeper{
+		sagaName:  sagaName,
+		store:     store,
+		handler:   handler,
+		interval:  defaultSweepInterval,
+		batchSize: defaultSweepBatchSize,
+		logger:    logger,
+	}
+
+	for _, option := range options {
+		option.configureTimeoutSweeper(s)
+	}
+
+	return s
+}
+
+func (i SweepInterval) configureTimeoutSweeper(s *TimeoutSweeper) {
+	if i > 0 {
+		s.interval = time.Duration(i)
+	}
+}
+
+func (b SweepBatchSize) configureTimeoutSweeper(s *TimeoutSweeper) {
+	if b > 0 {
+		s.batchSize = int(b)
+	}
+}
+
+// Run hands the expired sagas to the handler each interval until the context
+// is done; it is meant to be added to the waiter
+func (s *TimeoutSweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sweep(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Msgf("failed to sweep the %s sagas", s.sagaName)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *TimeoutSweeper) sweep(ctx context.Context) error {
+	ids, err := s.store.FindExpired(ctx, s.sagaName, s.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		// one saga failing should not hold up the others
+		if err = s.handler(ctx, id); err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Str("SagaID", id).Msgf("failed to handle the %s saga timeout", s.sagaName)
+		}
+	}
+
+	return nil
+}
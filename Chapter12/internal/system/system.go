@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/pressly/goose/v3"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -23,9 +24,16 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/am/memstream"
+	"eda-in-golang/internal/amotel"
 	"eda-in-golang/internal/config"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/jetstream"
+	"eda-in-golang/internal/kafka"
 	"eda-in-golang/internal/logger"
 	"eda-in-golang/internal/waiter"
 )
@@ -35,6 +43,8 @@ type System struct {
 	db     *sql.DB
 	nc     *nats.Conn
 	js     nats.JetStreamContext
+	broker kafka.Broker
+	stream am.MessageStream
 	mux    *chi.Mux
 	rpc    *grpc.Server
 	waiter waiter.Waiter
@@ -51,10 +61,6 @@ func NewSystem(cfg config.AppConfig) (*System, error) {
 		return nil, err
 	}
 
-	if err := s.initJS(); err != nil {
-		return nil, err
-	}
-
 	if err := s.initOpenTelemetry(); err != nil {
 		return nil, err
 	}
@@ -63,6 +69,10 @@ func NewSystem(cfg config.AppConfig) (*System, error) {
 	s.initRpc()
 	s.initLogger()
 
+	if err := s.initStream(); err != nil {
+		return nil, err
+	}
+
 	return s, nil
 }
 
@@ -131,6 +141,35 @@ func (s *System) JS() nats.JetStreamContext {
 	return s.js
 }
 
+func (s *System) initStream() error {
+	switch s.cfg.StreamDriver {
+	case config.NatsStreamDriver:
+		if s.cfg.Nats.URL == "" {
+			return fmt.Errorf("the NATS_URL is required when using the %s stream driver", config.NatsStreamDriver)
+		}
+		if err := s.initJS(); err != nil {
+			return err
+		}
+		s.stream = jetstream.NewStream(s.cfg.Nats.Stream, s.js, s.logger)
+	case config.KafkaStreamDriver:
+		if len(s.cfg.Kafka.Brokers) == 0 {
+			return fmt.Errorf("the KAFKA_BROKERS are required when using the %s stream driver", config.KafkaStreamDriver)
+		}
+		s.broker = kafka.NewBroker(s.cfg.Kafka.Brokers)
+		s.stream = kafka.NewStream(s.broker, s.logger)
+	case config.MemoryStreamDriver:
+		s.stream = memstream.NewStream(s.logger)
+	default:
+		return fmt.Errorf("unknown stream driver: %s", s.cfg.StreamDriver)
+	}
+
+	return nil
+}
+
+func (s *System) Stream() am.MessageStream {
+	return s.stream
+}
+
 func (s *System) initLogger() {
 	s.logger = logger.New(logger.LogConfig{
 		Environment: s.cfg.Environment,
@@ -156,6 +195,7 @@ func (s *System) initRpc() {
 	s.rpc = grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			otelgrpc.UnaryServerInterceptor(),
+			serverMetadataUnaryInterceptor(),
 			serverErrorUnaryInterceptor(),
 		),
 		// If there are streaming endpoints also add
@@ -245,6 +285,10 @@ func (s *System) WaitForRPC(ctx context.Context) error {
 }
 
 func (s *System) WaitForStream(ctx context.Context) error {
+	if s.nc == nil {
+		return s.waitForSubscriptions(ctx)
+	}
+
 	closed := make(chan struct{})
 	s.nc.SetClosedHandler(func(*nats.Conn) {
 		close(closed)
@@ -263,9 +307,48 @@ func (s *System) WaitForStream(ctx context.Context) error {
 	return group.Wait()
 }
 
+func (s *System) waitForSubscriptions(ctx context.Context) error {
+	fmt.Println("message stream started")
+	defer fmt.Println("message stream stopped")
+	<-ctx.Done()
+	if err := s.stream.Unsubscribe(); err != nil {
+		return err
+	}
+	if s.broker != nil {
+		return s.broker.Close()
+	}
+	return nil
+}
+
 func serverErrorUnaryInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		resp, err = handler(ctx, req)
 		return resp, errors.SendGRPCError(err)
 	}
 }
+
+const (
+	correlationIDHeader = "x-correlation-id"
+	actorHeader         = "x-actor"
+)
+
+// serverMetadataUnaryInterceptor starts the metadata kept with the events,
+// commands and replies created by a request; the correlation ID and actor may
+// be sent as the "x-correlation-id" and "x-actor" request metadata
+func serverMetadataUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md := ddd.Metadata{
+			ddd.CorrelationIDKey: uuid.New().String(),
+		}
+		if incoming, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := incoming.Get(correlationIDHeader); len(values) > 0 && values[0] != "" {
+				md.Set(ddd.CorrelationIDKey, values[0])
+			}
+			if values := incoming.Get(actorHeader); len(values) > 0 && values[0] != "" {
+				md.Set(ddd.ActorKey, values[0])
+			}
+		}
+
+		return handler(amotel.ContextWithTraceMetadata(ddd.ContextWithMetadata(ctx, md)), req)
+	}
+}
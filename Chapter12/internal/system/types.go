@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 
+	"eda-in-golang/internal/am"
 	"eda-in-golang/internal/config"
 	"eda-in-golang/internal/waiter"
 )
@@ -17,6 +18,7 @@ type Service interface {
 	Config() config.AppConfig
 	DB() *sql.DB
 	JS() nats.JetStreamContext
+	Stream() am.MessageStream
 	Mux() *chi.Mux
 	RPC() *grpc.Server
 	Waiter() waiter.Waiter
@@ -0,0 +1,72 @@
+package tm
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+// FileArchiver writes each batch of purged messages to a gzipped JSON lines file
+type FileArchiver struct {
+	dir string
+}
+
+type archivedMessage struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Subject  string       `json:"subject"`
+	Data     []byte       `json:"data"`
+	Metadata ddd.Metadata `json:"metadata"`
+	SentAt   time.Time    `json:"sent_at"`
+}
+
+var _ Archiver = (*FileArchiver)(nil)
+
+func NewFileArchiver(dir string) FileArchiver {
+	return FileArchiver{dir: dir}
+}
+
+func (a FileArchiver) Archive(_ context.Context, name string, msgs []am.Message) (err error) {
+	if err = os.MkdirAll(a.dir, 0o755); err != nil {
+		return err
+	}
+
+	fileName := filepath.Join(a.dir, fmt.Sprintf("%s-%s.jsonl.gz", name, time.Now().UTC().Format("20060102T150405.000000000")))
+
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(fileName)
+		}
+	}()
+
+	zw := gzip.NewWriter(file)
+	enc := json.NewEncoder(zw)
+	for _, msg := range msgs {
+		if err = enc.Encode(archivedMessage{
+			ID:       msg.ID(),
+			Name:     msg.MessageName(),
+			Subject:  msg.Subject(),
+			Data:     msg.Data(),
+			Metadata: msg.Metadata(),
+			SentAt:   msg.SentAt(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,91 @@
+package tm
+
+import (
+	"context"
+	"time"
+
+	"github.com/stackus/errors"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+const (
+	InboxCompleted InboxStatus = "completed"
+	InboxFailed    InboxStatus = "failed"
+)
+
+type (
+	InboxStatus string
+
+	// InboxEntry is the outcome recorded for a message and one of its handlers
+	InboxEntry struct {
+		Message    am.Message
+		Handler    string
+		Status     InboxStatus
+		Attempts   int
+		LastError  string
+		ReceivedAt time.Time
+		UpdatedAt  time.Time
+	}
+
+	// InboxEntryStore finds the entries recorded in an inbox
+	InboxEntryStore interface {
+		// FindFailed returns up to limit of the failed entries, the oldest failures first
+		FindFailed(ctx context.Context, limit int) ([]InboxEntry, error)
+		Find(ctx context.Context, msgID, handler string) (InboxEntry, error)
+	}
+
+	// InboxAdmin lists the failed inbox entries and retries them
+	InboxAdmin struct {
+		store     InboxEntryStore
+		publisher am.MessagePublisher
+	}
+
+	// retriedMessage is a failed message marked as replayed, so the stream does
+	// not drop it as a duplicate of the original
+	retriedMessage struct {
+		am.Message
+		metadata ddd.Metadata
+	}
+)
+
+func NewInboxAdmin(store InboxEntryStore, publisher am.MessagePublisher) InboxAdmin {
+	return InboxAdmin{
+		store:     store,
+		publisher: publisher,
+	}
+}
+
+func (a InboxAdmin) ListFailed(ctx context.Context, limit int) ([]InboxEntry, error) {
+	return a.store.FindFailed(ctx, limit)
+}
+
+// Retry publishes a failed message again with its original ID. Every
+// subscriber receives it, but the handlers that completed it already, here or
+// in other services, will find it in their inbox and skip it
+func (a InboxAdmin) Retry(ctx context.Context, msgID, handler string) error {
+	entry, err := a.store.Find(ctx, msgID, handler)
+	if err != nil {
+		return err
+	}
+
+	if entry.Status != InboxFailed {
+		return errors.ErrFailedPrecondition.Msgf("inbox entry %s for %s has not failed", msgID, handler)
+	}
+
+	metadata := make(ddd.Metadata, len(entry.Message.Metadata())+1)
+	for key, value := range entry.Message.Metadata() {
+		metadata.Set(key, value)
+	}
+	metadata.Set(am.ReplayedAtHdr, time.Now().Format(time.RFC3339Nano))
+
+	return a.publisher.Publish(ctx, entry.Message.Subject(), retriedMessage{
+		Message:  entry.Message,
+		metadata: metadata,
+	})
+}
+
+func (m retriedMessage) Metadata() ddd.Metadata { return m.metadata }
+
+func (s InboxStatus) String() string { return string(s) }
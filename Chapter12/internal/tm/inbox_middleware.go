@@ -11,25 +11,41 @@ import (
 
 type ErrDuplicateMessage string
 
+// InboxStore records how each handler has dealt with a message; a message
+// may be handled once by each of the named handlers
 type InboxStore interface {
-	Save(ctx context.Context, msg am.IncomingMessage) error
+	// Completed reports whether the handler has already handled the message
+	Completed(ctx context.Context, msgID, handler string) (bool, error)
+	// Complete records that the handler has handled the message; ErrDuplicateMessage
+	// is returned if it was completed elsewhere in the meantime
+	Complete(ctx context.Context, msg am.IncomingMessage, handler string) error
+	// Fail records a failed attempt by the handler. It is written apart from any
+	// transaction the store uses so that it survives the rollback
+	Fail(ctx context.Context, msg am.IncomingMessage, handler string, err error) error
 }
 
-func InboxHandler(store InboxStore) am.MessageHandlerMiddleware {
+func InboxHandler(store InboxStore, handler string) am.MessageHandlerMiddleware {
 	return func(next am.MessageHandler) am.MessageHandler {
 		return am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) error {
-			// try to insert the message
-			err := store.Save(ctx, msg)
+			completed, err := store.Completed(ctx, msg.ID(), handler)
 			if err != nil {
-				var errDupe ErrDuplicateMessage
-				if errors.As(err, &errDupe) {
-					// duplicate message; return without an error to let the message Ack
-					return nil
+				return err
+			}
+			if completed {
+				// duplicate message; return without an error to let the message Ack
+				return nil
+			}
+
+			if err = next.HandleMessage(ctx, msg); err != nil {
+				if failErr := store.Fail(ctx, msg, handler, err); failErr != nil {
+					return errors.Wrapf(err, "recording the inbox failure: %s", failErr)
 				}
 				return err
 			}
 
-			return next.HandleMessage(ctx, msg)
+			// a duplicate completed by another delivery is returned as an error so that
+			// the work done here is rolled back; the redelivery will then Ack
+			return store.Complete(ctx, msg, handler)
 		})
 	}
 }
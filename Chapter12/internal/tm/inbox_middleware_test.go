@@ -0,0 +1,124 @@
+package tm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+)
+
+type testIncomingMessage struct {
+	testMessage
+}
+
+func (m testIncomingMessage) ReceivedAt() time.Time { return time.Time{} }
+func (m testIncomingMessage) Ack() error            { return nil }
+func (m testIncomingMessage) NAck() error           { return nil }
+func (m testIncomingMessage) Extend() error         { return nil }
+func (m testIncomingMessage) Kill() error           { return nil }
+
+type fakeInboxStore struct {
+	entries map[string]*InboxEntry
+}
+
+func (s *fakeInboxStore) entry(msg am.Message, handler string) *InboxEntry {
+	key := msg.ID() + "/" + handler
+	if s.entries[key] == nil {
+		s.entries[key] = &InboxEntry{Message: msg, Handler: handler}
+	}
+	return s.entries[key]
+}
+
+func (s *fakeInboxStore) Completed(_ context.Context, msgID, handler string) (bool, error) {
+	entry, exists := s.entries[msgID+"/"+handler]
+	return exists && entry.Status == InboxCompleted, nil
+}
+
+func (s *fakeInboxStore) Complete(_ context.Context, msg am.IncomingMessage, handler string) error {
+	entry := s.entry(msg, handler)
+	entry.Status = InboxCompleted
+	entry.Attempts++
+	entry.LastError = ""
+	return nil
+}
+
+func (s *fakeInboxStore) Fail(_ context.Context, msg am.IncomingMessage, handler string, err error) error {
+	entry := s.entry(msg, handler)
+	entry.Status = InboxFailed
+	entry.Attempts++
+	entry.LastError = err.Error()
+	return nil
+}
+
+func (s *fakeInboxStore) FindFailed(context.Context, int) ([]InboxEntry, error) {
+	var entries []InboxEntry
+	for _, entry := range s.entries {
+		if entry.Status == InboxFailed {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s *fakeInboxStore) Find(_ context.Context, msgID, handler string) (InboxEntry, error) {
+	return *s.entries[msgID+"/"+handler], nil
+}
+
+func TestInboxHandler_RecordsOutcomePerHandler(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeInboxStore{entries: map[string]*InboxEntry{}}
+	msg := testIncomingMessage{testMessage{id: "msg-1"}}
+
+	var handled []string
+	handler := func(name string, err error) am.MessageHandler {
+		return InboxHandler(store, name)(am.MessageHandlerFunc(func(context.Context, am.IncomingMessage) error {
+			handled = append(handled, name)
+			return err
+		}))
+	}
+
+	assert.NoError(t, handler("events", nil).HandleMessage(ctx, msg))
+	assert.Error(t, handler("commands", fmt.Errorf("boom")).HandleMessage(ctx, msg))
+
+	// the completed handler skips the redelivery, the failed handler tries again
+	assert.NoError(t, handler("events", nil).HandleMessage(ctx, msg))
+	assert.Error(t, handler("commands", fmt.Errorf("boom again")).HandleMessage(ctx, msg))
+	assert.Equal(t, []string{"events", "commands", "commands"}, handled)
+
+	failed, err := NewInboxAdmin(store, nil).ListFailed(ctx, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, "commands", failed[0].Handler)
+		assert.Equal(t, 2, failed[0].Attempts)
+		assert.Equal(t, "boom again", failed[0].LastError)
+	}
+}
+
+func TestInboxAdmin_Retry(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeInboxStore{entries: map[string]*InboxEntry{}}
+	msg := testIncomingMessage{testMessage{id: "msg-1"}}
+	_ = store.Complete(ctx, msg, "events")
+	_ = store.Fail(ctx, msg, "commands", fmt.Errorf("boom"))
+
+	published := make(publishedMessages, 1)
+	admin := NewInboxAdmin(store, published)
+
+	assert.Error(t, admin.Retry(ctx, "msg-1", "events"))
+	assert.NoError(t, admin.Retry(ctx, "msg-1", "commands"))
+	retried := <-published
+	assert.Equal(t, "msg-1", retried.ID())
+	// marked as replayed so that the stream does not drop it as a duplicate
+	assert.NotNil(t, retried.Metadata().Get(am.ReplayedAtHdr))
+}
+
+type publishedMessages chan am.Message
+
+func (p publishedMessages) Publish(_ context.Context, _ string, msg am.Message) error {
+	p <- msg
+	return nil
+}
@@ -0,0 +1,22 @@
+package tm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stackus/errors"
+)
+
+// registerCollector registers the collector, or returns the collector already
+// registered by another processor or job of the same service
+func registerCollector[T prometheus.Collector](registerer prometheus.Registerer, collector T) T {
+	if err := registerer.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+
+	return collector
+}
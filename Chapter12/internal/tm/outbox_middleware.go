@@ -2,18 +2,52 @@ package tm
 
 import (
 	"context"
+	"time"
 
 	"github.com/stackus/errors"
 
 	"eda-in-golang/internal/am"
 )
 
-type OutboxStore interface {
-	Save(ctx context.Context, msg am.Message) error
-	FindUnpublished(ctx context.Context, limit int) ([]am.Message, error)
-	MarkPublished(ctx context.Context, ids ...string) error
-}
+type (
+	OutboxStore interface {
+		Save(ctx context.Context, msg am.Message) error
+		// ProcessUnpublished claims up to limit of the oldest unpublished messages that
+		// are due and not parked, and hands each one to fn. A message is marked
+		// published when fn returns nil, otherwise the failure is recorded against it;
+		// messages claimed by other processors are skipped. It returns the number of
+		// messages processed
+		ProcessUnpublished(ctx context.Context, limit int, fn func(msg OutboxMessage) *OutboxFailure) (int, error)
+		// Stats reports on the messages that have not been published
+		Stats(ctx context.Context) (OutboxStats, error)
+	}
+
+	// OutboxMessage is an unpublished message claimed from the outbox
+	OutboxMessage interface {
+		am.Message
+		// Attempts is the number of earlier failed attempts to publish the message
+		Attempts() int
+	}
+
+	// OutboxFailure is recorded against a message that could not be published;
+	// a parked message is not tried again
+	OutboxFailure struct {
+		Err           error
+		NextAttemptAt time.Time
+		Parked        bool
+	}
+
+	OutboxStats struct {
+		Unpublished       int
+		Parked            int
+		OldestUnpublished time.Time
+	}
+)
 
+// OutboxPublisher saves messages to the outbox in place of publishing them. It
+// may be given to the event, command and reply publishers alike; the outbox
+// processor publishes each message to its subject, which those publishers set
+// to the topic, and holds messages from the DelayedPublisher until they are due
 func OutboxPublisher(store OutboxStore) am.MessagePublisherMiddleware {
 	return func(next am.MessagePublisher) am.MessagePublisher {
 		return am.MessagePublisherFunc(func(ctx context.Context, topicName string, msg am.Message) error {
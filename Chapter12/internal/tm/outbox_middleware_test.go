@@ -0,0 +1,77 @@
+package tm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+	"eda-in-golang/internal/registry"
+	"eda-in-golang/internal/registry/serdes"
+)
+
+type testCommandPayload struct {
+	OrderID string
+}
+
+func (testCommandPayload) Key() string { return "test.Command" }
+
+type publishedTopics chan string
+
+func (p publishedTopics) Publish(_ context.Context, topicName string, msg am.Message) error {
+	p <- topicName + ":" + msg.MessageName()
+	return nil
+}
+
+func TestOutboxPublisher_CommandsAndReplies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := registry.New()
+	if err := serdes.NewJsonSerde(reg).Register(testCommandPayload{}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &fakeOutboxStore{}
+	commands := am.NewCommandPublisher(reg, nil, OutboxPublisher(store))
+	replies := am.NewReplyPublisher(reg, nil, OutboxPublisher(store))
+	messages := am.MessagePublisherWithMiddleware(nil, OutboxPublisher(store))
+
+	assert.NoError(t, commands.Publish(ctx, "test.commands", ddd.NewCommand("test.Command", testCommandPayload{OrderID: "order-id"})))
+	assert.NoError(t, replies.Publish(ctx, "test.replies", ddd.NewReply(am.FailureReply, nil)))
+	assert.NoError(t, messages.Publish(ctx, "test.topic", testMessage{id: "msg-1"}))
+
+	published := make(publishedTopics, 3)
+	processor := NewOutboxProcessor("outbox_commands_test", published, store, zerolog.Nop(),
+		OutboxPollingInterval(10*time.Millisecond),
+		OutboxMetricsRegisterer(prometheus.NewRegistry()),
+	)
+	go func() { _ = processor.Start(ctx) }()
+
+	for _, expected := range []string{"test.commands:test.Command", "test.replies:" + am.FailureReply, "test.topic:test.Message"} {
+		select {
+		case got := <-published:
+			assert.Equal(t, expected, got)
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not published", expected)
+		}
+	}
+}
+
+func TestOutboxPublisher_KeepsDeliveryTime(t *testing.T) {
+	store := &fakeOutboxStore{}
+	publisher := am.MessagePublisherWithMiddleware(nil, am.DelayedPublisher(), OutboxPublisher(store))
+	deliverAt := time.Now().Add(time.Hour)
+
+	assert.NoError(t, publisher.Publish(am.DeliverAt(context.Background(), deliverAt), "test.topic", testMessage{id: "msg-1"}))
+
+	if assert.Len(t, store.pending, 1) {
+		assert.Equal(t, "test.topic", store.pending[0].Subject())
+		assert.Equal(t, deliverAt, am.MessageDeliverAt(store.pending[0]))
+	}
+}
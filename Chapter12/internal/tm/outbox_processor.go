@@ -2,67 +2,236 @@ package tm
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
 	"eda-in-golang/internal/am"
 )
 
-const messageLimit = 50
-const pollingInterval = 333 * time.Millisecond
+const defaultMessageLimit = 50
+const defaultPollingInterval = 333 * time.Millisecond
+const defaultMaxAttempts = 10
+const listenRetryInterval = time.Second
+const statsInterval = 15 * time.Second
+const restartMaxDelay = time.Minute
+
+var defaultOutboxRetryPolicy = am.ExponentialBackoff(time.Second, 5*time.Minute)
+
+// restartPolicy spaces out restarts after the processor fails; the count of
+// failures is reset once it has stayed up for the longest delay
+var restartPolicy = am.ExponentialBackoff(time.Second, restartMaxDelay)
+
+type (
+	OutboxProcessor interface {
+		Start(ctx context.Context) error
+	}
+
+	// OutboxNotifier tells the processor when messages have been saved to the outbox
+	OutboxNotifier interface {
+		// Listen calls notify each time messages have been saved until the context
+		// is done or the connection fails
+		Listen(ctx context.Context, notify func()) error
+	}
+
+	OutboxProcessorOption interface {
+		configureOutboxProcessor(*outboxProcessor)
+	}
+
+	// OutboxBatchSize is the most messages published for each read of the outbox
+	OutboxBatchSize int
+
+	// OutboxPollingInterval is how long the processor waits before looking for
+	// new messages again; with notifications it is only a fallback
+	OutboxPollingInterval time.Duration
 
-type OutboxProcessor interface {
-	Start(ctx context.Context) error
+	// OutboxMaxAttempts is how many times publishing a message may fail before
+	// the message is parked
+	OutboxMaxAttempts int
+
+	outboxNotifications struct {
+		notifier OutboxNotifier
+	}
+
+	outboxRetryPolicy struct {
+		policy am.RetryPolicy
+	}
+
+	outboxRegisterer struct {
+		registerer prometheus.Registerer
+	}
+
+	outboxProcessor struct {
+		publisher       am.MessagePublisher
+		store           OutboxStore
+		notifier        OutboxNotifier
+		messageLimit    int
+		pollingInterval time.Duration
+		maxAttempts     int
+		retryPolicy     am.RetryPolicy
+		registerer      prometheus.Registerer
+		unpublished     prometheus.Gauge
+		parked          prometheus.Gauge
+		lag             prometheus.Gauge
+		logger          zerolog.Logger
+	}
+)
+
+func NewOutboxProcessor(serviceName string, publisher am.MessagePublisher, store OutboxStore, logger zerolog.Logger, options ...OutboxProcessorOption) OutboxProcessor {
+	p := &outboxProcessor{
+		publisher:       publisher,
+		store:           store,
+		messageLimit:    defaultMessageLimit,
+		pollingInterval: defaultPollingInterval,
+		maxAttempts:     defaultMaxAttempts,
+		retryPolicy:     defaultOutboxRetryPolicy,
+		registerer:      prometheus.DefaultRegisterer,
+		logger:          logger,
+	}
+
+	for _, option := range options {
+		option.configureOutboxProcessor(p)
+	}
+
+	p.unpublished = registerCollector(p.registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: serviceName,
+		Name:      "outbox_unpublished_messages",
+		Help:      fmt.Sprintf("The number of messages waiting in the %s outbox", serviceName),
+	}))
+	p.parked = registerCollector(p.registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: serviceName,
+		Name:      "outbox_parked_messages",
+		Help:      fmt.Sprintf("The number of messages parked in the %s outbox after too many failed attempts", serviceName),
+	}))
+	p.lag = registerCollector(p.registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: serviceName,
+		Name:      "outbox_lag_seconds",
+		Help:      fmt.Sprintf("The age of the oldest message waiting in the %s outbox", serviceName),
+	}))
+
+	return p
+}
+
+// OutboxNotifications wakes the processor as soon as messages are saved
+func OutboxNotifications(notifier OutboxNotifier) OutboxProcessorOption {
+	return outboxNotifications{notifier: notifier}
 }
 
-type outboxProcessor struct {
-	publisher am.MessagePublisher
-	store     OutboxStore
+// OutboxRetryPolicy decides how long a message waits after each failed attempt
+func OutboxRetryPolicy(policy am.RetryPolicy) OutboxProcessorOption {
+	return outboxRetryPolicy{policy: policy}
 }
 
-func NewOutboxProcessor(publisher am.MessagePublisher, store OutboxStore) OutboxProcessor {
-	return outboxProcessor{
-		publisher: publisher,
-		store:     store,
+// OutboxMetricsRegisterer registers the outbox gauges somewhere other than the
+// default prometheus registerer
+func OutboxMetricsRegisterer(registerer prometheus.Registerer) OutboxProcessorOption {
+	return outboxRegisterer{registerer: registerer}
+}
+
+func (s OutboxBatchSize) configureOutboxProcessor(p *outboxProcessor) {
+	if s > 0 {
+		p.messageLimit = int(s)
 	}
 }
 
-func (p outboxProcessor) Start(ctx context.Context) error {
-	errC := make(chan error)
+func (i OutboxPollingInterval) configureOutboxProcessor(p *outboxProcessor) {
+	if i > 0 {
+		p.pollingInterval = time.Duration(i)
+	}
+}
 
-	go func() {
-		errC <- p.processMessages(ctx)
-	}()
+func (a OutboxMaxAttempts) configureOutboxProcessor(p *outboxProcessor) {
+	if a > 0 {
+		p.maxAttempts = int(a)
+	}
+}
+
+func (n outboxNotifications) configureOutboxProcessor(p *outboxProcessor) {
+	p.notifier = n.notifier
+}
+
+func (r outboxRetryPolicy) configureOutboxProcessor(p *outboxProcessor) {
+	if r.policy != nil {
+		p.retryPolicy = r.policy
+	}
+}
 
-	select {
-	case <-ctx.Done():
-		return nil
-	case err := <-errC:
-		return err
+func (r outboxRegisterer) configureOutboxProcessor(p *outboxProcessor) {
+	if r.registerer != nil {
+		p.registerer = r.registerer
 	}
 }
 
-func (p outboxProcessor) processMessages(ctx context.Context) error {
+// Start publishes messages until the context is done; the processor is
+// restarted after it fails
+func (p *outboxProcessor) Start(ctx context.Context) error {
+	wake := make(chan struct{}, 1)
+	if p.notifier != nil {
+		go p.listen(ctx, wake)
+	}
+
+	failures := 0
+	for {
+		startedAt := time.Now()
+		err := p.processMessages(ctx, wake)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if time.Since(startedAt) > restartMaxDelay {
+			failures = 0
+		}
+		failures++
+
+		delay := restartPolicy.Backoff(failures)
+		p.logger.Error().Err(err).Msgf("outbox processor failed; restarting in %s", delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// listen keeps a listener running; the processor goes on polling while the
+// listener is reconnecting
+func (p *outboxProcessor) listen(ctx context.Context, wake chan<- struct{}) {
+	notify := func() {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		_ = p.notifier.Listen(ctx, notify)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (p *outboxProcessor) processMessages(ctx context.Context, wake <-chan struct{}) error {
+	var statsAt time.Time
 	timer := time.NewTimer(0)
 	for {
-		msgs, err := p.store.FindUnpublished(ctx, messageLimit)
+		processed, err := p.store.ProcessUnpublished(ctx, p.messageLimit, p.publishMessage(ctx))
 		if err != nil {
 			return err
 		}
 
-		if len(msgs) > 0 {
-			ids := make([]string, len(msgs))
-			for i, msg := range msgs {
-				ids[i] = msg.ID()
-				err = p.publisher.Publish(ctx, msg.Subject(), msg)
-				if err != nil {
-					return err
-				}
-			}
-			err = p.store.MarkPublished(ctx, ids...)
-			if err != nil {
-				return err
-			}
+		if time.Since(statsAt) >= statsInterval {
+			p.updateStats(ctx)
+			statsAt = time.Now()
+		}
 
+		if processed > 0 {
 			// poll again immediately
 			continue
 		}
@@ -74,13 +243,55 @@ func (p outboxProcessor) processMessages(ctx context.Context) error {
 			}
 		}
 
-		// wait a short time before polling again
-		timer.Reset(pollingInterval)
+		// wait until notified, or a short time, before polling again
+		timer.Reset(p.pollingInterval)
 
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-wake:
 		case <-timer.C:
 		}
 	}
 }
+
+func (p *outboxProcessor) publishMessage(ctx context.Context) func(OutboxMessage) *OutboxFailure {
+	return func(msg OutboxMessage) *OutboxFailure {
+		err := p.publisher.Publish(ctx, msg.Subject(), msg)
+		if err == nil {
+			return nil
+		}
+
+		attempts := msg.Attempts() + 1
+		if attempts >= p.maxAttempts {
+			p.logger.Error().Err(err).
+				Str("MessageID", msg.ID()).
+				Int("Attempts", attempts).
+				Msg("parking outbox message after too many failed attempts")
+			return &OutboxFailure{Err: err, Parked: true}
+		}
+
+		return &OutboxFailure{
+			Err:           err,
+			NextAttemptAt: time.Now().Add(p.retryPolicy.Backoff(attempts)),
+		}
+	}
+}
+
+func (p *outboxProcessor) updateStats(ctx context.Context) {
+	stats, err := p.store.Stats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Error().Err(err).Msg("failed to read the outbox stats")
+		}
+		return
+	}
+
+	p.unpublished.Set(float64(stats.Unpublished))
+	p.parked.Set(float64(stats.Parked))
+	if stats.OldestUnpublished.IsZero() {
+		p.lag.Set(0)
+	} else {
+		p.lag.Set(time.Since(stats.OldestUnpublished).Seconds())
+	}
+}
@@ -0,0 +1,191 @@
+package tm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+	"eda-in-golang/internal/ddd"
+)
+
+type testMessage struct {
+	id string
+}
+
+func (m testMessage) ID() string             { return m.id }
+func (m testMessage) Subject() string        { return "test.topic" }
+func (m testMessage) MessageName() string    { return "test.Message" }
+func (m testMessage) Data() []byte           { return nil }
+func (m testMessage) Metadata() ddd.Metadata { return ddd.Metadata{} }
+func (m testMessage) SentAt() time.Time      { return time.Time{} }
+
+type fakeOutboxStore struct {
+	mu       sync.Mutex
+	pending  []am.Message
+	attempts map[string]int
+	parked   []string
+}
+
+type fakeOutboxMessage struct {
+	am.Message
+	attempts int
+}
+
+func (m fakeOutboxMessage) Attempts() int { return m.attempts }
+
+func (s *fakeOutboxStore) Save(_ context.Context, msg am.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, msg)
+	return nil
+}
+
+func (s *fakeOutboxStore) ProcessUnpublished(_ context.Context, limit int, fn func(OutboxMessage) *OutboxFailure) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attempts == nil {
+		s.attempts = make(map[string]int)
+	}
+	if len(s.pending) < limit {
+		limit = len(s.pending)
+	}
+	var remaining []am.Message
+	for _, msg := range s.pending[:limit] {
+		failure := fn(fakeOutboxMessage{Message: msg, attempts: s.attempts[msg.ID()]})
+		switch {
+		case failure == nil:
+		case failure.Parked:
+			s.parked = append(s.parked, msg.ID())
+		default:
+			s.attempts[msg.ID()]++
+			remaining = append(remaining, msg)
+		}
+	}
+	s.pending = append(remaining, s.pending[limit:]...)
+	return limit, nil
+}
+
+func (s *fakeOutboxStore) Stats(context.Context) (OutboxStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return OutboxStats{Unpublished: len(s.pending), Parked: len(s.parked)}, nil
+}
+
+type fakeNotifier struct {
+	notify chan func()
+}
+
+func (n fakeNotifier) Listen(ctx context.Context, notify func()) error {
+	n.notify <- notify
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type publishedIDs chan string
+
+func (p publishedIDs) Publish(_ context.Context, _ string, msg am.Message) error {
+	p <- msg.ID()
+	return nil
+}
+
+func TestOutboxProcessor_Notifications(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &fakeOutboxStore{}
+	notifier := fakeNotifier{notify: make(chan func(), 1)}
+	published := make(publishedIDs, 10)
+
+	processor := NewOutboxProcessor("outbox_notifications_test", published, store, zerolog.Nop(),
+		OutboxBatchSize(2),
+		OutboxPollingInterval(time.Hour),
+		OutboxNotifications(notifier),
+		OutboxMetricsRegisterer(prometheus.NewRegistry()),
+	)
+	go func() { _ = processor.Start(ctx) }()
+
+	notify := <-notifier.notify
+
+	for _, id := range []string{"msg-1", "msg-2", "msg-3"} {
+		_ = store.Save(ctx, testMessage{id: id})
+	}
+	notify()
+
+	for _, id := range []string{"msg-1", "msg-2", "msg-3"} {
+		select {
+		case got := <-published:
+			assert.Equal(t, id, got)
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not published", id)
+		}
+	}
+}
+
+type failingPublisher struct {
+	failing   string
+	published publishedIDs
+}
+
+func (p failingPublisher) Publish(ctx context.Context, topicName string, msg am.Message) error {
+	if msg.ID() == p.failing {
+		return fmt.Errorf("cannot publish %s", msg.ID())
+	}
+	return p.published.Publish(ctx, topicName, msg)
+}
+
+func TestOutboxProcessor_ParksFailingMessages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &fakeOutboxStore{}
+	for _, id := range []string{"msg-1", "poison", "msg-2"} {
+		_ = store.Save(ctx, testMessage{id: id})
+	}
+	published := make(publishedIDs, 10)
+
+	processor := NewOutboxProcessor("outbox_parking_test", failingPublisher{failing: "poison", published: published}, store, zerolog.Nop(),
+		OutboxMaxAttempts(3),
+		OutboxRetryPolicy(am.ConstantBackoff(0)),
+		OutboxPollingInterval(time.Millisecond),
+		OutboxMetricsRegisterer(prometheus.NewRegistry()),
+	)
+	go func() { _ = processor.Start(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.parked) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// the poison message does not hold up the others
+	assert.Equal(t, "msg-1", <-published)
+	assert.Equal(t, "msg-2", <-published)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, []string{"poison"}, store.parked)
+	assert.Equal(t, 2, store.attempts["poison"])
+	assert.Empty(t, store.pending)
+}
+
+func TestOutboxProcessor_SharesServiceMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	first := NewOutboxProcessor("outbox_metrics_test", make(publishedIDs), &fakeOutboxStore{}, zerolog.Nop(),
+		OutboxMetricsRegisterer(registry),
+	).(*outboxProcessor)
+	second := NewOutboxProcessor("outbox_metrics_test", make(publishedIDs), &fakeOutboxStore{}, zerolog.Nop(),
+		OutboxMetricsRegisterer(registry),
+	).(*outboxProcessor)
+
+	assert.Same(t, first.unpublished, second.unpublished)
+	assert.Same(t, first.parked, second.parked)
+	assert.Same(t, first.lag, second.lag)
+}
@@ -0,0 +1,157 @@
+package tm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"eda-in-golang/internal/am"
+)
+
+const defaultRetentionInterval = time.Hour
+const defaultRetentionBatchSize = 1000
+
+type (
+	// RetentionStore removes the rows of an outbox or inbox that are no longer needed
+	RetentionStore interface {
+		// Purge deletes up to limit of the rows older than the cutoff and returns the
+		// number deleted. The rows are handed to archive, when it is not nil, and
+		// are kept should it fail
+		Purge(ctx context.Context, cutoff time.Time, limit int, archive func(msgs []am.Message) error) (int, error)
+	}
+
+	// Archiver keeps purged messages somewhere other than the database
+	Archiver interface {
+		Archive(ctx context.Context, name string, msgs []am.Message) error
+	}
+
+	// RetentionPolicy purges the rows from a store once they reach the max age;
+	// a policy with no max age keeps every row
+	RetentionPolicy struct {
+		Name     string
+		Store    RetentionStore
+		MaxAge   time.Duration
+		Archiver Archiver
+	}
+
+	RetentionOption interface {
+		configureRetentionJob(*RetentionJob)
+	}
+
+	// RetentionInterval is how long the job waits between purges
+	RetentionInterval time.Duration
+
+	// RetentionBatchSize is the most rows deleted by each statement
+	RetentionBatchSize int
+
+	retentionRegisterer struct {
+		registerer prometheus.Registerer
+	}
+
+	RetentionJob struct {
+		policies   []RetentionPolicy
+		interval   time.Duration
+		batchSize  int
+		registerer prometheus.Registerer
+		purged     *prometheus.CounterVec
+		logger     zerolog.Logger
+	}
+)
+
+func NewRetentionJob(serviceName string, logger zerolog.Logger, policies []RetentionPolicy, options ...RetentionOption) *RetentionJob {
+	j := &RetentionJob{
+		policies:   policies,
+		interval:   defaultRetentionInterval,
+		batchSize:  defaultRetentionBatchSize,
+		registerer: prometheus.DefaultRegisterer,
+		logger:     logger,
+	}
+
+	for _, option := range options {
+		option.configureRetentionJob(j)
+	}
+
+	j.purged = registerCollector(j.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: serviceName,
+		Name:      "retention_purged_rows_count",
+		Help:      fmt.Sprintf("The total number of outbox and inbox rows purged by %s", serviceName),
+	}, []string{"policy", "archived"}))
+
+	return j
+}
+
+// RetentionMetricsRegisterer registers the retention counter somewhere other
+// than the default prometheus registerer
+func RetentionMetricsRegisterer(registerer prometheus.Registerer) RetentionOption {
+	return retentionRegisterer{registerer: registerer}
+}
+
+func (i RetentionInterval) configureRetentionJob(j *RetentionJob) {
+	if i > 0 {
+		j.interval = time.Duration(i)
+	}
+}
+
+func (s RetentionBatchSize) configureRetentionJob(j *RetentionJob) {
+	if s > 0 {
+		j.batchSize = int(s)
+	}
+}
+
+func (r retentionRegisterer) configureRetentionJob(j *RetentionJob) {
+	if r.registerer != nil {
+		j.registerer = r.registerer
+	}
+}
+
+// Run purges the stores each interval until the context is done; it is meant
+// to be added to the waiter
+func (j *RetentionJob) Run(ctx context.Context) error {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, policy := range j.policies {
+			if policy.MaxAge <= 0 {
+				continue
+			}
+			if err := j.purge(ctx, policy); err != nil && ctx.Err() == nil {
+				j.logger.Error().Err(err).Msgf("failed to purge the %s", policy.Name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RetentionJob) purge(ctx context.Context, policy RetentionPolicy) error {
+	cutoff := time.Now().Add(-policy.MaxAge)
+
+	var archive func([]am.Message) error
+	if policy.Archiver != nil {
+		archive = func(msgs []am.Message) error {
+			return policy.Archiver.Archive(ctx, policy.Name, msgs)
+		}
+	}
+	archived := fmt.Sprintf("%t", archive != nil)
+
+	for {
+		purged, err := policy.Store.Purge(ctx, cutoff, j.batchSize, archive)
+		if err != nil {
+			return err
+		}
+
+		j.purged.WithLabelValues(policy.Name, archived).Add(float64(purged))
+
+		if purged < j.batchSize {
+			return nil
+		}
+	}
+}
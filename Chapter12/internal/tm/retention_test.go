@@ -0,0 +1,113 @@
+package tm
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"eda-in-golang/internal/am"
+)
+
+type fakeRetentionStore struct {
+	mu      sync.Mutex
+	rows    []am.Message
+	cutoffs []time.Time
+}
+
+func (s *fakeRetentionStore) Purge(_ context.Context, cutoff time.Time, limit int, archive func([]am.Message) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cutoffs = append(s.cutoffs, cutoff)
+	if len(s.rows) < limit {
+		limit = len(s.rows)
+	}
+	if archive != nil && limit > 0 {
+		if err := archive(s.rows[:limit]); err != nil {
+			return 0, err
+		}
+	}
+	s.rows = s.rows[limit:]
+
+	return limit, nil
+}
+
+func TestRetentionJob_PurgesInBatchesAndArchives(t *testing.T) {
+	dir := t.TempDir()
+	store := &fakeRetentionStore{}
+	for _, id := range []string{"msg-1", "msg-2", "msg-3", "msg-4", "msg-5"} {
+		store.rows = append(store.rows, testMessage{id: id})
+	}
+	kept := &fakeRetentionStore{rows: []am.Message{testMessage{id: "kept"}}}
+
+	job := NewRetentionJob("retention_test", zerolog.Nop(), []RetentionPolicy{
+		{Name: "outbox", Store: store, MaxAge: time.Hour, Archiver: NewFileArchiver(dir)},
+		{Name: "inbox", Store: kept},
+	}, RetentionBatchSize(2), RetentionInterval(time.Hour), RetentionMetricsRegisterer(prometheus.NewRegistry()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = job.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.rows) == 0
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	// batches of 2, 2 and 1 rows
+	assert.Len(t, store.cutoffs, 3)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), store.cutoffs[0], time.Second)
+	// a policy without a max age keeps its rows
+	assert.Len(t, kept.rows, 1)
+
+	files, err := filepath.Glob(filepath.Join(dir, "outbox-*.jsonl.gz"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	var ids []string
+	for _, fileName := range files {
+		ids = append(ids, readArchive(t, fileName)...)
+	}
+	assert.ElementsMatch(t, []string{"msg-1", "msg-2", "msg-3", "msg-4", "msg-5"}, ids)
+}
+
+func readArchive(t *testing.T, fileName string) []string {
+	t.Helper()
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var msg archivedMessage
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		ids = append(ids, msg.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE baskets.events
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+ALTER TABLE baskets.snapshots
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+
+ALTER TABLE ordering.events
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+ALTER TABLE ordering.snapshots
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+
+ALTER TABLE stores.events
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+ALTER TABLE stores.snapshots
+  ADD COLUMN schema_version int NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE stores.snapshots
+  DROP COLUMN IF EXISTS schema_version;
+ALTER TABLE stores.events
+  DROP COLUMN IF EXISTS schema_version;
+
+ALTER TABLE ordering.snapshots
+  DROP COLUMN IF EXISTS schema_version;
+ALTER TABLE ordering.events
+  DROP COLUMN IF EXISTS schema_version;
+
+ALTER TABLE baskets.snapshots
+  DROP COLUMN IF EXISTS schema_version;
+ALTER TABLE baskets.events
+  DROP COLUMN IF EXISTS schema_version;
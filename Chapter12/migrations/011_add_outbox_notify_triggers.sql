@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION public.outbox_notify_trigger()
+  RETURNS TRIGGER AS
+$$
+BEGIN
+  PERFORM pg_notify(TG_TABLE_SCHEMA || '.' || TG_TABLE_NAME, '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON baskets.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON cosec.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON customers.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON depot.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON notifications.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON ordering.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON payments.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON search.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+CREATE TRIGGER outbox_notify_trgr
+  AFTER INSERT
+  ON stores.outbox
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON baskets.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON cosec.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON customers.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON depot.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON notifications.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON ordering.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON payments.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON search.outbox;
+DROP TRIGGER IF EXISTS outbox_notify_trgr ON stores.outbox;
+
+DROP FUNCTION IF EXISTS public.outbox_notify_trigger();
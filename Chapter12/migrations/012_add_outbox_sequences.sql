@@ -0,0 +1,65 @@
+-- +goose Up
+ALTER TABLE baskets.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX baskets_unpublished_seq_idx ON baskets.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE cosec.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX cosec_unpublished_seq_idx ON cosec.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE customers.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX customers_unpublished_seq_idx ON customers.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE depot.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX depot_unpublished_seq_idx ON depot.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE notifications.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX notifications_unpublished_seq_idx ON notifications.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE ordering.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX ordering_unpublished_seq_idx ON ordering.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE payments.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX payments_unpublished_seq_idx ON payments.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE search.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX search_unpublished_seq_idx ON search.outbox (seq) WHERE published_at IS NULL;
+
+ALTER TABLE stores.outbox
+  ADD COLUMN seq bigserial NOT NULL;
+CREATE INDEX stores_unpublished_seq_idx ON stores.outbox (seq) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS baskets.baskets_unpublished_seq_idx;
+ALTER TABLE baskets.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS cosec.cosec_unpublished_seq_idx;
+ALTER TABLE cosec.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS customers.customers_unpublished_seq_idx;
+ALTER TABLE customers.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS depot.depot_unpublished_seq_idx;
+ALTER TABLE depot.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS notifications.notifications_unpublished_seq_idx;
+ALTER TABLE notifications.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS ordering.ordering_unpublished_seq_idx;
+ALTER TABLE ordering.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS payments.payments_unpublished_seq_idx;
+ALTER TABLE payments.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS search.search_unpublished_seq_idx;
+ALTER TABLE search.outbox
+  DROP COLUMN IF EXISTS seq;
+DROP INDEX IF EXISTS stores.stores_unpublished_seq_idx;
+ALTER TABLE stores.outbox
+  DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,101 @@
+-- +goose Up
+ALTER TABLE baskets.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE cosec.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE customers.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE depot.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE notifications.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE ordering.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE payments.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE search.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+ALTER TABLE stores.outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+-- +goose Down
+ALTER TABLE baskets.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE cosec.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE customers.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE depot.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE notifications.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE ordering.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE payments.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE search.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
+ALTER TABLE stores.outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
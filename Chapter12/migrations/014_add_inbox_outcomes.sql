@@ -0,0 +1,182 @@
+-- +goose Up
+ALTER TABLE baskets.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX baskets_inbox_failed_idx ON baskets.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE cosec.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX cosec_inbox_failed_idx ON cosec.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE customers.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX customers_inbox_failed_idx ON customers.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE depot.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX depot_inbox_failed_idx ON depot.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE notifications.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX notifications_inbox_failed_idx ON notifications.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE ordering.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX ordering_inbox_failed_idx ON ordering.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE payments.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX payments_inbox_failed_idx ON payments.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE search.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX search_inbox_failed_idx ON search.inbox (updated_at) WHERE status = 'failed';
+
+ALTER TABLE stores.inbox
+  ADD COLUMN handler text NOT NULL DEFAULT '',
+  ADD COLUMN status text NOT NULL DEFAULT 'completed',
+  ADD COLUMN attempts int NOT NULL DEFAULT 1,
+  ADD COLUMN last_error text,
+  ADD COLUMN updated_at timestamptz,
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id, handler);
+CREATE INDEX stores_inbox_failed_idx ON stores.inbox (updated_at) WHERE status = 'failed';
+
+-- +goose Down
+DROP INDEX IF EXISTS baskets.baskets_inbox_failed_idx;
+DELETE FROM baskets.inbox a USING baskets.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE baskets.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS cosec.cosec_inbox_failed_idx;
+DELETE FROM cosec.inbox a USING cosec.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE cosec.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS customers.customers_inbox_failed_idx;
+DELETE FROM customers.inbox a USING customers.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE customers.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS depot.depot_inbox_failed_idx;
+DELETE FROM depot.inbox a USING depot.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE depot.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS notifications.notifications_inbox_failed_idx;
+DELETE FROM notifications.inbox a USING notifications.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE notifications.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS ordering.ordering_inbox_failed_idx;
+DELETE FROM ordering.inbox a USING ordering.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE ordering.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS payments.payments_inbox_failed_idx;
+DELETE FROM payments.inbox a USING payments.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE payments.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS search.search_inbox_failed_idx;
+DELETE FROM search.inbox a USING search.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE search.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
+DROP INDEX IF EXISTS stores.stores_inbox_failed_idx;
+DELETE FROM stores.inbox a USING stores.inbox b WHERE a.id = b.id AND a.handler > b.handler;
+ALTER TABLE stores.inbox
+  DROP CONSTRAINT inbox_pkey,
+  ADD PRIMARY KEY (id),
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts,
+  DROP COLUMN IF EXISTS status,
+  DROP COLUMN IF EXISTS handler;
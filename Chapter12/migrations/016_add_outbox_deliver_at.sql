@@ -0,0 +1,47 @@
+-- +goose Up
+ALTER TABLE baskets.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE cosec.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE customers.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE depot.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE notifications.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE ordering.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE payments.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE search.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+ALTER TABLE stores.outbox
+  ADD COLUMN deliver_at timestamptz;
+
+-- +goose Down
+ALTER TABLE baskets.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE cosec.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE customers.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE depot.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE notifications.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE ordering.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE payments.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE search.outbox
+  DROP COLUMN IF EXISTS deliver_at;
+ALTER TABLE stores.outbox
+  DROP COLUMN IF EXISTS deliver_at;
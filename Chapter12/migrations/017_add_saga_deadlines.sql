@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE cosec.sagas
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN deadline timestamptz;
+CREATE INDEX cosec_sagas_deadline_idx ON cosec.sagas (deadline) WHERE NOT done AND deadline IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS cosec.cosec_sagas_deadline_idx;
+ALTER TABLE cosec.sagas
+  DROP COLUMN IF EXISTS deadline,
+  DROP COLUMN IF EXISTS attempts;
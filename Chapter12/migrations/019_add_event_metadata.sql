@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE baskets.events
+  ADD COLUMN metadata bytea NOT NULL DEFAULT '{}';
+
+ALTER TABLE ordering.events
+  ADD COLUMN metadata bytea NOT NULL DEFAULT '{}';
+
+ALTER TABLE stores.events
+  ADD COLUMN metadata bytea NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE baskets.events
+  DROP COLUMN IF EXISTS metadata;
+ALTER TABLE ordering.events
+  DROP COLUMN IF EXISTS metadata;
+ALTER TABLE stores.events
+  DROP COLUMN IF EXISTS metadata;
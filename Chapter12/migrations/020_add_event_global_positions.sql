@@ -0,0 +1,97 @@
+-- +goose Up
+-- the existing events are numbered in the order they occurred, not the order
+-- they happen to be stored in, and are placed before any later transaction
+ALTER TABLE baskets.events
+  ADD COLUMN global_position bigint,
+  ADD COLUMN transaction_id  xid8;
+CREATE SEQUENCE baskets.events_global_position_seq OWNED BY baskets.events.global_position;
+UPDATE baskets.events e
+SET global_position = o.global_position,
+    transaction_id  = '0'::xid8
+FROM (SELECT stream_id, stream_name, stream_version,
+             row_number() OVER (ORDER BY occurred_at, stream_version, stream_id) AS global_position
+      FROM baskets.events) o
+WHERE (e.stream_id, e.stream_name, e.stream_version) = (o.stream_id, o.stream_name, o.stream_version);
+SELECT setval('baskets.events_global_position_seq', coalesce(max(global_position), 0) + 1, false) FROM baskets.events;
+ALTER TABLE baskets.events
+  ALTER COLUMN global_position SET DEFAULT nextval('baskets.events_global_position_seq'),
+  ALTER COLUMN global_position SET NOT NULL,
+  ALTER COLUMN transaction_id SET DEFAULT pg_current_xact_id(),
+  ALTER COLUMN transaction_id SET NOT NULL;
+CREATE UNIQUE INDEX baskets_events_global_position_idx ON baskets.events (global_position);
+CREATE INDEX baskets_events_stream_order_idx ON baskets.events (transaction_id, global_position);
+
+CREATE TRIGGER events_notify_trgr
+  AFTER INSERT
+  ON baskets.events
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+ALTER TABLE ordering.events
+  ADD COLUMN global_position bigint,
+  ADD COLUMN transaction_id  xid8;
+CREATE SEQUENCE ordering.events_global_position_seq OWNED BY ordering.events.global_position;
+UPDATE ordering.events e
+SET global_position = o.global_position,
+    transaction_id  = '0'::xid8
+FROM (SELECT stream_id, stream_name, stream_version,
+             row_number() OVER (ORDER BY occurred_at, stream_version, stream_id) AS global_position
+      FROM ordering.events) o
+WHERE (e.stream_id, e.stream_name, e.stream_version) = (o.stream_id, o.stream_name, o.stream_version);
+SELECT setval('ordering.events_global_position_seq', coalesce(max(global_position), 0) + 1, false) FROM ordering.events;
+ALTER TABLE ordering.events
+  ALTER COLUMN global_position SET DEFAULT nextval('ordering.events_global_position_seq'),
+  ALTER COLUMN global_position SET NOT NULL,
+  ALTER COLUMN transaction_id SET DEFAULT pg_current_xact_id(),
+  ALTER COLUMN transaction_id SET NOT NULL;
+CREATE UNIQUE INDEX ordering_events_global_position_idx ON ordering.events (global_position);
+CREATE INDEX ordering_events_stream_order_idx ON ordering.events (transaction_id, global_position);
+
+CREATE TRIGGER events_notify_trgr
+  AFTER INSERT
+  ON ordering.events
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+ALTER TABLE stores.events
+  ADD COLUMN global_position bigint,
+  ADD COLUMN transaction_id  xid8;
+CREATE SEQUENCE stores.events_global_position_seq OWNED BY stores.events.global_position;
+UPDATE stores.events e
+SET global_position = o.global_position,
+    transaction_id  = '0'::xid8
+FROM (SELECT stream_id, stream_name, stream_version,
+             row_number() OVER (ORDER BY occurred_at, stream_version, stream_id) AS global_position
+      FROM stores.events) o
+WHERE (e.stream_id, e.stream_name, e.stream_version) = (o.stream_id, o.stream_name, o.stream_version);
+SELECT setval('stores.events_global_position_seq', coalesce(max(global_position), 0) + 1, false) FROM stores.events;
+ALTER TABLE stores.events
+  ALTER COLUMN global_position SET DEFAULT nextval('stores.events_global_position_seq'),
+  ALTER COLUMN global_position SET NOT NULL,
+  ALTER COLUMN transaction_id SET DEFAULT pg_current_xact_id(),
+  ALTER COLUMN transaction_id SET NOT NULL;
+CREATE UNIQUE INDEX stores_events_global_position_idx ON stores.events (global_position);
+CREATE INDEX stores_events_stream_order_idx ON stores.events (transaction_id, global_position);
+
+CREATE TRIGGER events_notify_trgr
+  AFTER INSERT
+  ON stores.events
+  FOR EACH STATEMENT EXECUTE PROCEDURE public.outbox_notify_trigger();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS events_notify_trgr ON baskets.events;
+DROP INDEX IF EXISTS baskets.baskets_events_stream_order_idx;
+DROP INDEX IF EXISTS baskets.baskets_events_global_position_idx;
+ALTER TABLE baskets.events
+  DROP COLUMN IF EXISTS transaction_id,
+  DROP COLUMN IF EXISTS global_position;
+DROP TRIGGER IF EXISTS events_notify_trgr ON ordering.events;
+DROP INDEX IF EXISTS ordering.ordering_events_stream_order_idx;
+DROP INDEX IF EXISTS ordering.ordering_events_global_position_idx;
+ALTER TABLE ordering.events
+  DROP COLUMN IF EXISTS transaction_id,
+  DROP COLUMN IF EXISTS global_position;
+DROP TRIGGER IF EXISTS events_notify_trgr ON stores.events;
+DROP INDEX IF EXISTS stores.stores_events_stream_order_idx;
+DROP INDEX IF EXISTS stores.stores_events_global_position_idx;
+ALTER TABLE stores.events
+  DROP COLUMN IF EXISTS transaction_id,
+  DROP COLUMN IF EXISTS global_position;
@@ -0,0 +1,59 @@
+package migrations_test
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+
+	baskets "eda-in-golang/baskets/migrations"
+	cosec "eda-in-golang/cosec/migrations"
+	customers "eda-in-golang/customers/migrations"
+	depot "eda-in-golang/depot/migrations"
+	"eda-in-golang/migrations"
+	notifications "eda-in-golang/notifications/migrations"
+	ordering "eda-in-golang/ordering/migrations"
+	payments "eda-in-golang/payments/migrations"
+	search "eda-in-golang/search/migrations"
+	stores "eda-in-golang/stores/migrations"
+)
+
+func TestMigrations_UniqueVersions(t *testing.T) {
+	tests := map[string]fs.FS{
+		"mallbots":      migrations.FS,
+		"baskets":       baskets.FS,
+		"cosec":         cosec.FS,
+		"customers":     customers.FS,
+		"depot":         depot.FS,
+		"notifications": notifications.FS,
+		"ordering":      ordering.FS,
+		"payments":      payments.FS,
+		"search":        search.FS,
+		"stores":        stores.FS,
+	}
+	for name, migrationsFS := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := collect(migrationsFS); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// collect runs the same collection MigrateDB does, which panics when two
+// migrations share a version
+func collect(migrationsFS fs.FS) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+
+	goose.SetBaseFS(migrationsFS)
+	defer goose.SetBaseFS(nil)
+
+	_, err = goose.CollectMigrations(".", 0, goose.MaxVersion)
+
+	return err
+}
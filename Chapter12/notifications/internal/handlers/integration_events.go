@@ -30,11 +30,22 @@ func NewIntegrationEventHandlers(reg registry.Registry, app application.App, cus
 	}, mws...)
 }
 
+// Customer changes are fetched in batches and each batch is cached in a single
+// transaction
+//
+// The pull consumer uses a new durable name as the existing push consumer
+// cannot be turned into a pull consumer. It starts from the beginning of the
+// stream, the inbox skips what was handled already, and the old
+// "notification-customers" consumer may be deleted once it is no longer in use:
+//
+//	nats consumer rm mallbots notification-customers
+const customerBatchSize = 50
+
 func RegisterIntegrationEventHandlers(subscriber am.MessageSubscriber, handlers am.MessageHandler) (err error) {
 	_, err = subscriber.Subscribe(customerspb.CustomerAggregateChannel, handlers, am.MessageFilter{
 		customerspb.CustomerRegisteredEvent,
 		customerspb.CustomerSmsChangedEvent,
-	}, am.GroupName("notification-customers"))
+	}, am.GroupName("notification-customers-batch"), am.PullBatchSize(customerBatchSize))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+
+	"eda-in-golang/internal/am"
+)
+
+// txMessageHandler handles each message, or each batch of messages, within a
+// single database transaction
+type txMessageHandler struct {
+	db       *sql.DB
+	handlers func(tx *sql.Tx) am.MessageHandler
+}
+
+var _ am.MessageHandler = (*txMessageHandler)(nil)
+var _ am.BatchMessageHandler = (*txMessageHandler)(nil)
+
+// NewIntegrationEventHandlersTx returns handlers that begin a transaction for
+// each message, or batch, and use the handlers built for that transaction
+func NewIntegrationEventHandlersTx(db *sql.DB, handlers func(tx *sql.Tx) am.MessageHandler) am.MessageHandler {
+	return txMessageHandler{
+		db:       db,
+		handlers: handlers,
+	}
+}
+
+func (h txMessageHandler) HandleMessage(ctx context.Context, msg am.IncomingMessage) error {
+	return h.HandleMessages(ctx, []am.IncomingMessage{msg})
+}
+
+func (h txMessageHandler) HandleMessages(ctx context.Context, msgs []am.IncomingMessage) (err error) {
+	var tx *sql.Tx
+	tx, err = h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	handlers := h.handlers(tx)
+	for _, msg := range msgs {
+		if err = handlers.HandleMessage(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
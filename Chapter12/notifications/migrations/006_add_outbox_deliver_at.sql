@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE outbox
+  ADD COLUMN deliver_at timestamptz;
+
+-- +goose Down
+ALTER TABLE outbox
+  DROP COLUMN IF EXISTS deliver_at;
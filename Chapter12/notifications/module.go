@@ -2,12 +2,12 @@ package notifications
 
 import (
 	"context"
+	"database/sql"
 
 	"eda-in-golang/customers/customerspb"
 	"eda-in-golang/internal/am"
 	"eda-in-golang/internal/amotel"
 	"eda-in-golang/internal/amprom"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -36,9 +36,8 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	if err = orderingpb.Registrations(reg); err != nil {
 		return err
 	}
-	inboxStore := pg.NewInboxStore(constants.InboxTableName, svc.DB())
 	messageSubscriber := am.NewMessageSubscriber(
-		jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger()),
+		svc.Stream(),
 		amotel.OtelMessageContextExtractor(),
 		amprom.ReceivedMessagesCounter(constants.ServiceName),
 	)
@@ -50,10 +49,17 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 
 	// setup application
 	app := application.New(customers)
-	integrationEventHandlers := handlers.NewIntegrationEventHandlers(
-		reg, app, customers,
-		tm.InboxHandler(inboxStore),
-	)
+	integrationEventHandlers := handlers.NewIntegrationEventHandlersTx(svc.DB(), func(tx *sql.Tx) am.MessageHandler {
+		customers := postgres.NewCustomerCacheRepository(
+			constants.CustomersCacheTableName,
+			postgresotel.Trace(tx),
+			grpc.NewCustomerRepository(svc.Config().Rpc.Service(constants.CustomersServiceName)),
+		)
+		return handlers.NewIntegrationEventHandlers(
+			reg, application.New(customers), customers,
+			tm.InboxHandler(pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), constants.IntegrationEventHandlersKey),
+		)
+	})
 
 	// setup Driver adapters
 	if err := grpc.RegisterServer(ctx, app, svc.RPC()); err != nil {
@@ -63,5 +69,13 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return err
 	}
 
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
+
 	return nil
 }
@@ -9,6 +9,9 @@ const (
 	CustomersServiceName = "CUSTOMERS"
 )
 
+// DeadLetterChannel receives messages that could not be handled after all redelivery attempts
+const DeadLetterChannel = "mallbots." + ServiceName + ".deadletters"
+
 // Dependency Injection Keys
 const (
 	RegistryKey                 = "registry"
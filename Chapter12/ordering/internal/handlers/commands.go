@@ -13,6 +13,7 @@ import (
 	"eda-in-golang/internal/registry"
 	"eda-in-golang/ordering/internal/application"
 	"eda-in-golang/ordering/internal/application/commands"
+	"eda-in-golang/ordering/internal/constants"
 	"eda-in-golang/ordering/orderingpb"
 )
 
@@ -30,7 +31,7 @@ func RegisterCommandHandlers(subscriber am.MessageSubscriber, handlers am.Messag
 	_, err := subscriber.Subscribe(orderingpb.CommandChannel, handlers, am.MessageFilter{
 		orderingpb.RejectOrderCommand,
 		orderingpb.ApproveOrderCommand,
-	}, am.GroupName("ordering-commands"))
+	}, am.GroupName("ordering-commands"), am.DeadLetterTopic(constants.DeadLetterChannel), am.RedeliveryPolicy(redeliveryPolicy))
 	return err
 }
 
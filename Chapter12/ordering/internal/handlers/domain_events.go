@@ -82,7 +82,7 @@ func (h domainHandlers[T]) onOrderCreated(ctx context.Context, event ddd.Event)
 			PaymentId:  payload.PaymentID,
 			ShoppingId: payload.ShoppingID,
 			Items:      items,
-		}),
+		}, event.Metadata()),
 	)
 }
 
@@ -93,7 +93,7 @@ func (h domainHandlers[T]) onOrderRejected(ctx context.Context, event ddd.Event)
 			Id:         payload.ID(),
 			CustomerId: payload.CustomerID,
 			PaymentId:  payload.PaymentID,
-		}),
+		}, event.Metadata()),
 	)
 }
 
@@ -104,7 +104,7 @@ func (h domainHandlers[T]) onOrderApproved(ctx context.Context, event ddd.Event)
 			Id:         payload.ID(),
 			CustomerId: payload.CustomerID,
 			PaymentId:  payload.PaymentID,
-		}),
+		}, event.Metadata()),
 	)
 }
 
@@ -116,7 +116,7 @@ func (h domainHandlers[T]) onOrderReadied(ctx context.Context, event ddd.Event)
 			CustomerId: payload.CustomerID,
 			PaymentId:  payload.PaymentID,
 			Total:      payload.GetTotal(),
-		}),
+		}, event.Metadata()),
 	)
 }
 
@@ -127,7 +127,7 @@ func (h domainHandlers[T]) onOrderCanceled(ctx context.Context, event ddd.Event)
 			Id:         payload.ID(),
 			CustomerId: payload.CustomerID,
 			PaymentId:  payload.PaymentID,
-		}),
+		}, event.Metadata()),
 	)
 }
 
@@ -138,6 +138,6 @@ func (h domainHandlers[T]) onOrderCompleted(ctx context.Context, event ddd.Event
 			Id:         payload.ID(),
 			CustomerId: payload.CustomerID,
 			InvoiceId:  payload.InvoiceID,
-		}),
+		}, event.Metadata()),
 	)
 }
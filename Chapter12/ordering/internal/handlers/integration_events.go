@@ -15,6 +15,7 @@ import (
 	"eda-in-golang/internal/registry"
 	"eda-in-golang/ordering/internal/application"
 	"eda-in-golang/ordering/internal/application/commands"
+	"eda-in-golang/ordering/internal/constants"
 	"eda-in-golang/ordering/internal/domain"
 )
 
@@ -33,14 +34,14 @@ func NewIntegrationEventHandlers(reg registry.Registry, app application.App, mws
 func RegisterIntegrationEventHandlers(subscriber am.MessageSubscriber, handlers am.MessageHandler) (err error) {
 	_, err = subscriber.Subscribe(basketspb.BasketAggregateChannel, handlers, am.MessageFilter{
 		basketspb.BasketCheckedOutEvent,
-	}, am.GroupName("ordering-baskets"))
+	}, am.GroupName("ordering-baskets"), am.DeadLetterTopic(constants.DeadLetterChannel), am.RedeliveryPolicy(redeliveryPolicy))
 	if err != nil {
 		return err
 	}
 
 	_, err = subscriber.Subscribe(depotpb.ShoppingListAggregateChannel, handlers, am.MessageFilter{
 		depotpb.ShoppingListCompletedEvent,
-	}, am.GroupName("ordering-depot"))
+	}, am.GroupName("ordering-depot"), am.DeadLetterTopic(constants.DeadLetterChannel), am.RedeliveryPolicy(redeliveryPolicy))
 
 	return
 }
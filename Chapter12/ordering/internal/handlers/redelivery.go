@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"time"
+
+	"eda-in-golang/internal/am"
+)
+
+// redeliveryPolicy spaces out the redelivery of messages that failed to be handled
+var redeliveryPolicy = am.WithMaxElapsedTime(
+	am.ExponentialBackoffWithJitter(time.Second, 30*time.Second),
+	5*time.Minute,
+)
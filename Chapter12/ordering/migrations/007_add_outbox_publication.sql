@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE outbox_relay_positions (
+  slot_name  text        NOT NULL,
+  lsn        text        NOT NULL,
+  updated_at timestamptz NOT NULL,
+  PRIMARY KEY (slot_name)
+);
+
+CREATE PUBLICATION ordering_outbox_pub FOR TABLE outbox WITH (publish = 'insert');
+
+-- +goose Down
+SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = 'ordering_outbox_relay';
+DROP PUBLICATION IF EXISTS ordering_outbox_pub;
+DROP TABLE IF EXISTS outbox_relay_positions;
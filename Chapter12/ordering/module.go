@@ -11,10 +11,10 @@ import (
 	"eda-in-golang/internal/am"
 	"eda-in-golang/internal/amotel"
 	"eda-in-golang/internal/amprom"
+	"eda-in-golang/internal/config"
 	"eda-in-golang/internal/ddd"
 	"eda-in-golang/internal/di"
 	"eda-in-golang/internal/es"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -55,7 +55,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddSingleton(constants.DomainDispatcherKey, func(c di.Container) (any, error) {
 		return ddd.NewEventDispatcher[ddd.Event](), nil
 	})
@@ -70,6 +70,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			stream,
 			amotel.OtelMessageContextInjector(),
 			sentCounter,
+			am.DelayedPublisher(),
 			tm.OutboxPublisher(outboxStore),
 		), nil
 	})
@@ -94,8 +95,21 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
+	snapshotOptions, err := pg.SnapshotStrategies(svc.Config().Snapshots, domain.OrderAggregate)
+	if err != nil {
+		return err
+	}
+	if svc.Config().Snapshots.Async {
+		reg := container.Get(constants.RegistryKey).(registry.Registry)
+		snapshotter := pg.NewSnapshotter(constants.SnapshotsTableName, svc.DB(), reg,
+			pg.NewEventStore(constants.EventsTableName, svc.DB(), reg),
+			svc.Logger(),
+		)
+		snapshotOptions = append(snapshotOptions, pg.AsyncSnapshots(snapshotter))
+		svc.Waiter().Add(snapshotter.Run)
+	}
 	container.AddScoped(constants.OrdersRepoKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
 		reg := c.Get(constants.RegistryKey).(registry.Registry)
@@ -104,7 +118,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.RegistryKey).(registry.Registry),
 			es.AggregateStoreWithMiddleware(
 				pg.NewEventStore(constants.EventsTableName, tx, reg),
-				pg.NewSnapshotStore(constants.SnapshotsTableName, tx, reg),
+				pg.NewSnapshotStore(constants.SnapshotsTableName, tx, reg, snapshotOptions...),
 			),
 		), nil
 	})
@@ -123,7 +137,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return handlers.NewIntegrationEventHandlers(
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.ApplicationKey).(application.App),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.IntegrationEventHandlersKey),
 		), nil
 	})
 	container.AddScoped(constants.CommandHandlersKey, func(c di.Container) (any, error) {
@@ -131,13 +145,25 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.RegistryKey).(registry.Registry),
 			c.Get(constants.ApplicationKey).(application.App),
 			c.Get(constants.ReplyPublisherKey).(am.ReplyPublisher),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.CommandHandlersKey),
 		), nil
 	})
-	outboxProcessor := tm.NewOutboxProcessor(
+	outboxStore := pg.NewOutboxStore(constants.OutboxTableName, svc.DB())
+	var outboxProcessors []tm.OutboxProcessor
+	if svc.Config().Outbox.Relay == config.ReplicationOutboxRelay {
+		// the relay leaves scheduled messages to be polled for once they are due
+		outboxStore = outboxStore.Scheduled()
+		outboxProcessors = append(outboxProcessors,
+			pg.NewOutboxRelay(constants.OutboxTableName, svc.Config().PG.Conn, svc.DB(), stream, svc.Logger()),
+		)
+	}
+	outboxProcessors = append(outboxProcessors, tm.NewOutboxProcessor(
+		constants.ServiceName,
 		stream,
-		pg.NewOutboxStore(constants.OutboxTableName, svc.DB()),
-	)
+		outboxStore,
+		svc.Logger(),
+		pg.OutboxProcessorOptions(svc.Config().Outbox, constants.OutboxTableName, svc.DB())...,
+	))
 
 	// setup Driver adapters
 	if err = grpc.RegisterServerTx(container, svc.RPC()); err != nil {
@@ -156,7 +182,16 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	if err = handlers.RegisterCommandHandlersTx(container); err != nil {
 		return err
 	}
-	startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	for _, outboxProcessor := range outboxProcessors {
+		startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	}
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
 
 	return nil
 }
@@ -37,6 +37,20 @@ func NewIntegrationEventHandlers(reg registry.Registry, orders application.Order
 	}, mws...)
 }
 
+// Catalog changes are fetched in batches and each batch is saved in a single transaction
+//
+// The pull consumers use new durable names as an existing push consumer cannot
+// be turned into a pull consumer. They start from the beginning of the stream,
+// the inbox skips what was handled already, and the old "search-products" and
+// "search-stores" consumers may be deleted once they are no longer in use:
+//
+//	nats consumer rm mallbots search-products
+//	nats consumer rm mallbots search-stores
+const (
+	productBatchSize = 50
+	storeBatchSize   = 10
+)
+
 func RegisterIntegrationEventHandlers(subscriber am.MessageSubscriber, handlers am.MessageHandler) (err error) {
 	if _, err = subscriber.Subscribe(customerspb.CustomerAggregateChannel, handlers, am.MessageFilter{
 		customerspb.CustomerRegisteredEvent,
@@ -44,12 +58,14 @@ func RegisterIntegrationEventHandlers(subscriber am.MessageSubscriber, handlers
 		return
 	}
 
+	// the events of an order are handled in order unless one fails; status changes
+	// fail until the order has been added so they are not lost if they overtake it
 	if _, err = subscriber.Subscribe(orderingpb.OrderAggregateChannel, handlers, am.MessageFilter{
 		orderingpb.OrderCreatedEvent,
 		orderingpb.OrderReadiedEvent,
 		orderingpb.OrderCanceledEvent,
 		orderingpb.OrderCompletedEvent,
-	}, am.GroupName("notification-orders")); err != nil {
+	}, am.GroupName("notification-orders"), am.MaxConcurrency(4), am.PartitionByMetadata(ddd.AggregateIDKey)); err != nil {
 		return
 	}
 
@@ -57,14 +73,14 @@ func RegisterIntegrationEventHandlers(subscriber am.MessageSubscriber, handlers
 		storespb.ProductAddedEvent,
 		storespb.ProductRebrandedEvent,
 		storespb.ProductRemovedEvent,
-	}, am.GroupName("search-products")); err != nil {
+	}, am.GroupName("search-products-batch"), am.PullBatchSize(productBatchSize)); err != nil {
 		return
 	}
 
 	if _, err = subscriber.Subscribe(storespb.StoreAggregateChannel, handlers, am.MessageFilter{
 		storespb.StoreCreatedEvent,
 		storespb.StoreRebrandedEvent,
-	}, am.GroupName("search-stores")); err != nil {
+	}, am.GroupName("search-stores-batch"), am.PullBatchSize(storeBatchSize)); err != nil {
 		return
 	}
 
@@ -9,24 +9,44 @@ import (
 	"eda-in-golang/search/internal/constants"
 )
 
-func RegisterIntegrationEventHandlersTx(container di.Container) (err error) {
-	rawMsgHandler := am.MessageHandlerFunc(func(ctx context.Context, msg am.IncomingMessage) (err error) {
-		ctx = container.Scoped(ctx)
-		defer func(tx *sql.Tx) {
-			if p := recover(); p != nil {
-				_ = tx.Rollback()
-				panic(p)
-			} else if err != nil {
-				_ = tx.Rollback()
-			} else {
-				err = tx.Commit()
-			}
-		}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
-
-		return di.Get(ctx, constants.IntegrationEventHandlersKey).(am.MessageHandler).HandleMessage(ctx, msg)
-	})
+// txMessageHandler handles each message, or each batch of messages, within a
+// single database transaction
+type txMessageHandler struct {
+	container di.Container
+}
+
+var _ am.MessageHandler = (*txMessageHandler)(nil)
+var _ am.BatchMessageHandler = (*txMessageHandler)(nil)
 
+func RegisterIntegrationEventHandlersTx(container di.Container) (err error) {
 	subscriber := container.Get(constants.MessageSubscriberKey).(am.MessageSubscriber)
 
-	return RegisterIntegrationEventHandlers(subscriber, rawMsgHandler)
+	return RegisterIntegrationEventHandlers(subscriber, txMessageHandler{container: container})
+}
+
+func (h txMessageHandler) HandleMessage(ctx context.Context, msg am.IncomingMessage) error {
+	return h.HandleMessages(ctx, []am.IncomingMessage{msg})
+}
+
+func (h txMessageHandler) HandleMessages(ctx context.Context, msgs []am.IncomingMessage) (err error) {
+	ctx = h.container.Scoped(ctx)
+	defer func(tx *sql.Tx) {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+	handlers := di.Get(ctx, constants.IntegrationEventHandlersKey).(am.MessageHandler)
+	for _, msg := range msgs {
+		if err = handlers.HandleMessage(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
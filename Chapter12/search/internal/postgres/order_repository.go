@@ -65,8 +65,17 @@ $8)`
 func (r OrderRepository) UpdateStatus(ctx context.Context, orderID, status string) error {
 	const query = `UPDATE %s SET status = $2 WHERE order_id = $1`
 
-	_, err := r.db.ExecContext(ctx, r.table(query), orderID, status)
-	return err
+	result, err := r.db.ExecContext(ctx, r.table(query), orderID, status)
+	if err != nil {
+		return err
+	}
+	// the order is added when it is created, which may be waiting to be redelivered
+	if count, err := result.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return errors.ErrNotFound.Msgf("the order %s has not been added", orderID)
+	}
+	return nil
 }
 
 func (r OrderRepository) Search(ctx context.Context, search application.SearchOrders) ([]*models.Order, error) {
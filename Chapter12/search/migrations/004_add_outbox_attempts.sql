@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE outbox
+  ADD COLUMN attempts int NOT NULL DEFAULT 0,
+  ADD COLUMN last_error text,
+  ADD COLUMN next_attempt_at timestamptz,
+  ADD COLUMN parked_at timestamptz;
+
+-- +goose Down
+ALTER TABLE outbox
+  DROP COLUMN IF EXISTS parked_at,
+  DROP COLUMN IF EXISTS next_attempt_at,
+  DROP COLUMN IF EXISTS last_error,
+  DROP COLUMN IF EXISTS attempts;
@@ -9,7 +9,6 @@ import (
 	"eda-in-golang/internal/amotel"
 	"eda-in-golang/internal/amprom"
 	"eda-in-golang/internal/di"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
 	"eda-in-golang/internal/registry"
@@ -47,7 +46,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddScoped(constants.DatabaseTransactionKey, func(c di.Container) (any, error) {
 		return svc.DB().Begin()
 	})
@@ -60,7 +59,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
 	container.AddScoped(constants.CustomersRepoKey, func(c di.Container) (any, error) {
 		return postgres.NewCustomerCacheRepository(
@@ -103,7 +102,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.CustomersRepoKey).(application.CustomerCacheRepository),
 			c.Get(constants.StoresRepoKey).(application.StoreCacheRepository),
 			c.Get(constants.ProductsRepoKey).(application.ProductCacheRepository),
-			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore)),
+			tm.InboxHandler(c.Get(constants.InboxStoreKey).(tm.InboxStore), constants.IntegrationEventHandlersKey),
 		), nil
 	})
 
@@ -121,5 +120,13 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		return err
 	}
 
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
+
 	return nil
 }
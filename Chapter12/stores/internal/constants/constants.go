@@ -28,8 +28,9 @@ const (
 	CommandHandlersKey          = "commandHandlers"
 	ReplyHandlersKey            = "replyHandlers"
 
-	CatalogHandlersKey = "catalogHandlers"
-	MallHandlersKey    = "mallHandlers"
+	CatalogProjectorKey = "catalogProjector"
+	MallProjectorKey    = "mallProjector"
+	CheckpointStoreKey  = "checkpointStore"
 
 	StoresRepoKey   = "storesRepo"
 	ProductsRepoKey = "productsRepo"
@@ -45,6 +46,14 @@ const (
 	SnapshotsTableName = ServiceName + ".snapshots"
 	SagasTableName     = ServiceName + ".sagas"
 
+	CheckpointsTableName = ServiceName + ".projection_checkpoints"
+
 	CatalogTableName = ServiceName + ".products"
 	MallTableName    = ServiceName + ".stores"
 )
+
+// Projection Names
+const (
+	CatalogProjectionName = ServiceName + ".catalog"
+	MallProjectionName    = ServiceName + ".mall"
+)
@@ -20,4 +20,5 @@ type CatalogRepository interface {
 	RemoveProduct(ctx context.Context, productID string) error
 	Find(ctx context.Context, productID string) (*CatalogProduct, error)
 	GetCatalog(ctx context.Context, storeID string) ([]*CatalogProduct, error)
+	Reset(ctx context.Context) error
 }
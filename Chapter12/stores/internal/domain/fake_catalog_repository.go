@@ -45,3 +45,8 @@ func (r *FakeCatalogRepository) GetCatalog(ctx context.Context, storeID string)
 	// TODO implement me
 	panic("implement me")
 }
+
+func (r *FakeCatalogRepository) Reset(ctx context.Context) error {
+	r.products = map[string]*CatalogProduct{}
+	return nil
+}
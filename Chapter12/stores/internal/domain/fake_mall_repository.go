@@ -45,3 +45,8 @@ func (r *FakeMallRepository) AllParticipating(ctx context.Context) ([]*MallStore
 	// TODO implement me
 	panic("implement me")
 }
+
+func (r *FakeMallRepository) Reset(ctx context.Context) error {
+	r.stores = map[string]*MallStore{}
+	return nil
+}
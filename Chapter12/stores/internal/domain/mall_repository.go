@@ -18,4 +18,5 @@ type MallRepository interface {
 	Find(ctx context.Context, storeID string) (*MallStore, error)
 	All(ctx context.Context) ([]*MallStore, error)
 	AllParticipating(ctx context.Context) ([]*MallStore, error)
+	Reset(ctx context.Context) error
 }
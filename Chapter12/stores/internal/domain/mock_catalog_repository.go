@@ -87,6 +87,20 @@ func (_m *MockCatalogRepository) Rebrand(ctx context.Context, productID string,
 	return r0
 }
 
+// Reset provides a mock function with given fields: ctx
+func (_m *MockCatalogRepository) Reset(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveProduct provides a mock function with given fields: ctx, productID
 func (_m *MockCatalogRepository) RemoveProduct(ctx context.Context, productID string) error {
 	ret := _m.Called(ctx, productID)
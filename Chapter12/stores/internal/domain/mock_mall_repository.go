@@ -110,6 +110,20 @@ func (_m *MockMallRepository) RenameStore(ctx context.Context, storeID string, n
 	return r0
 }
 
+// Reset provides a mock function with given fields: ctx
+func (_m *MockMallRepository) Reset(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetStoreParticipation provides a mock function with given fields: ctx, storeID, participating
 func (_m *MockMallRepository) SetStoreParticipation(ctx context.Context, storeID string, participating bool) error {
 	ret := _m.Called(ctx, storeID, participating)
@@ -8,47 +8,38 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"eda-in-golang/internal/ddd"
-	"eda-in-golang/internal/di"
 	"eda-in-golang/internal/errorsotel"
-	"eda-in-golang/stores/internal/constants"
+	"eda-in-golang/internal/projection"
 	"eda-in-golang/stores/internal/domain"
 )
 
-type catalogHandlers[T ddd.Event] struct {
+type catalogProjector struct {
 	catalog domain.CatalogRepository
 }
 
-var _ ddd.EventHandler[ddd.Event] = (*catalogHandlers[ddd.Event])(nil)
+var _ projection.Projector = (*catalogProjector)(nil)
 
-func NewCatalogHandlers(catalog domain.CatalogRepository) ddd.EventHandler[ddd.Event] {
-	return catalogHandlers[ddd.Event]{
+func NewCatalogProjector(catalog domain.CatalogRepository) projection.Projector {
+	return catalogProjector{
 		catalog: catalog,
 	}
 }
 
-func RegisterCatalogHandlers(subscriber ddd.EventSubscriber[ddd.Event], handlers ddd.EventHandler[ddd.Event]) {
-	subscriber.Subscribe(handlers,
+func (p catalogProjector) Events() []string {
+	return []string{
 		domain.ProductAddedEvent,
 		domain.ProductRebrandedEvent,
 		domain.ProductPriceIncreasedEvent,
 		domain.ProductPriceDecreasedEvent,
 		domain.ProductRemovedEvent,
-	)
+	}
 }
 
-func RegisterCatalogHandlersTx(container di.Container) {
-	handlers := ddd.EventHandlerFunc[ddd.Event](func(ctx context.Context, event ddd.Event) error {
-		catalogHandlers := di.Get(ctx, constants.CatalogHandlersKey).(ddd.EventHandler[ddd.Event])
-
-		return catalogHandlers.HandleEvent(ctx, event)
-	})
-
-	subscriber := container.Get(constants.DomainDispatcherKey).(*ddd.EventDispatcher[ddd.Event])
-
-	RegisterCatalogHandlers(subscriber, handlers)
+func (p catalogProjector) Reset(ctx context.Context) error {
+	return p.catalog.Reset(ctx)
 }
 
-func (h catalogHandlers[T]) HandleEvent(ctx context.Context, event T) (err error) {
+func (p catalogProjector) HandleEvent(ctx context.Context, event ddd.AggregateEvent) (err error) {
 	span := trace.SpanFromContext(ctx)
 	defer func(started time.Time) {
 		if err != nil {
@@ -68,40 +59,32 @@ func (h catalogHandlers[T]) HandleEvent(ctx context.Context, event T) (err error
 
 	switch event.EventName() {
 	case domain.ProductAddedEvent:
-		return h.onProductAdded(ctx, event)
+		return p.onProductAdded(ctx, event)
 	case domain.ProductRebrandedEvent:
-		return h.onProductRebranded(ctx, event)
-	case domain.ProductPriceIncreasedEvent:
-		return h.onProductPriceIncreased(ctx, event)
-	case domain.ProductPriceDecreasedEvent:
-		return h.onProductPriceDecreased(ctx, event)
+		return p.onProductRebranded(ctx, event)
+	case domain.ProductPriceIncreasedEvent, domain.ProductPriceDecreasedEvent:
+		return p.onProductPriceChanged(ctx, event)
 	case domain.ProductRemovedEvent:
-		return h.onProductRemoved(ctx, event)
+		return p.onProductRemoved(ctx, event)
 	}
 	return nil
 }
 
-func (h catalogHandlers[T]) onProductAdded(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Product)
-	return h.catalog.AddProduct(ctx, payload.ID(), payload.StoreID, payload.Name, payload.Description, payload.SKU, payload.Price)
-}
-
-func (h catalogHandlers[T]) onProductRebranded(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Product)
-	return h.catalog.Rebrand(ctx, payload.ID(), payload.Name, payload.Description)
+func (p catalogProjector) onProductAdded(ctx context.Context, event ddd.AggregateEvent) error {
+	payload := event.Payload().(*domain.ProductAdded)
+	return p.catalog.AddProduct(ctx, event.AggregateID(), payload.StoreID, payload.Name, payload.Description, payload.SKU, payload.Price)
 }
 
-func (h catalogHandlers[T]) onProductPriceIncreased(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.ProductPriceDelta)
-	return h.catalog.UpdatePrice(ctx, payload.Product.ID(), payload.Delta)
+func (p catalogProjector) onProductRebranded(ctx context.Context, event ddd.AggregateEvent) error {
+	payload := event.Payload().(*domain.ProductRebranded)
+	return p.catalog.Rebrand(ctx, event.AggregateID(), payload.Name, payload.Description)
 }
 
-func (h catalogHandlers[T]) onProductPriceDecreased(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.ProductPriceDelta)
-	return h.catalog.UpdatePrice(ctx, payload.Product.ID(), payload.Delta)
+func (p catalogProjector) onProductPriceChanged(ctx context.Context, event ddd.AggregateEvent) error {
+	payload := event.Payload().(*domain.ProductPriceChanged)
+	return p.catalog.UpdatePrice(ctx, event.AggregateID(), payload.Delta)
 }
 
-func (h catalogHandlers[T]) onProductRemoved(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Product)
-	return h.catalog.RemoveProduct(ctx, payload.ID())
+func (p catalogProjector) onProductRemoved(ctx context.Context, event ddd.AggregateEvent) error {
+	return p.catalog.RemoveProduct(ctx, event.AggregateID())
 }
@@ -8,46 +8,37 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"eda-in-golang/internal/ddd"
-	"eda-in-golang/internal/di"
 	"eda-in-golang/internal/errorsotel"
-	"eda-in-golang/stores/internal/constants"
+	"eda-in-golang/internal/projection"
 	"eda-in-golang/stores/internal/domain"
 )
 
-type mallHandlers[T ddd.Event] struct {
+type mallProjector struct {
 	mall domain.MallRepository
 }
 
-var _ ddd.EventHandler[ddd.Event] = (*mallHandlers[ddd.Event])(nil)
+var _ projection.Projector = (*mallProjector)(nil)
 
-func NewMallHandlers(mall domain.MallRepository) ddd.EventHandler[ddd.Event] {
-	return mallHandlers[ddd.Event]{
+func NewMallProjector(mall domain.MallRepository) projection.Projector {
+	return mallProjector{
 		mall: mall,
 	}
 }
 
-func RegisterMallHandlers(subscriber ddd.EventSubscriber[ddd.Event], handlers ddd.EventHandler[ddd.Event]) {
-	subscriber.Subscribe(handlers,
+func (p mallProjector) Events() []string {
+	return []string{
 		domain.StoreCreatedEvent,
 		domain.StoreParticipationEnabledEvent,
 		domain.StoreParticipationDisabledEvent,
 		domain.StoreRebrandedEvent,
-	)
+	}
 }
 
-func RegisterMallHandlersTx(container di.Container) {
-	handlers := ddd.EventHandlerFunc[ddd.Event](func(ctx context.Context, event ddd.Event) error {
-		mallHandlers := di.Get(ctx, constants.MallHandlersKey).(ddd.EventHandler[ddd.Event])
-
-		return mallHandlers.HandleEvent(ctx, event)
-	})
-
-	subscriber := container.Get(constants.DomainDispatcherKey).(*ddd.EventDispatcher[ddd.Event])
-
-	RegisterMallHandlers(subscriber, handlers)
+func (p mallProjector) Reset(ctx context.Context) error {
+	return p.mall.Reset(ctx)
 }
 
-func (h mallHandlers[T]) HandleEvent(ctx context.Context, event T) (err error) {
+func (p mallProjector) HandleEvent(ctx context.Context, event ddd.AggregateEvent) (err error) {
 	span := trace.SpanFromContext(ctx)
 	defer func(started time.Time) {
 		if err != nil {
@@ -67,33 +58,26 @@ func (h mallHandlers[T]) HandleEvent(ctx context.Context, event T) (err error) {
 
 	switch event.EventName() {
 	case domain.StoreCreatedEvent:
-		return h.onStoreCreated(ctx, event)
-	case domain.StoreParticipationEnabledEvent:
-		return h.onStoreParticipationEnabled(ctx, event)
-	case domain.StoreParticipationDisabledEvent:
-		return h.onStoreParticipationDisabled(ctx, event)
+		return p.onStoreCreated(ctx, event)
+	case domain.StoreParticipationEnabledEvent, domain.StoreParticipationDisabledEvent:
+		return p.onStoreParticipationToggled(ctx, event)
 	case domain.StoreRebrandedEvent:
-		return h.onStoreRebranded(ctx, event)
+		return p.onStoreRebranded(ctx, event)
 	}
 	return nil
 }
 
-func (h mallHandlers[T]) onStoreCreated(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Store)
-	return h.mall.AddStore(ctx, payload.ID(), payload.Name, payload.Location)
-}
-
-func (h mallHandlers[T]) onStoreParticipationEnabled(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Store)
-	return h.mall.SetStoreParticipation(ctx, payload.ID(), true)
+func (p mallProjector) onStoreCreated(ctx context.Context, event ddd.AggregateEvent) error {
+	payload := event.Payload().(*domain.StoreCreated)
+	return p.mall.AddStore(ctx, event.AggregateID(), payload.Name, payload.Location)
 }
 
-func (h mallHandlers[T]) onStoreParticipationDisabled(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Store)
-	return h.mall.SetStoreParticipation(ctx, payload.ID(), false)
+func (p mallProjector) onStoreParticipationToggled(ctx context.Context, event ddd.AggregateEvent) error {
+	payload := event.Payload().(*domain.StoreParticipationToggled)
+	return p.mall.SetStoreParticipation(ctx, event.AggregateID(), payload.Participating)
 }
 
-func (h mallHandlers[T]) onStoreRebranded(ctx context.Context, event ddd.Event) error {
-	payload := event.Payload().(*domain.Store)
-	return h.mall.RenameStore(ctx, payload.ID(), payload.Name)
+func (p mallProjector) onStoreRebranded(ctx context.Context, event ddd.AggregateEvent) error {
+	payload := event.Payload().(*domain.StoreRebranded)
+	return p.mall.RenameStore(ctx, event.AggregateID(), payload.Name)
 }
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+
+	"eda-in-golang/internal/di"
+	"eda-in-golang/internal/projection"
+	"eda-in-golang/stores/internal/constants"
+)
+
+func NewProjectionWorkTx(container di.Container, projectorKey string) projection.WorkFunc {
+	return func(ctx context.Context, fn func(context.Context, projection.Projector, projection.CheckpointStore) error) (err error) {
+		ctx = container.Scoped(ctx)
+		defer func(tx *sql.Tx) {
+			if p := recover(); p != nil {
+				_ = tx.Rollback()
+				panic(p)
+			} else if err != nil {
+				_ = tx.Rollback()
+			} else {
+				err = tx.Commit()
+			}
+		}(di.Get(ctx, constants.DatabaseTransactionKey).(*sql.Tx))
+
+		return fn(ctx,
+			di.Get(ctx, projectorKey).(projection.Projector),
+			di.Get(ctx, constants.CheckpointStoreKey).(projection.CheckpointStore),
+		)
+	}
+}
@@ -109,6 +109,15 @@ func (r CatalogRepository) GetCatalog(ctx context.Context, storeID string) (prod
 	return products, nil
 }
 
+// Reset removes every product ahead of rebuilding the projection
+func (r CatalogRepository) Reset(ctx context.Context) error {
+	const query = "DELETE FROM %s"
+
+	_, err := r.db.ExecContext(ctx, r.table(query))
+
+	return err
+}
+
 func (r CatalogRepository) table(query string) string {
 	return fmt.Sprintf(query, r.tableName)
 }
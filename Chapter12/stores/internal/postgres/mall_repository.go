@@ -128,6 +128,15 @@ func (r MallRepository) AllParticipating(ctx context.Context) (stores []*domain.
 	return stores, nil
 }
 
+// Reset removes every store ahead of rebuilding the projection
+func (r MallRepository) Reset(ctx context.Context) error {
+	const query = "DELETE FROM %s"
+
+	_, err := r.db.ExecContext(ctx, r.table(query))
+
+	return err
+}
+
 func (r MallRepository) table(query string) string {
 	return fmt.Sprintf(query, r.tableName)
 }
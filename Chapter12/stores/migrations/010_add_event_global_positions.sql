@@ -0,0 +1,43 @@
+-- +goose Up
+ALTER TABLE events
+  ADD COLUMN global_position bigint,
+  ADD COLUMN transaction_id  xid8;
+
+-- the existing events are numbered in the order they occurred, not the order
+-- they happen to be stored in, and are placed before any later transaction
+CREATE SEQUENCE events_global_position_seq OWNED BY events.global_position;
+
+UPDATE events e
+SET global_position = o.global_position,
+    transaction_id  = '0'::xid8
+FROM (SELECT stream_id, stream_name, stream_version,
+             row_number() OVER (ORDER BY occurred_at, stream_version, stream_id) AS global_position
+      FROM events) o
+WHERE (e.stream_id, e.stream_name, e.stream_version) = (o.stream_id, o.stream_name, o.stream_version);
+
+SELECT setval('events_global_position_seq', coalesce(max(global_position), 0) + 1, false) FROM events;
+
+ALTER TABLE events
+  ALTER COLUMN global_position SET DEFAULT nextval('events_global_position_seq'),
+  ALTER COLUMN global_position SET NOT NULL,
+  ALTER COLUMN transaction_id SET DEFAULT pg_current_xact_id(),
+  ALTER COLUMN transaction_id SET NOT NULL;
+
+CREATE UNIQUE INDEX events_global_position_idx ON events (global_position);
+CREATE INDEX events_stream_order_idx ON events (transaction_id, global_position);
+
+-- outbox_notify_trigger notifies on the name of whichever table it is on
+CREATE TRIGGER events_notify_trgr
+  AFTER INSERT
+  ON events
+  FOR EACH STATEMENT EXECUTE PROCEDURE outbox_notify_trigger();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS events_notify_trgr ON events;
+
+DROP INDEX IF EXISTS events_stream_order_idx;
+DROP INDEX IF EXISTS events_global_position_idx;
+
+ALTER TABLE events
+  DROP COLUMN IF EXISTS transaction_id,
+  DROP COLUMN IF EXISTS global_position;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE projection_checkpoints (
+  name                 text        NOT NULL,
+  position             bigint      NOT NULL DEFAULT 0,
+  status               text        NOT NULL,
+  handled              bigint      NOT NULL DEFAULT 0,
+  rebuild_requested_at timestamptz,
+  started_at           timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at           timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS projection_checkpoints;
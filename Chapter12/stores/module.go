@@ -9,12 +9,13 @@ import (
 	"eda-in-golang/internal/am"
 	"eda-in-golang/internal/amotel"
 	"eda-in-golang/internal/amprom"
+	"eda-in-golang/internal/config"
 	"eda-in-golang/internal/ddd"
 	"eda-in-golang/internal/di"
 	"eda-in-golang/internal/es"
-	"eda-in-golang/internal/jetstream"
 	pg "eda-in-golang/internal/postgres"
 	"eda-in-golang/internal/postgresotel"
+	"eda-in-golang/internal/projection"
 	"eda-in-golang/internal/registry"
 	"eda-in-golang/internal/registry/serdes"
 	"eda-in-golang/internal/system"
@@ -49,7 +50,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 		}
 		return reg, nil
 	})
-	stream := jetstream.NewStream(svc.Config().Nats.Stream, svc.JS(), svc.Logger())
+	stream := svc.Stream()
 	container.AddSingleton(constants.DomainDispatcherKey, func(c di.Container) (any, error) {
 		return ddd.NewEventDispatcher[ddd.Event](), nil
 	})
@@ -64,6 +65,7 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			stream,
 			amotel.OtelMessageContextInjector(),
 			sentCounter,
+			am.DelayedPublisher(),
 			tm.OutboxPublisher(outboxStore),
 		), nil
 	})
@@ -82,14 +84,27 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	})
 	container.AddScoped(constants.InboxStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
-		return pg.NewInboxStore(constants.InboxTableName, tx), nil
+		return pg.NewInboxStore(constants.InboxTableName, tx, svc.DB()), nil
 	})
+	snapshotOptions, err := pg.SnapshotStrategies(svc.Config().Snapshots, domain.StoreAggregate, domain.ProductAggregate)
+	if err != nil {
+		return err
+	}
+	if svc.Config().Snapshots.Async {
+		reg := container.Get(constants.RegistryKey).(registry.Registry)
+		snapshotter := pg.NewSnapshotter(constants.SnapshotsTableName, svc.DB(), reg,
+			pg.NewEventStore(constants.EventsTableName, svc.DB(), reg),
+			svc.Logger(),
+		)
+		snapshotOptions = append(snapshotOptions, pg.AsyncSnapshots(snapshotter))
+		svc.Waiter().Add(snapshotter.Run)
+	}
 	container.AddScoped(constants.AggregateStoreKey, func(c di.Container) (any, error) {
 		tx := postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx))
 		reg := c.Get(constants.RegistryKey).(registry.Registry)
 		return es.AggregateStoreWithMiddleware(
 			pg.NewEventStore(constants.EventsTableName, tx, reg),
-			pg.NewSnapshotStore(constants.SnapshotsTableName, tx, reg),
+			pg.NewSnapshotStore(constants.SnapshotsTableName, tx, reg, snapshotOptions...),
 		), nil
 	})
 	container.AddScoped(constants.StoresRepoKey, func(c di.Container) (any, error) {
@@ -129,19 +144,37 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 			c.Get(constants.DomainDispatcherKey).(ddd.EventPublisher[ddd.Event]),
 		), nil
 	})
-	container.AddScoped(constants.CatalogHandlersKey, func(c di.Container) (any, error) {
-		return handlers.NewCatalogHandlers(c.Get(constants.CatalogRepoKey).(domain.CatalogRepository)), nil
+	container.AddScoped(constants.CheckpointStoreKey, func(c di.Container) (any, error) {
+		return pg.NewCheckpointStore(
+			constants.CheckpointsTableName,
+			postgresotel.Trace(c.Get(constants.DatabaseTransactionKey).(*sql.Tx)),
+		), nil
+	})
+	container.AddScoped(constants.CatalogProjectorKey, func(c di.Container) (any, error) {
+		return handlers.NewCatalogProjector(c.Get(constants.CatalogRepoKey).(domain.CatalogRepository)), nil
 	})
-	container.AddScoped(constants.MallHandlersKey, func(c di.Container) (any, error) {
-		return handlers.NewMallHandlers(c.Get(constants.MallRepoKey).(domain.MallRepository)), nil
+	container.AddScoped(constants.MallProjectorKey, func(c di.Container) (any, error) {
+		return handlers.NewMallProjector(c.Get(constants.MallRepoKey).(domain.MallRepository)), nil
 	})
 	container.AddScoped(constants.DomainEventHandlersKey, func(c di.Container) (any, error) {
 		return handlers.NewDomainEventHandlers(c.Get(constants.EventPublisherKey).(am.EventPublisher)), nil
 	})
-	outboxProcessor := tm.NewOutboxProcessor(
+	outboxStore := pg.NewOutboxStore(constants.OutboxTableName, svc.DB())
+	var outboxProcessors []tm.OutboxProcessor
+	if svc.Config().Outbox.Relay == config.ReplicationOutboxRelay {
+		// the relay leaves scheduled messages to be polled for once they are due
+		outboxStore = outboxStore.Scheduled()
+		outboxProcessors = append(outboxProcessors,
+			pg.NewOutboxRelay(constants.OutboxTableName, svc.Config().PG.Conn, svc.DB(), stream, svc.Logger()),
+		)
+	}
+	outboxProcessors = append(outboxProcessors, tm.NewOutboxProcessor(
+		constants.ServiceName,
 		stream,
-		pg.NewOutboxStore(constants.OutboxTableName, svc.DB()),
-	)
+		outboxStore,
+		svc.Logger(),
+		pg.OutboxProcessorOptions(svc.Config().Outbox, constants.OutboxTableName, svc.DB())...,
+	))
 
 	// setup Driver adapters
 	if err = grpc.RegisterServerTx(container, svc.RPC()); err != nil {
@@ -153,13 +186,44 @@ func Root(ctx context.Context, svc system.Service) (err error) {
 	if err = rest.RegisterSwagger(svc.Mux()); err != nil {
 		return err
 	}
-	handlers.RegisterCatalogHandlersTx(container)
-	handlers.RegisterMallHandlersTx(container)
 	handlers.RegisterDomainEventHandlersTx(container)
 	if err = storespb.RegisterAsyncAPI(svc.Mux()); err != nil {
 		return err
 	}
-	startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	for _, outboxProcessor := range outboxProcessors {
+		startOutboxProcessor(ctx, outboxProcessor, svc.Logger())
+	}
+	eventStream := pg.NewEventStore(constants.EventsTableName, svc.DB(), container.Get(constants.RegistryKey).(registry.Registry))
+	eventStreamListener := pg.NewEventStreamListener(constants.EventsTableName, svc.DB())
+	svc.Waiter().Add(
+		projection.NewProjection(
+			constants.CatalogProjectionName,
+			eventStream,
+			handlers.NewProjectionWorkTx(container, constants.CatalogProjectorKey),
+			svc.Logger(),
+			projection.SubscriptionOptions(
+				es.StreamAggregateName(domain.ProductAggregate),
+				es.StreamNotifications(eventStreamListener),
+			),
+		).Run,
+		projection.NewProjection(
+			constants.MallProjectionName,
+			eventStream,
+			handlers.NewProjectionWorkTx(container, constants.MallProjectorKey),
+			svc.Logger(),
+			projection.SubscriptionOptions(
+				es.StreamAggregateName(domain.StoreAggregate),
+				es.StreamNotifications(eventStreamListener),
+			),
+		).Run,
+	)
+	svc.Waiter().Add(tm.NewRetentionJob(
+		constants.ServiceName,
+		svc.Logger(),
+		pg.RetentionPolicies(svc.Config().Retention, constants.OutboxTableName, constants.InboxTableName, svc.DB()),
+		tm.RetentionInterval(svc.Config().Retention.Interval),
+		tm.RetentionBatchSize(svc.Config().Retention.BatchSize),
+	).Run)
 
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/cucumber/godog"
 	"github.com/go-openapi/strfmt"
@@ -78,10 +79,23 @@ func (c *storesFeature) noop() {
 	// noop
 }
 
+// eventually retries the query for a short while; the stores and products are
+// projected from the events after the commands have returned
+func (c *storesFeature) eventually(query func() error) (err error) {
+	for i := 0; i < 20; i++ {
+		if err = query(); err == nil {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return err
+}
+
 func (c *storesFeature) expectAStoreCalledToExist(ctx context.Context, name string) error {
-	var storeID string
-	row := c.db.QueryRow("SELECT id FROM stores.stores WHERE name = $1", name)
-	err := row.Scan(&storeID)
+	err := c.eventually(func() error {
+		var storeID string
+		return c.db.QueryRow("SELECT id FROM stores.stores WHERE name = $1", name).Scan(&storeID)
+	})
 	if err != nil {
 		return errors.ErrNotFound.Msgf("the store `%s` does not exist", name)
 	}
@@ -123,9 +137,10 @@ func (c *storesFeature) expectTheStoreWasCreated(ctx context.Context) error {
 }
 
 func (c *storesFeature) expectAProductCalledToExist(ctx context.Context, name string) error {
-	var productID string
-	row := c.db.QueryRow("SELECT id FROM stores.products WHERE name = $1", name)
-	err := row.Scan(&productID)
+	err := c.eventually(func() error {
+		var productID string
+		return c.db.QueryRow("SELECT id FROM stores.products WHERE name = $1", name).Scan(&productID)
+	})
 	if err != nil {
 		return errors.ErrNotFound.Msgf("the product `%s` does not exist", name)
 	}